@@ -29,33 +29,95 @@ type MissionStageRef struct {
 	Name string `json:"name,omitempty"`
 }
 
+// AircraftRequirement selects the aircraft a task flies. With no type, any ready aircraft
+// with the capabilities matches.
 type AircraftRequirement struct {
-	Type               string   `json:"type,omitempty"`
+	Type string `json:"type,omitempty"`
+	// AlternateTypes are accepted too, in order of preference after Type, so that the task
+	// can fly when the fleet of Type is grounded.
+	AlternateTypes []string `json:"alternateTypes,omitempty"`
+
 	MinFuelLevel       int32    `json:"minFuelLevel,omitempty"`
 	Capabilities       []string `json:"capabilities,omitempty"`
 	RequiredHardpoints int32    `json:"requiredHardpoints,omitempty"`
 	PreferredLocation  string   `json:"preferredLocation,omitempty"`
+
+	// Node pins the task to one aircraft node by name. The assignment planner sets it in
+	// apply mode to the aircraft it planned for the task.
+	Node string `json:"node,omitempty"`
+}
+
+type UnschedulableAction string
+
+const (
+	UnschedulableActionFail     UnschedulableAction = "fail"
+	UnschedulableActionRelax    UnschedulableAction = "relax"
+	UnschedulableActionFallback UnschedulableAction = "fallback"
+)
+
+// UnschedulablePolicy bounds how long a task waits for an aircraft. Once its pod failed
+// to schedule maxAttempts times, or stayed unscheduled for timeout, the action is taken.
+type UnschedulablePolicy struct {
+	// +kubebuilder:validation:Minimum=1
+	MaxAttempts int32            `json:"maxAttempts,omitempty"`
+	Timeout     *metav1.Duration `json:"timeout,omitempty"`
+
+	// Action "fail" fails the task with the last scheduler message. "relax" recreates the
+	// pod without the node pin and the distance and preferredLocation preferences, and
+	// "fallback" recreates it for fallbackAircraftType; if the new pod cannot be scheduled
+	// either, the task fails. Defaults to fail.
+	// +kubebuilder:validation:Enum=fail;relax;fallback
+	Action UnschedulableAction `json:"action,omitempty"`
+
+	FallbackAircraftType string `json:"fallbackAircraftType,omitempty"`
 }
 
+// OperationArea is a circle around Center, or the Polygon when one is given.
 type OperationArea struct {
 	Center GeoCoordinates `json:"center,omitempty"`
-	Radius string         `json:"radius,omitempty"`
+
+	// Radius of the circle, e.g. "150km", "80nm" or "5000m".
+	// +kubebuilder:validation:Pattern=`^\s*[0-9][0-9,]*(\.[0-9]+)?\s*(m|km|nm|nmi|KM|NM)\s*$`
+	Radius string `json:"radius,omitempty"`
+
+	// Polygon lists the vertices of the area boundary; it takes precedence over center and radius.
+	// +kubebuilder:validation:MinItems=3
+	Polygon []GeoCoordinates `json:"polygon,omitempty"`
+}
+
+// Waypoint is a named point on a task route.
+type Waypoint struct {
+	// +kubebuilder:validation:MinLength=1
+	Name        string         `json:"name"`
+	Coordinates GeoCoordinates `json:"coordinates"`
 }
 
 type TaskPhase struct {
-	Name      string           `json:"name,omitempty"`
-	Duration  *metav1.Duration `json:"duration,omitempty"`
-	Waypoints []string         `json:"waypoints,omitempty"`
-	Tactics   string           `json:"tactics,omitempty"`
+	Name     string           `json:"name,omitempty"`
+	Duration *metav1.Duration `json:"duration,omitempty"`
+	// Waypoints are the names of the waypoints flown in this phase, in order. They refer to
+	// taskParams.waypoints or the WaypointSet named by taskParams.waypointSetRef.
+	Waypoints []string `json:"waypoints,omitempty"`
+	Tactics   string   `json:"tactics,omitempty"`
 }
 
 type FlightTaskParams struct {
-	Altitude        string            `json:"altitude,omitempty"`
-	Speed           string            `json:"speed,omitempty"`
-	MissionDuration *metav1.Duration  `json:"missionDuration,omitempty"`
-	OperationArea   *OperationArea    `json:"operationArea,omitempty"`
-	Phases          []TaskPhase       `json:"phases,omitempty"`
-	Extra           map[string]string `json:"extra,omitempty"`
+	Altitude string `json:"altitude,omitempty"`
+	Speed    string `json:"speed,omitempty"`
+
+	// MissionDuration is how long the task may run, counted from the start of its pod.
+	// Once it has passed the pod is terminated and the task fails with DeadlineExceeded.
+	MissionDuration *metav1.Duration `json:"missionDuration,omitempty"`
+
+	OperationArea *OperationArea `json:"operationArea,omitempty"`
+	Phases        []TaskPhase    `json:"phases,omitempty"`
+
+	// Waypoints defines waypoints inline; they take precedence over the WaypointSet.
+	Waypoints []Waypoint `json:"waypoints,omitempty"`
+	// WaypointSetRef names a WaypointSet in the task's namespace to look waypoints up in.
+	WaypointSetRef string `json:"waypointSetRef,omitempty"`
+
+	Extra map[string]string `json:"extra,omitempty"`
 }
 
 type WeaponRef struct {
@@ -77,8 +139,20 @@ type FlightTaskSpec struct {
 	Role       string            `json:"role,omitempty"`
 	TaskParams *FlightTaskParams `json:"taskParams,omitempty"`
 
+	// TargetRef names the target in the Mission objective this task is flown against.
+	TargetRef string `json:"targetRef,omitempty"`
+
 	WeaponLoadout []FlightTaskWeaponLoadoutItem `json:"weaponLoadout,omitempty"`
 
+	// UnschedulablePolicy overrides the manager-wide policy for a pod that cannot be
+	// scheduled; fields it leaves unset keep the manager default.
+	UnschedulablePolicy *UnschedulablePolicy `json:"unschedulablePolicy,omitempty"`
+
+	// ImagePullTimeout is how long a container of the task pod may fail to pull its image
+	// before the task fails; it overrides the manager-wide default. Without either, the
+	// task waits for the image to be fixed.
+	ImagePullTimeout *metav1.Duration `json:"imagePullTimeout,omitempty"`
+
 	// PodTemplate is an optional pod template for executing the task.
 	// Using a schemaless object avoids generating an enormous OpenAPI schema in the CRD.
 	// +kubebuilder:pruning:PreserveUnknownFields
@@ -90,16 +164,75 @@ type SchedulingInfo struct {
 	AssignedNode       string       `json:"assignedNode,omitempty"`
 	AssignedTime       *metav1.Time `json:"assignedTime,omitempty"`
 	SchedulingAttempts int32        `json:"schedulingAttempts,omitempty"`
+
+	// PreferencesRelaxed is set once the pod was recreated without scheduling preferences.
+	PreferencesRelaxed bool `json:"preferencesRelaxed,omitempty"`
+	// FallbackAircraftType is the aircraft type the pod was recreated for.
+	FallbackAircraftType string `json:"fallbackAircraftType,omitempty"`
 }
 
 type ExecutionStatus struct {
-	CurrentPhase     string            `json:"currentPhase,omitempty"`
+	CurrentPhase string `json:"currentPhase,omitempty"`
+	// ReportedPhase is the phase last reported by the task's telemetry. When it names one of
+	// taskParams.phases it drives CurrentPhase; otherwise the phases advance by their
+	// durations from the time the pod started.
+	ReportedPhase string `json:"reportedPhase,omitempty"`
+	// Phases records when each of taskParams.phases started and ended.
+	Phases []PhaseStatus `json:"phases,omitempty"`
+
 	Location         *GeoCoordinates   `json:"location,omitempty"`
 	Altitude         string            `json:"altitude,omitempty"`
 	Speed            string            `json:"speed,omitempty"`
 	FuelRemaining    int32             `json:"fuelRemaining,omitempty"`
 	WeaponsRemaining map[string]int32  `json:"weaponsRemaining,omitempty"`
 	Extra            map[string]string `json:"extra,omitempty"`
+
+	// LastReportTime is when the task last reported telemetry.
+	LastReportTime *metav1.Time `json:"lastReportTime,omitempty"`
+	// Track holds the most recently reported positions, oldest first.
+	Track []TrackPoint `json:"track,omitempty"`
+}
+
+// TrackPoint is a reported position of a task.
+type TrackPoint struct {
+	Time     metav1.Time    `json:"time"`
+	Location GeoCoordinates `json:"location"`
+	Altitude string         `json:"altitude,omitempty"`
+	Speed    string         `json:"speed,omitempty"`
+}
+
+type PhaseStatus struct {
+	Name      string       `json:"name"`
+	StartTime *metav1.Time `json:"startTime,omitempty"`
+	EndTime   *metav1.Time `json:"endTime,omitempty"`
+	// Overrun is set once the phase has lasted longer than its declared duration.
+	Overrun bool `json:"overrun,omitempty"`
+}
+
+// TaskRoute is the route flown by a task, resolved from the waypoints of its phases.
+type TaskRoute struct {
+	Waypoints []RouteWaypoint `json:"waypoints,omitempty"`
+	// DistanceKm is the total length of the route.
+	DistanceKm int32        `json:"distanceKm,omitempty"`
+	Phases     []PhaseRoute `json:"phases,omitempty"`
+}
+
+type RouteWaypoint struct {
+	Name  string `json:"name"`
+	Phase string `json:"phase,omitempty"`
+	// Coordinates in decimal degrees.
+	Coordinates GeoCoordinates `json:"coordinates"`
+	// LegKm is the distance from the previous waypoint; 0 for the first one.
+	LegKm int32 `json:"legKm,omitempty"`
+}
+
+type PhaseRoute struct {
+	Name       string `json:"name"`
+	DistanceKm int32  `json:"distanceKm,omitempty"`
+	// FlightTime is the time needed to fly the phase at taskParams.speed.
+	FlightTime *metav1.Duration `json:"flightTime,omitempty"`
+	// ETA is the time from the start of the route to the last waypoint of the phase.
+	ETA *metav1.Duration `json:"eta,omitempty"`
 }
 
 // FlightTaskStatus defines the observed state of FlightTask
@@ -111,6 +244,18 @@ type FlightTaskStatus struct {
 	PodRef          *corev1.ObjectReference `json:"podRef,omitempty"`
 	ExecutionStatus *ExecutionStatus        `json:"executionStatus,omitempty"`
 
+	// Route is resolved when the task pod is created; it is also given to the task
+	// container as TASK_ROUTE.
+	Route *TaskRoute `json:"route,omitempty"`
+
+	// Outputs are the key/values reported by the task result (see ResultCaptured condition).
+	// Weapon sidecar outputs are prefixed with "<weapon>.".
+	Outputs map[string]string `json:"outputs,omitempty"`
+
+	// WeaponImageFallbacks are the weapons whose sidecars run the fallback image of the
+	// Weapon because their image could not be pulled.
+	WeaponImageFallbacks []string `json:"weaponImageFallbacks,omitempty"`
+
 	Conditions []metav1.Condition `json:"conditions,omitempty"`
 }
 
@@ -118,6 +263,7 @@ type FlightTaskStatus struct {
 //+kubebuilder:resource:shortName=ft
 //+kubebuilder:subresource:status
 //+kubebuilder:printcolumn:name="Phase",type=string,JSONPath=".status.phase",description="Status phase"
+//+kubebuilder:printcolumn:name="Target",type=string,JSONPath=".spec.targetRef",description="Objective target"
 
 // FlightTask is the Schema for the flighttasks API
 type FlightTask struct {
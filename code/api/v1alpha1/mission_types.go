@@ -49,6 +49,8 @@ const (
 	MissionPhaseSucceeded MissionPhase = "已完成"
 	MissionPhaseFailed    MissionPhase = "失败"
 	MissionPhaseCancelled MissionPhase = "已取消"
+	// MissionPhaseSkipped only appears in stage summaries.
+	MissionPhaseSkipped MissionPhase = "已跳过"
 )
 
 type StageExecutionType string
@@ -80,10 +82,36 @@ type MissionObjective struct {
 	TargetCoordinates *GeoCoordinates   `json:"targetCoordinates,omitempty"`
 	TargetDescription string            `json:"targetDescription,omitempty"`
 	Extra             map[string]string `json:"extra,omitempty"`
+
+	// Targets are the aim points of the Mission. Tasks select one with targetRef;
+	// tasks without targetRef use targetCoordinates, or else the highest-priority target.
+	Targets []MissionTarget `json:"targets,omitempty"`
+}
+
+type MissionTarget struct {
+	Name        string          `json:"name"`
+	Coordinates *GeoCoordinates `json:"coordinates,omitempty"`
+
+	// +kubebuilder:validation:Enum=low;medium;high;critical
+	Priority    MissionPriority `json:"priority,omitempty"`
+	Description string          `json:"description,omitempty"`
+
+	// Guidance and Warheads restrict the weapons the assignment planner may use against this target.
+	Guidance []string `json:"guidance,omitempty"`
+	Warheads []string `json:"warheads,omitempty"`
+
+	// Rounds is the number of weapons needed to engage the target; defaults to 1.
+	// +kubebuilder:validation:Minimum=1
+	Rounds int32 `json:"rounds,omitempty"`
 }
 
+// GeoCoordinates is a position in decimal degrees ("39.9042") or degrees, minutes and
+// seconds (`39°54'15"N`, "北纬39°54′15″"). Latitudes beyond ±90 and longitudes beyond
+// ±180 are rejected on admission.
 type GeoCoordinates struct {
-	Latitude  string `json:"latitude,omitempty"`
+	// +kubebuilder:validation:Pattern=`^\s*(北纬|南纬|[NS])?\s*[-+]?(90(\.0+)?(\s*[°º:d ]\s*0?0(\.0+)?(\s*['′:m ]\s*0?0(\.0+)?)?\s*["″s]?)?|[0-8]?[0-9](\.[0-9]+)?(\s*[°º:d ]\s*[0-5]?[0-9](\.[0-9]+)?(\s*['′:m ]\s*[0-5]?[0-9](\.[0-9]+)?)?\s*["″s]?)?)\s*['′m°º]?\s*[NS]?\s*$`
+	Latitude string `json:"latitude,omitempty"`
+	// +kubebuilder:validation:Pattern=`^\s*(东经|西经|[EW])?\s*[-+]?(180(\.0+)?(\s*[°º:d ]\s*0?0(\.0+)?(\s*['′:m ]\s*0?0(\.0+)?)?\s*["″s]?)?|(1[0-7][0-9]|[0-9]?[0-9])(\.[0-9]+)?(\s*[°º:d ]\s*[0-5]?[0-9](\.[0-9]+)?(\s*['′:m ]\s*[0-5]?[0-9](\.[0-9]+)?)?\s*["″s]?)?)\s*['′m°º]?\s*[EW]?\s*$`
 	Longitude string `json:"longitude,omitempty"`
 }
 
@@ -94,6 +122,10 @@ type MissionStageTemplate struct {
 	DependsOn   []string           `json:"dependsOn,omitempty"`
 	Timeout     *metav1.Duration   `json:"timeout,omitempty"`
 
+	// When is a condition over earlier stages, e.g. stages.isr.outputs.targetConfirmed == "true".
+	// It is evaluated once the stage's dependencies are met; a false condition skips the stage.
+	When string `json:"when,omitempty"`
+
 	FlightTasks []MissionStageFlightTaskTemplate `json:"flightTasks,omitempty"`
 }
 
@@ -101,8 +133,24 @@ type MissionConfig struct {
 	FailurePolicy      *FailurePolicy      `json:"failurePolicy,omitempty"`
 	CancellationPolicy *CancellationPolicy `json:"cancellationPolicy,omitempty"`
 	Coordination       *Coordination       `json:"coordination,omitempty"`
+	Simulation         *SimulationConfig   `json:"simulation,omitempty"`
+	Assignment         *AssignmentConfig   `json:"assignment,omitempty"`
+
+	// RangeCheck decides what happens when a task's target is beyond the effective
+	// range of a loaded weapon from the aircraft it was scheduled on, or a leg of its
+	// route is longer than the aircraft's range. Defaults to warn.
+	// +kubebuilder:validation:Enum=ignore;warn;fail
+	RangeCheck RangeCheckPolicy `json:"rangeCheck,omitempty"`
 }
 
+type RangeCheckPolicy string
+
+const (
+	RangeCheckIgnore RangeCheckPolicy = "ignore"
+	RangeCheckWarn   RangeCheckPolicy = "warn"
+	RangeCheckFail   RangeCheckPolicy = "fail"
+)
+
 type FailurePolicy struct {
 	MaxRetries         int32              `json:"maxRetries,omitempty"`
 	RetryStrategy      RetryStrategy      `json:"retryStrategy,omitempty"`
@@ -120,6 +168,62 @@ type Coordination struct {
 	EmergencyFrequency string `json:"emergencyFrequency,omitempty"`
 }
 
+// SimulationConfig runs the Mission through the controllers without creating any pods.
+type SimulationConfig struct {
+	Enabled bool `json:"enabled,omitempty"`
+
+	// TimeScale accelerates simulated time, e.g. 60 plays one simulated minute per real second.
+	// +kubebuilder:validation:Minimum=1
+	TimeScale int32 `json:"timeScale,omitempty"`
+
+	// FailureRate is the percentage of simulated FlightTasks that fail during execution.
+	// +kubebuilder:validation:Minimum=0
+	// +kubebuilder:validation:Maximum=100
+	FailureRate int32 `json:"failureRate,omitempty"`
+
+	// Seed makes injected failures reproducible across runs of the same Mission.
+	Seed int64 `json:"seed,omitempty"`
+}
+
+type AssignmentMode string
+
+const (
+	AssignmentModePlan  AssignmentMode = "plan"
+	AssignmentModeApply AssignmentMode = "apply"
+)
+
+// AssignmentConfig enables the weapon-target assignment planner. It pairs the objective
+// targets with tasks, ready aircraft nodes and Weapons in one greedy pass, by target
+// priority and aircraft distance, and reports the result in status.assignmentPlan.
+type AssignmentConfig struct {
+	// Mode "plan" only reports the assignment (dry run); "apply" also sets targetRef,
+	// weaponLoadout and aircraftRequirement.node on the assigned tasks that do not set
+	// them. Defaults to plan.
+	// +kubebuilder:validation:Enum=plan;apply
+	Mode AssignmentMode `json:"mode,omitempty"`
+
+	// Roles are the task roles the planner may assign; defaults to strike.
+	Roles []string `json:"roles,omitempty"`
+}
+
+type TimeBudgetAction string
+
+const (
+	TimeBudgetActionFail   TimeBudgetAction = "fail"
+	TimeBudgetActionCancel TimeBudgetAction = "cancel"
+)
+
+// TimeBudget bounds the time a Mission may take across all of its stages, counted from
+// status.startTime.
+type TimeBudget struct {
+	Duration metav1.Duration `json:"duration"`
+
+	// Action is what happens when the budget runs out: the Mission is failed or cancelled,
+	// and its unfinished stages and tasks are stopped either way. Defaults to fail.
+	// +kubebuilder:validation:Enum=fail;cancel
+	Action TimeBudgetAction `json:"action,omitempty"`
+}
+
 // MissionSpec defines the desired state of Mission
 type MissionSpec struct {
 	MissionName string `json:"missionName,omitempty"`
@@ -135,6 +239,9 @@ type MissionSpec struct {
 	Stages []MissionStageTemplate `json:"stages,omitempty"`
 
 	Config *MissionConfig `json:"config,omitempty"`
+
+	// TimeBudget is the overall time the Mission may take, on top of the stage timeouts.
+	TimeBudget *TimeBudget `json:"timeBudget,omitempty"`
 }
 
 type MissionStageSummary struct {
@@ -142,6 +249,12 @@ type MissionStageSummary struct {
 	Phase          MissionPhase `json:"phase,omitempty"`
 	StartTime      *metav1.Time `json:"startTime,omitempty"`
 	CompletionTime *metav1.Time `json:"completionTime,omitempty"`
+
+	// EstimatedDuration is how long the stage is expected to run.
+	EstimatedDuration *metav1.Duration `json:"estimatedDuration,omitempty"`
+	// EstimatedCompletionTime is set while the stage has not finished and has an
+	// estimated duration. It is rewritten when the estimate moves by a minute or more.
+	EstimatedCompletionTime *metav1.Time `json:"estimatedCompletionTime,omitempty"`
 }
 
 type MissionStatistics struct {
@@ -152,6 +265,68 @@ type MissionStatistics struct {
 	PendingTasks     int32 `json:"pendingTasks,omitempty"`
 }
 
+// AssignmentPlan is the output of the weapon-target assignment planner.
+type AssignmentPlan struct {
+	ObservedGeneration int64        `json:"observedGeneration,omitempty"`
+	GeneratedTime      *metav1.Time `json:"generatedTime,omitempty"`
+
+	// Coverage is the number of covered targets over the number of targets, e.g. "2/3".
+	Coverage    string             `json:"coverage,omitempty"`
+	Assignments []TaskAssignment   `json:"assignments,omitempty"`
+	Unassigned  []UnassignedTarget `json:"unassigned,omitempty"`
+}
+
+type TaskAssignment struct {
+	Stage    string `json:"stage,omitempty"`
+	Task     string `json:"task,omitempty"`
+	Target   string `json:"target,omitempty"`
+	Aircraft string `json:"aircraft,omitempty"`
+
+	// DistanceKm from the aircraft to the target, when both positions are known.
+	DistanceKm    int32               `json:"distanceKm,omitempty"`
+	WeaponLoadout []WeaponLoadoutItem `json:"weaponLoadout,omitempty"`
+}
+
+// UnassignedTarget explains why no task could be assigned to a target.
+type UnassignedTarget struct {
+	Target string `json:"target,omitempty"`
+	Reason string `json:"reason,omitempty"`
+}
+
+// MissionBudgetStatus tracks a Mission against spec.timeBudget. Unfinished stages are
+// estimated to complete as in status.estimatedCompletionTime.
+type MissionBudgetStatus struct {
+	Deadline *metav1.Time `json:"deadline,omitempty"`
+
+	// Remaining is the time that was left until the deadline when the last stage finished,
+	// negative if it finished late. It is unset while stages run; the time left is then
+	// the time until deadline.
+	Remaining *metav1.Duration `json:"remaining,omitempty"`
+
+	// Slack is the least slack of the unfinished stages, that of the critical path.
+	Slack *metav1.Duration `json:"slack,omitempty"`
+
+	// Late is true when the Mission is estimated to finish after its deadline.
+	Late bool `json:"late,omitempty"`
+
+	// ExceededTime is when the budget ran out and the Mission was stopped.
+	ExceededTime *metav1.Time `json:"exceededTime,omitempty"`
+
+	Stages []StageSlack `json:"stages,omitempty"`
+}
+
+// StageSlack is how long an unfinished stage may overrun its estimated completion before
+// the Mission misses its deadline. The slack is negative for stages that are running late.
+type StageSlack struct {
+	Name                    string           `json:"name"`
+	EstimatedCompletionTime *metav1.Time     `json:"estimatedCompletionTime,omitempty"`
+	LatestCompletionTime    *metav1.Time     `json:"latestCompletionTime,omitempty"`
+	Slack                   *metav1.Duration `json:"slack,omitempty"`
+
+	// Critical marks the stages on the critical path, those with the least slack.
+	Critical bool `json:"critical,omitempty"`
+}
+
 // MissionStatus defines the observed state of Mission
 type MissionStatus struct {
 	// +kubebuilder:validation:Enum=待执行;运行中;已完成;失败;已取消
@@ -163,6 +338,27 @@ type MissionStatus struct {
 	Message        string       `json:"message,omitempty"`
 	StartTime      *metav1.Time `json:"startTime,omitempty"`
 	LastUpdateTime *metav1.Time `json:"lastUpdateTime,omitempty"`
+
+	AssignmentPlan *AssignmentPlan `json:"assignmentPlan,omitempty"`
+
+	// CriticalPath is the chain of stages, along dependsOn, that decides when the Mission
+	// completes; a delay in any of them delays the Mission.
+	CriticalPath []string `json:"criticalPath,omitempty"`
+
+	// EstimatedCompletionTime is when the last stage is expected to complete. A stage is
+	// expected to run for the phase durations of its tasks (bounded by missionDuration),
+	// else the mean run time of the stage in earlier Missions, else its timeout. It is unset
+	// while an unfinished stage has no estimate, and rewritten, with CriticalPath, when it
+	// moves by a minute or more.
+	EstimatedCompletionTime *metav1.Time `json:"estimatedCompletionTime,omitempty"`
+
+	// Progress is the percentage of the estimated run time of the stages that is done.
+	// +kubebuilder:validation:Minimum=0
+	// +kubebuilder:validation:Maximum=100
+	Progress int32 `json:"progress,omitempty"`
+
+	// Budget is set when the Mission has a time budget.
+	Budget *MissionBudgetStatus `json:"budget,omitempty"`
 }
 
 //+kubebuilder:object:root=true
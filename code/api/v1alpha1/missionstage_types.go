@@ -35,6 +35,7 @@ const (
 	MissionStagePhaseRunning   MissionStagePhase = "运行中"
 	MissionStagePhaseSucceeded MissionStagePhase = "已完成"
 	MissionStagePhaseFailed    MissionStagePhase = "失败"
+	MissionStagePhaseSkipped   MissionStagePhase = "已跳过"
 )
 
 type FlightTaskPhase string
@@ -45,6 +46,8 @@ const (
 	FlightTaskPhaseRunning   FlightTaskPhase = "运行中"
 	FlightTaskPhaseSucceeded FlightTaskPhase = "已完成"
 	FlightTaskPhaseFailed    FlightTaskPhase = "失败"
+	// FlightTaskPhaseSkipped is only reported in MissionStage.status; skipped tasks are never created.
+	FlightTaskPhaseSkipped FlightTaskPhase = "已跳过"
 )
 
 type WeaponLoadoutItem struct {
@@ -54,13 +57,48 @@ type WeaponLoadoutItem struct {
 }
 
 type MissionStageFlightTaskTemplate struct {
-	Name          string                `json:"name,omitempty"`
-	Aircraft      string                `json:"aircraft,omitempty"`
-	Role          string                `json:"role,omitempty"`
-	Priority      MissionPriority       `json:"priority,omitempty"`
-	WeaponLoadout []WeaponLoadoutItem   `json:"weaponLoadout,omitempty"`
-	TaskParams    map[string]string     `json:"taskParams,omitempty"`
-	PodTemplate   *runtime.RawExtension `json:"podTemplate,omitempty"`
+	Name string `json:"name,omitempty"`
+	// Aircraft is the aircraft type; shorthand for aircraftRequirement.type, which wins when both are set.
+	Aircraft string `json:"aircraft,omitempty"`
+
+	// AircraftRequirement is copied to the FlightTask as is.
+	AircraftRequirement *AircraftRequirement `json:"aircraftRequirement,omitempty"`
+
+	Role          string              `json:"role,omitempty"`
+	Priority      MissionPriority     `json:"priority,omitempty"`
+	WeaponLoadout []WeaponLoadoutItem `json:"weaponLoadout,omitempty"`
+
+	// Params are the typed task parameters copied to the FlightTask's spec.taskParams.
+	Params *FlightTaskParams `json:"params,omitempty"`
+
+	// TaskParams are free-form parameters added to the FlightTask's taskParams.extra;
+	// entries of params.extra take precedence.
+	TaskParams map[string]string `json:"taskParams,omitempty"`
+
+	PodTemplate *runtime.RawExtension `json:"podTemplate,omitempty"`
+
+	// TargetRef names the Mission objective target this task is flown against.
+	TargetRef string `json:"targetRef,omitempty"`
+
+	// UnschedulablePolicy is copied to the FlightTask.
+	UnschedulablePolicy *UnschedulablePolicy `json:"unschedulablePolicy,omitempty"`
+
+	// ImagePullTimeout is copied to the FlightTask.
+	ImagePullTimeout *metav1.Duration `json:"imagePullTimeout,omitempty"`
+
+	// Count expands the template into N FlightTasks named <name>-1 … <name>-N.
+	// +kubebuilder:validation:Minimum=1
+	Count int32 `json:"count,omitempty"`
+
+	// WithItems expands the template into one FlightTask per item. {{ item.<key> }} and
+	// {{ index }} in targetRef, when, params, taskParams values and the pod template are replaced per item.
+	// Count is ignored when WithItems is set.
+	WithItems []map[string]string `json:"withItems,omitempty"`
+
+	// When is a condition over earlier stages, e.g. stages.isr.outputs.targetConfirmed == "true".
+	// The task is created once the referenced stages finish and the condition holds;
+	// otherwise it is reported as skipped in the stage status.
+	When string `json:"when,omitempty"`
 }
 
 type MissionStageSynchronization struct {
@@ -94,6 +132,9 @@ type MissionStageSpec struct {
 
 	DependsOn []string `json:"dependsOn,omitempty"`
 
+	// When is copied from the Mission stage template and evaluated by the Mission controller.
+	When string `json:"when,omitempty"`
+
 	FlightTasks []MissionStageFlightTaskTemplate `json:"flightTasks,omitempty"`
 
 	Config *MissionStageConfig `json:"config,omitempty"`
@@ -104,12 +145,13 @@ type MissionStageFlightTaskStatus struct {
 	Phase        FlightTaskPhase `json:"phase,omitempty"`
 	AircraftNode string          `json:"aircraftNode,omitempty"`
 	PodName      string          `json:"podName,omitempty"`
+	Target       string          `json:"target,omitempty"`
 	Message      string          `json:"message,omitempty"`
 }
 
 // MissionStageStatus defines the observed state of MissionStage
 type MissionStageStatus struct {
-	// +kubebuilder:validation:Enum=待执行;运行中;已完成;失败
+	// +kubebuilder:validation:Enum=待执行;运行中;已完成;失败;已跳过
 	Phase MissionStagePhase `json:"phase,omitempty"`
 
 	FlightTasksStatus []MissionStageFlightTaskStatus `json:"flightTasksStatus,omitempty"`
@@ -117,6 +159,11 @@ type MissionStageStatus struct {
 	StartTime      *metav1.Time `json:"startTime,omitempty"`
 	CompletionTime *metav1.Time `json:"completionTime,omitempty"`
 	Message        string       `json:"message,omitempty"`
+
+	// Reason is set on a stage that was failed before its tasks finished: StageTimedOut
+	// when it exceeded its timeout, MissionBudgetExceeded when its Mission ran out of its
+	// time budget.
+	Reason string `json:"reason,omitempty"`
 }
 
 //+kubebuilder:object:root=true
@@ -0,0 +1,52 @@
+/*
+Copyright 2026 yydashuai.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// WaypointSetSpec defines the waypoints of a WaypointSet
+type WaypointSetSpec struct {
+	// +kubebuilder:validation:MinItems=1
+	Waypoints []Waypoint `json:"waypoints"`
+}
+
+//+kubebuilder:object:root=true
+//+kubebuilder:resource:shortName=wps
+
+// WaypointSet is a reusable set of named waypoints that FlightTask phases can refer to
+// through taskParams.waypointSetRef.
+type WaypointSet struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec WaypointSetSpec `json:"spec,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+
+// WaypointSetList contains a list of WaypointSet
+type WaypointSetList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []WaypointSet `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&WaypointSet{}, &WaypointSetList{})
+}
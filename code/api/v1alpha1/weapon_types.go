@@ -36,6 +36,11 @@ type WeaponSpecImage struct {
 	Repository string            `json:"repository,omitempty"`
 	Tag        string            `json:"tag,omitempty"`
 	PullPolicy corev1.PullPolicy `json:"pullPolicy,omitempty"`
+
+	// FallbackImage is the full image reference the sidecar is recreated with when the
+	// image has failed to pull for a quarter of the task's imagePullTimeout, or for a
+	// minute if the task has none.
+	FallbackImage string `json:"fallbackImage,omitempty"`
 }
 
 type WeaponSpecifications struct {
@@ -108,6 +113,18 @@ type WeaponCompatibilityCheck struct {
 	LastChecked  *metav1.Time `json:"lastChecked,omitempty"`
 }
 
+// WeaponParsedSpecifications are spec.specifications converted to fixed units.
+type WeaponParsedSpecifications struct {
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+
+	RangeMeters int64 `json:"rangeMeters,omitempty"`
+	WeightKg    int32 `json:"weightKg,omitempty"`
+	SpeedKmh    int32 `json:"speedKmh,omitempty"`
+
+	// Errors lists the specifications that could not be parsed.
+	Errors []string `json:"errors,omitempty"`
+}
+
 // WeaponStatus defines the observed state of Weapon
 type WeaponStatus struct {
 	// +kubebuilder:validation:Enum=可用;更新中;已弃用
@@ -115,6 +132,11 @@ type WeaponStatus struct {
 
 	Usage               *WeaponUsage               `json:"usage,omitempty"`
 	CompatibilityChecks []WeaponCompatibilityCheck `json:"compatibilityChecks,omitempty"`
+
+	Parsed *WeaponParsedSpecifications `json:"parsed,omitempty"`
+
+	// Conditions include ImageConfigured, False while spec.image.repository is empty.
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
 }
 
 //+kubebuilder:object:root=true
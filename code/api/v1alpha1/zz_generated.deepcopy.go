@@ -29,6 +29,11 @@ import (
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *AircraftRequirement) DeepCopyInto(out *AircraftRequirement) {
 	*out = *in
+	if in.AlternateTypes != nil {
+		in, out := &in.AlternateTypes, &out.AlternateTypes
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
 	if in.Capabilities != nil {
 		in, out := &in.Capabilities, &out.Capabilities
 		*out = make([]string, len(*in))
@@ -46,6 +51,57 @@ func (in *AircraftRequirement) DeepCopy() *AircraftRequirement {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AssignmentConfig) DeepCopyInto(out *AssignmentConfig) {
+	*out = *in
+	if in.Roles != nil {
+		in, out := &in.Roles, &out.Roles
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AssignmentConfig.
+func (in *AssignmentConfig) DeepCopy() *AssignmentConfig {
+	if in == nil {
+		return nil
+	}
+	out := new(AssignmentConfig)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AssignmentPlan) DeepCopyInto(out *AssignmentPlan) {
+	*out = *in
+	if in.GeneratedTime != nil {
+		in, out := &in.GeneratedTime, &out.GeneratedTime
+		*out = (*in).DeepCopy()
+	}
+	if in.Assignments != nil {
+		in, out := &in.Assignments, &out.Assignments
+		*out = make([]TaskAssignment, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Unassigned != nil {
+		in, out := &in.Unassigned, &out.Unassigned
+		*out = make([]UnassignedTarget, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AssignmentPlan.
+func (in *AssignmentPlan) DeepCopy() *AssignmentPlan {
+	if in == nil {
+		return nil
+	}
+	out := new(AssignmentPlan)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *CancellationPolicy) DeepCopyInto(out *CancellationPolicy) {
 	*out = *in
@@ -84,6 +140,13 @@ func (in *Coordination) DeepCopy() *Coordination {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ExecutionStatus) DeepCopyInto(out *ExecutionStatus) {
 	*out = *in
+	if in.Phases != nil {
+		in, out := &in.Phases, &out.Phases
+		*out = make([]PhaseStatus, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 	if in.Location != nil {
 		in, out := &in.Location, &out.Location
 		*out = new(GeoCoordinates)
@@ -103,6 +166,17 @@ func (in *ExecutionStatus) DeepCopyInto(out *ExecutionStatus) {
 			(*out)[key] = val
 		}
 	}
+	if in.LastReportTime != nil {
+		in, out := &in.LastReportTime, &out.LastReportTime
+		*out = (*in).DeepCopy()
+	}
+	if in.Track != nil {
+		in, out := &in.Track, &out.Track
+		*out = make([]TrackPoint, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ExecutionStatus.
@@ -200,7 +274,7 @@ func (in *FlightTaskParams) DeepCopyInto(out *FlightTaskParams) {
 	if in.OperationArea != nil {
 		in, out := &in.OperationArea, &out.OperationArea
 		*out = new(OperationArea)
-		**out = **in
+		(*in).DeepCopyInto(*out)
 	}
 	if in.Phases != nil {
 		in, out := &in.Phases, &out.Phases
@@ -209,6 +283,11 @@ func (in *FlightTaskParams) DeepCopyInto(out *FlightTaskParams) {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
+	if in.Waypoints != nil {
+		in, out := &in.Waypoints, &out.Waypoints
+		*out = make([]Waypoint, len(*in))
+		copy(*out, *in)
+	}
 	if in.Extra != nil {
 		in, out := &in.Extra, &out.Extra
 		*out = make(map[string]string, len(*in))
@@ -245,6 +324,16 @@ func (in *FlightTaskSpec) DeepCopyInto(out *FlightTaskSpec) {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
+	if in.UnschedulablePolicy != nil {
+		in, out := &in.UnschedulablePolicy, &out.UnschedulablePolicy
+		*out = new(UnschedulablePolicy)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.ImagePullTimeout != nil {
+		in, out := &in.ImagePullTimeout, &out.ImagePullTimeout
+		*out = new(v1.Duration)
+		**out = **in
+	}
 	if in.PodTemplate != nil {
 		in, out := &in.PodTemplate, &out.PodTemplate
 		*out = new(runtime.RawExtension)
@@ -280,6 +369,23 @@ func (in *FlightTaskStatus) DeepCopyInto(out *FlightTaskStatus) {
 		*out = new(ExecutionStatus)
 		(*in).DeepCopyInto(*out)
 	}
+	if in.Route != nil {
+		in, out := &in.Route, &out.Route
+		*out = new(TaskRoute)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Outputs != nil {
+		in, out := &in.Outputs, &out.Outputs
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.WeaponImageFallbacks != nil {
+		in, out := &in.WeaponImageFallbacks, &out.WeaponImageFallbacks
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
 	if in.Conditions != nil {
 		in, out := &in.Conditions, &out.Conditions
 		*out = make([]v1.Condition, len(*in))
@@ -362,6 +468,46 @@ func (in *Mission) DeepCopyObject() runtime.Object {
 	return nil
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MissionBudgetStatus) DeepCopyInto(out *MissionBudgetStatus) {
+	*out = *in
+	if in.Deadline != nil {
+		in, out := &in.Deadline, &out.Deadline
+		*out = (*in).DeepCopy()
+	}
+	if in.Remaining != nil {
+		in, out := &in.Remaining, &out.Remaining
+		*out = new(v1.Duration)
+		**out = **in
+	}
+	if in.Slack != nil {
+		in, out := &in.Slack, &out.Slack
+		*out = new(v1.Duration)
+		**out = **in
+	}
+	if in.ExceededTime != nil {
+		in, out := &in.ExceededTime, &out.ExceededTime
+		*out = (*in).DeepCopy()
+	}
+	if in.Stages != nil {
+		in, out := &in.Stages, &out.Stages
+		*out = make([]StageSlack, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MissionBudgetStatus.
+func (in *MissionBudgetStatus) DeepCopy() *MissionBudgetStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(MissionBudgetStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *MissionConfig) DeepCopyInto(out *MissionConfig) {
 	*out = *in
@@ -380,6 +526,16 @@ func (in *MissionConfig) DeepCopyInto(out *MissionConfig) {
 		*out = new(Coordination)
 		**out = **in
 	}
+	if in.Simulation != nil {
+		in, out := &in.Simulation, &out.Simulation
+		*out = new(SimulationConfig)
+		**out = **in
+	}
+	if in.Assignment != nil {
+		in, out := &in.Assignment, &out.Assignment
+		*out = new(AssignmentConfig)
+		(*in).DeepCopyInto(*out)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MissionConfig.
@@ -439,6 +595,13 @@ func (in *MissionObjective) DeepCopyInto(out *MissionObjective) {
 			(*out)[key] = val
 		}
 	}
+	if in.Targets != nil {
+		in, out := &in.Targets, &out.Targets
+		*out = make([]MissionTarget, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MissionObjective.
@@ -486,6 +649,11 @@ func (in *MissionSpec) DeepCopyInto(out *MissionSpec) {
 		*out = new(MissionConfig)
 		(*in).DeepCopyInto(*out)
 	}
+	if in.TimeBudget != nil {
+		in, out := &in.TimeBudget, &out.TimeBudget
+		*out = new(TimeBudget)
+		**out = **in
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MissionSpec.
@@ -608,6 +776,11 @@ func (in *MissionStageFlightTaskStatus) DeepCopy() *MissionStageFlightTaskStatus
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *MissionStageFlightTaskTemplate) DeepCopyInto(out *MissionStageFlightTaskTemplate) {
 	*out = *in
+	if in.AircraftRequirement != nil {
+		in, out := &in.AircraftRequirement, &out.AircraftRequirement
+		*out = new(AircraftRequirement)
+		(*in).DeepCopyInto(*out)
+	}
 	if in.WeaponLoadout != nil {
 		in, out := &in.WeaponLoadout, &out.WeaponLoadout
 		*out = make([]WeaponLoadoutItem, len(*in))
@@ -615,6 +788,11 @@ func (in *MissionStageFlightTaskTemplate) DeepCopyInto(out *MissionStageFlightTa
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
+	if in.Params != nil {
+		in, out := &in.Params, &out.Params
+		*out = new(FlightTaskParams)
+		(*in).DeepCopyInto(*out)
+	}
 	if in.TaskParams != nil {
 		in, out := &in.TaskParams, &out.TaskParams
 		*out = make(map[string]string, len(*in))
@@ -627,6 +805,29 @@ func (in *MissionStageFlightTaskTemplate) DeepCopyInto(out *MissionStageFlightTa
 		*out = new(runtime.RawExtension)
 		(*in).DeepCopyInto(*out)
 	}
+	if in.UnschedulablePolicy != nil {
+		in, out := &in.UnschedulablePolicy, &out.UnschedulablePolicy
+		*out = new(UnschedulablePolicy)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.ImagePullTimeout != nil {
+		in, out := &in.ImagePullTimeout, &out.ImagePullTimeout
+		*out = new(v1.Duration)
+		**out = **in
+	}
+	if in.WithItems != nil {
+		in, out := &in.WithItems, &out.WithItems
+		*out = make([]map[string]string, len(*in))
+		for i := range *in {
+			if (*in)[i] != nil {
+				in, out := &(*in)[i], &(*out)[i]
+				*out = make(map[string]string, len(*in))
+				for key, val := range *in {
+					(*out)[key] = val
+				}
+			}
+		}
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MissionStageFlightTaskTemplate.
@@ -758,6 +959,15 @@ func (in *MissionStageSummary) DeepCopyInto(out *MissionStageSummary) {
 		in, out := &in.CompletionTime, &out.CompletionTime
 		*out = (*in).DeepCopy()
 	}
+	if in.EstimatedDuration != nil {
+		in, out := &in.EstimatedDuration, &out.EstimatedDuration
+		*out = new(v1.Duration)
+		**out = **in
+	}
+	if in.EstimatedCompletionTime != nil {
+		in, out := &in.EstimatedCompletionTime, &out.EstimatedCompletionTime
+		*out = (*in).DeepCopy()
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MissionStageSummary.
@@ -855,6 +1065,25 @@ func (in *MissionStatus) DeepCopyInto(out *MissionStatus) {
 		in, out := &in.LastUpdateTime, &out.LastUpdateTime
 		*out = (*in).DeepCopy()
 	}
+	if in.AssignmentPlan != nil {
+		in, out := &in.AssignmentPlan, &out.AssignmentPlan
+		*out = new(AssignmentPlan)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.CriticalPath != nil {
+		in, out := &in.CriticalPath, &out.CriticalPath
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.EstimatedCompletionTime != nil {
+		in, out := &in.EstimatedCompletionTime, &out.EstimatedCompletionTime
+		*out = (*in).DeepCopy()
+	}
+	if in.Budget != nil {
+		in, out := &in.Budget, &out.Budget
+		*out = new(MissionBudgetStatus)
+		(*in).DeepCopyInto(*out)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MissionStatus.
@@ -867,10 +1096,45 @@ func (in *MissionStatus) DeepCopy() *MissionStatus {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MissionTarget) DeepCopyInto(out *MissionTarget) {
+	*out = *in
+	if in.Coordinates != nil {
+		in, out := &in.Coordinates, &out.Coordinates
+		*out = new(GeoCoordinates)
+		**out = **in
+	}
+	if in.Guidance != nil {
+		in, out := &in.Guidance, &out.Guidance
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Warheads != nil {
+		in, out := &in.Warheads, &out.Warheads
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MissionTarget.
+func (in *MissionTarget) DeepCopy() *MissionTarget {
+	if in == nil {
+		return nil
+	}
+	out := new(MissionTarget)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *OperationArea) DeepCopyInto(out *OperationArea) {
 	*out = *in
 	out.Center = in.Center
+	if in.Polygon != nil {
+		in, out := &in.Polygon, &out.Polygon
+		*out = make([]GeoCoordinates, len(*in))
+		copy(*out, *in)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new OperationArea.
@@ -883,6 +1147,70 @@ func (in *OperationArea) DeepCopy() *OperationArea {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PhaseRoute) DeepCopyInto(out *PhaseRoute) {
+	*out = *in
+	if in.FlightTime != nil {
+		in, out := &in.FlightTime, &out.FlightTime
+		*out = new(v1.Duration)
+		**out = **in
+	}
+	if in.ETA != nil {
+		in, out := &in.ETA, &out.ETA
+		*out = new(v1.Duration)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PhaseRoute.
+func (in *PhaseRoute) DeepCopy() *PhaseRoute {
+	if in == nil {
+		return nil
+	}
+	out := new(PhaseRoute)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PhaseStatus) DeepCopyInto(out *PhaseStatus) {
+	*out = *in
+	if in.StartTime != nil {
+		in, out := &in.StartTime, &out.StartTime
+		*out = (*in).DeepCopy()
+	}
+	if in.EndTime != nil {
+		in, out := &in.EndTime, &out.EndTime
+		*out = (*in).DeepCopy()
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PhaseStatus.
+func (in *PhaseStatus) DeepCopy() *PhaseStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(PhaseStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RouteWaypoint) DeepCopyInto(out *RouteWaypoint) {
+	*out = *in
+	out.Coordinates = in.Coordinates
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RouteWaypoint.
+func (in *RouteWaypoint) DeepCopy() *RouteWaypoint {
+	if in == nil {
+		return nil
+	}
+	out := new(RouteWaypoint)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *SchedulingInfo) DeepCopyInto(out *SchedulingInfo) {
 	*out = *in
@@ -902,6 +1230,71 @@ func (in *SchedulingInfo) DeepCopy() *SchedulingInfo {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SimulationConfig) DeepCopyInto(out *SimulationConfig) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SimulationConfig.
+func (in *SimulationConfig) DeepCopy() *SimulationConfig {
+	if in == nil {
+		return nil
+	}
+	out := new(SimulationConfig)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *StageSlack) DeepCopyInto(out *StageSlack) {
+	*out = *in
+	if in.EstimatedCompletionTime != nil {
+		in, out := &in.EstimatedCompletionTime, &out.EstimatedCompletionTime
+		*out = (*in).DeepCopy()
+	}
+	if in.LatestCompletionTime != nil {
+		in, out := &in.LatestCompletionTime, &out.LatestCompletionTime
+		*out = (*in).DeepCopy()
+	}
+	if in.Slack != nil {
+		in, out := &in.Slack, &out.Slack
+		*out = new(v1.Duration)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new StageSlack.
+func (in *StageSlack) DeepCopy() *StageSlack {
+	if in == nil {
+		return nil
+	}
+	out := new(StageSlack)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *TaskAssignment) DeepCopyInto(out *TaskAssignment) {
+	*out = *in
+	if in.WeaponLoadout != nil {
+		in, out := &in.WeaponLoadout, &out.WeaponLoadout
+		*out = make([]WeaponLoadoutItem, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TaskAssignment.
+func (in *TaskAssignment) DeepCopy() *TaskAssignment {
+	if in == nil {
+		return nil
+	}
+	out := new(TaskAssignment)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *TaskPhase) DeepCopyInto(out *TaskPhase) {
 	*out = *in
@@ -927,6 +1320,195 @@ func (in *TaskPhase) DeepCopy() *TaskPhase {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *TaskRoute) DeepCopyInto(out *TaskRoute) {
+	*out = *in
+	if in.Waypoints != nil {
+		in, out := &in.Waypoints, &out.Waypoints
+		*out = make([]RouteWaypoint, len(*in))
+		copy(*out, *in)
+	}
+	if in.Phases != nil {
+		in, out := &in.Phases, &out.Phases
+		*out = make([]PhaseRoute, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TaskRoute.
+func (in *TaskRoute) DeepCopy() *TaskRoute {
+	if in == nil {
+		return nil
+	}
+	out := new(TaskRoute)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *TimeBudget) DeepCopyInto(out *TimeBudget) {
+	*out = *in
+	out.Duration = in.Duration
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TimeBudget.
+func (in *TimeBudget) DeepCopy() *TimeBudget {
+	if in == nil {
+		return nil
+	}
+	out := new(TimeBudget)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *TrackPoint) DeepCopyInto(out *TrackPoint) {
+	*out = *in
+	in.Time.DeepCopyInto(&out.Time)
+	out.Location = in.Location
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TrackPoint.
+func (in *TrackPoint) DeepCopy() *TrackPoint {
+	if in == nil {
+		return nil
+	}
+	out := new(TrackPoint)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *UnassignedTarget) DeepCopyInto(out *UnassignedTarget) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new UnassignedTarget.
+func (in *UnassignedTarget) DeepCopy() *UnassignedTarget {
+	if in == nil {
+		return nil
+	}
+	out := new(UnassignedTarget)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *UnschedulablePolicy) DeepCopyInto(out *UnschedulablePolicy) {
+	*out = *in
+	if in.Timeout != nil {
+		in, out := &in.Timeout, &out.Timeout
+		*out = new(v1.Duration)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new UnschedulablePolicy.
+func (in *UnschedulablePolicy) DeepCopy() *UnschedulablePolicy {
+	if in == nil {
+		return nil
+	}
+	out := new(UnschedulablePolicy)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *Waypoint) DeepCopyInto(out *Waypoint) {
+	*out = *in
+	out.Coordinates = in.Coordinates
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Waypoint.
+func (in *Waypoint) DeepCopy() *Waypoint {
+	if in == nil {
+		return nil
+	}
+	out := new(Waypoint)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *WaypointSet) DeepCopyInto(out *WaypointSet) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new WaypointSet.
+func (in *WaypointSet) DeepCopy() *WaypointSet {
+	if in == nil {
+		return nil
+	}
+	out := new(WaypointSet)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *WaypointSet) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *WaypointSetList) DeepCopyInto(out *WaypointSetList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]WaypointSet, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new WaypointSetList.
+func (in *WaypointSetList) DeepCopy() *WaypointSetList {
+	if in == nil {
+		return nil
+	}
+	out := new(WaypointSetList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *WaypointSetList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *WaypointSetSpec) DeepCopyInto(out *WaypointSetSpec) {
+	*out = *in
+	if in.Waypoints != nil {
+		in, out := &in.Waypoints, &out.Waypoints
+		*out = make([]Waypoint, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new WaypointSetSpec.
+func (in *WaypointSetSpec) DeepCopy() *WaypointSetSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(WaypointSetSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *Weapon) DeepCopyInto(out *Weapon) {
 	*out = *in
@@ -1089,6 +1671,26 @@ func (in *WeaponLoadoutItem) DeepCopy() *WeaponLoadoutItem {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *WeaponParsedSpecifications) DeepCopyInto(out *WeaponParsedSpecifications) {
+	*out = *in
+	if in.Errors != nil {
+		in, out := &in.Errors, &out.Errors
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new WeaponParsedSpecifications.
+func (in *WeaponParsedSpecifications) DeepCopy() *WeaponParsedSpecifications {
+	if in == nil {
+		return nil
+	}
+	out := new(WeaponParsedSpecifications)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *WeaponRef) DeepCopyInto(out *WeaponRef) {
 	*out = *in
@@ -1209,6 +1811,18 @@ func (in *WeaponStatus) DeepCopyInto(out *WeaponStatus) {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
+	if in.Parsed != nil {
+		in, out := &in.Parsed, &out.Parsed
+		*out = new(WeaponParsedSpecifications)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]v1.Condition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new WeaponStatus.
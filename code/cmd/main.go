@@ -17,14 +17,19 @@ limitations under the License.
 package main
 
 import (
+	"bytes"
+	"context"
 	"crypto/tls"
 	"flag"
+	"fmt"
 	"os"
+	"time"
 
 	// Import all Kubernetes client auth plugins (e.g. Azure, GCP, OIDC, etc.)
 	// to ensure that exec-entrypoint and run can make use of them.
 	_ "k8s.io/client-go/plugin/pkg/client/auth"
 
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
 	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
@@ -36,6 +41,8 @@ import (
 
 	airforcev1alpha1 "github.com/yydashuai/mission-system/api/v1alpha1"
 	"github.com/yydashuai/mission-system/internal/controller"
+	"github.com/yydashuai/mission-system/internal/telemetry"
+	"github.com/yydashuai/mission-system/internal/tracing"
 	//+kubebuilder:scaffold:imports
 )
 
@@ -57,6 +64,15 @@ func main() {
 	var probeAddr string
 	var secureMetrics bool
 	var enableHTTP2 bool
+	var otlpEndpoint string
+	var telemetryAddr string
+	var telemetryURL string
+	var telemetryKeyFile string
+	var unschedulableMaxAttempts int
+	var unschedulableTimeout time.Duration
+	var unschedulableAction string
+	var unschedulableFallback string
+	var imagePullTimeout time.Duration
 	flag.StringVar(&metricsAddr, "metrics-bind-address", ":8080", "The address the metric endpoint binds to.")
 	flag.StringVar(&probeAddr, "health-probe-bind-address", ":8081", "The address the probe endpoint binds to.")
 	flag.BoolVar(&enableLeaderElection, "leader-elect", false,
@@ -66,6 +82,26 @@ func main() {
 		"If set the metrics endpoint is served securely")
 	flag.BoolVar(&enableHTTP2, "enable-http2", false,
 		"If set, HTTP/2 will be enabled for the metrics and webhook servers")
+	flag.StringVar(&otlpEndpoint, "otlp-endpoint", os.Getenv("OTEL_EXPORTER_OTLP_ENDPOINT"),
+		"Base URL of the OTLP/HTTP trace receiver (e.g. http://otel-collector:4318). "+
+			"Tracing is disabled when empty.")
+	flag.StringVar(&telemetryAddr, "telemetry-bind-address", "0",
+		"The address the telemetry ingestion endpoint binds to. Use \"0\" to disable it.")
+	flag.StringVar(&telemetryURL, "telemetry-url", "",
+		"Base URL task pods reach the telemetry endpoint at. Defaults to http://<telemetry-bind-address>.")
+	flag.StringVar(&telemetryKeyFile, "telemetry-key-file", "",
+		"File holding the key that signs per-task telemetry tokens. Required when telemetry is enabled.")
+	flag.IntVar(&unschedulableMaxAttempts, "unschedulable-max-attempts", 0,
+		"Default number of failed scheduling attempts after which a task's unschedulable policy applies. "+
+			"0 means no limit.")
+	flag.DurationVar(&unschedulableTimeout, "unschedulable-timeout", 0,
+		"Default time a task's pod may stay unscheduled before its unschedulable policy applies. 0 means no limit.")
+	flag.StringVar(&unschedulableAction, "unschedulable-action", string(airforcev1alpha1.UnschedulableActionFail),
+		"Default action for tasks that stay unschedulable: fail, relax or fallback.")
+	flag.StringVar(&unschedulableFallback, "unschedulable-fallback-aircraft-type", "",
+		"Default aircraft type tasks fall back to with --unschedulable-action=fallback.")
+	flag.DurationVar(&imagePullTimeout, "image-pull-timeout", 0,
+		"Default time a task pod's container may fail to pull its image before the task fails. 0 means no limit.")
 	opts := zap.Options{
 		Development: true,
 	}
@@ -74,6 +110,37 @@ func main() {
 
 	ctrl.SetLogger(zap.New(zap.UseFlagOptions(&opts)))
 
+	unschedulablePolicy := airforcev1alpha1.UnschedulablePolicy{
+		MaxAttempts:          int32(unschedulableMaxAttempts),
+		Action:               airforcev1alpha1.UnschedulableAction(unschedulableAction),
+		FallbackAircraftType: unschedulableFallback,
+	}
+	if unschedulableTimeout > 0 {
+		unschedulablePolicy.Timeout = &metav1.Duration{Duration: unschedulableTimeout}
+	}
+	switch unschedulablePolicy.Action {
+	case airforcev1alpha1.UnschedulableActionFail, airforcev1alpha1.UnschedulableActionRelax, airforcev1alpha1.UnschedulableActionFallback:
+	default:
+		setupLog.Error(fmt.Errorf("unknown action %q", unschedulableAction), "invalid --unschedulable-action")
+		os.Exit(1)
+	}
+
+	shutdownTracing, err := tracing.Setup(tracing.Options{
+		Endpoint:    otlpEndpoint,
+		ServiceName: os.Getenv("OTEL_SERVICE_NAME"),
+	})
+	if err != nil {
+		setupLog.Error(err, "unable to set up tracing")
+		os.Exit(1)
+	}
+	defer func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if err := shutdownTracing(ctx); err != nil {
+			setupLog.Error(err, "failed to flush traces")
+		}
+	}()
+
 	// if the enable-http2 flag is false (the default), http/2 should be disabled
 	// due to its vulnerabilities. More specifically, disabling http/2 will
 	// prevent from being vulnerable to the HTTP/2 Stream Cancelation and
@@ -122,31 +189,61 @@ func main() {
 		os.Exit(1)
 	}
 
+	var telemetryCreds controller.TelemetryCredentials
+	if telemetryAddr != "0" && telemetryAddr != "" {
+		key, err := os.ReadFile(telemetryKeyFile)
+		if err != nil {
+			setupLog.Error(err, "unable to read telemetry key")
+			os.Exit(1)
+		}
+		server, err := telemetry.NewServer(mgr.GetClient(), telemetry.Options{
+			BindAddress: telemetryAddr,
+			URL:         telemetryURL,
+			Key:         bytes.TrimSpace(key),
+		})
+		if err != nil {
+			setupLog.Error(err, "unable to create telemetry server")
+			os.Exit(1)
+		}
+		if err := mgr.Add(server); err != nil {
+			setupLog.Error(err, "unable to add telemetry server")
+			os.Exit(1)
+		}
+		telemetryCreds = server
+	}
+
 	if err = (&controller.MissionReconciler{
-		Client: mgr.GetClient(),
-		Scheme: mgr.GetScheme(),
+		Client:   tracing.WrapClient(mgr.GetClient()),
+		Scheme:   mgr.GetScheme(),
+		Recorder: mgr.GetEventRecorderFor("mission-controller"),
 	}).SetupWithManager(mgr); err != nil {
 		setupLog.Error(err, "unable to create controller", "controller", "Mission")
 		os.Exit(1)
 	}
 	if err = (&controller.MissionStageReconciler{
-		Client: mgr.GetClient(),
-		Scheme: mgr.GetScheme(),
+		Client:   tracing.WrapClient(mgr.GetClient()),
+		Scheme:   mgr.GetScheme(),
+		Recorder: mgr.GetEventRecorderFor("missionstage-controller"),
 	}).SetupWithManager(mgr); err != nil {
 		setupLog.Error(err, "unable to create controller", "controller", "MissionStage")
 		os.Exit(1)
 	}
 	if err = (&controller.FlightTaskReconciler{
-		Client:    mgr.GetClient(),
-		Scheme:    mgr.GetScheme(),
-		APIReader: mgr.GetAPIReader(),
+		Client:              tracing.WrapClient(mgr.GetClient()),
+		Scheme:              mgr.GetScheme(),
+		Recorder:            mgr.GetEventRecorderFor("flighttask-controller"),
+		APIReader:           mgr.GetAPIReader(),
+		Telemetry:           telemetryCreds,
+		UnschedulablePolicy: unschedulablePolicy,
+		ImagePullTimeout:    imagePullTimeout,
 	}).SetupWithManager(mgr); err != nil {
 		setupLog.Error(err, "unable to create controller", "controller", "FlightTask")
 		os.Exit(1)
 	}
 	if err = (&controller.WeaponReconciler{
-		Client: mgr.GetClient(),
-		Scheme: mgr.GetScheme(),
+		Client:   mgr.GetClient(),
+		Scheme:   mgr.GetScheme(),
+		Recorder: mgr.GetEventRecorderFor("weapon-controller"),
 	}).SetupWithManager(mgr); err != nil {
 		setupLog.Error(err, "unable to create controller", "controller", "Weapon")
 		os.Exit(1)
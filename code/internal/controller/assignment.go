@@ -0,0 +1,216 @@
+/*
+Copyright 2026 yydashuai.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strconv"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	airforcev1alpha1 "github.com/yydashuai/mission-system/api/v1alpha1"
+	"github.com/yydashuai/mission-system/internal/planning"
+)
+
+const defaultAssignmentRole = "strike"
+
+// reconcileAssignmentPlan runs the weapon-target assignment planner when the Mission
+// enables it, and records the plan in status. The plan is recomputed only when the
+// Mission spec changes so that applied assignments stay stable while the Mission runs.
+func (r *MissionReconciler) reconcileAssignmentPlan(ctx context.Context, mission *airforcev1alpha1.Mission) error {
+	cfg := assignmentConfig(mission)
+	if cfg == nil {
+		if mission.Status.AssignmentPlan == nil {
+			return nil
+		}
+		patch := client.MergeFrom(mission.DeepCopy())
+		mission.Status.AssignmentPlan = nil
+		return r.Status().Patch(ctx, mission, patch)
+	}
+	if p := mission.Status.AssignmentPlan; p != nil && p.ObservedGeneration == mission.Generation {
+		return nil
+	}
+
+	problem, err := r.assignmentProblem(ctx, mission, cfg)
+	if err != nil {
+		return err
+	}
+	result := planning.Solve(problem)
+
+	now := metav1.Now()
+	plan := &airforcev1alpha1.AssignmentPlan{
+		ObservedGeneration: mission.Generation,
+		GeneratedTime:      &now,
+		Coverage:           fmt.Sprintf("%d/%d", result.Covered, result.Total),
+	}
+	for _, a := range result.Assignments {
+		item := airforcev1alpha1.TaskAssignment{
+			Stage:    a.Stage,
+			Task:     a.Task,
+			Target:   a.Target,
+			Aircraft: a.Aircraft,
+		}
+		if a.DistanceKm >= 0 {
+			item.DistanceKm = int32(math.Round(a.DistanceKm))
+		}
+		for _, l := range a.Weapons {
+			item.WeaponLoadout = append(item.WeaponLoadout, airforcev1alpha1.WeaponLoadoutItem{Weapon: l.Weapon, Quantity: l.Quantity})
+		}
+		plan.Assignments = append(plan.Assignments, item)
+	}
+	for _, u := range result.Unassigned {
+		plan.Unassigned = append(plan.Unassigned, airforcev1alpha1.UnassignedTarget{Target: u.Target, Reason: u.Reason})
+	}
+
+	patch := client.MergeFrom(mission.DeepCopy())
+	mission.Status.AssignmentPlan = plan
+	if err := r.Status().Patch(ctx, mission, patch); err != nil {
+		return err
+	}
+	log.FromContext(ctx).Info("computed assignment plan", "coverage", plan.Coverage, "unassigned", len(plan.Unassigned))
+	return nil
+}
+
+func assignmentConfig(mission *airforcev1alpha1.Mission) *airforcev1alpha1.AssignmentConfig {
+	if mission.Spec.Config == nil {
+		return nil
+	}
+	return mission.Spec.Config.Assignment
+}
+
+// assignmentProblem collects the objective targets, the tasks of every stage, the ready
+// aircraft nodes and the usable Weapons of the Mission's namespace.
+func (r *MissionReconciler) assignmentProblem(ctx context.Context, mission *airforcev1alpha1.Mission, cfg *airforcev1alpha1.AssignmentConfig) (planning.Problem, error) {
+	var problem planning.Problem
+
+	if mission.Spec.Objective != nil {
+		for _, t := range mission.Spec.Objective.Targets {
+			target := planning.Target{
+				Name:     t.Name,
+				Priority: priorityRank(t.Priority),
+				Guidance: t.Guidance,
+				Warheads: t.Warheads,
+				Rounds:   t.Rounds,
+			}
+			if p, err := geoPoint(t.Coordinates); err == nil {
+				target.Location = &p
+			}
+			problem.Targets = append(problem.Targets, target)
+		}
+	}
+
+	roles := cfg.Roles
+	if len(roles) == 0 {
+		roles = []string{defaultAssignmentRole}
+	}
+	for _, stage := range mission.Spec.Stages {
+		for _, tmpl := range normalizeStageFlightTasks(stage.FlightTasks) {
+			if tmpl.TargetRef == "" && !containsString(roles, tmpl.Role) {
+				continue
+			}
+			task := planning.Task{
+				Stage:        stage.Name,
+				Name:         tmpl.Name,
+				AircraftType: templateAircraftType(tmpl),
+				Target:       tmpl.TargetRef,
+			}
+			for _, l := range tmpl.WeaponLoadout {
+				task.Weapons = append(task.Weapons, planning.Loadout{Weapon: l.Weapon, Quantity: l.Quantity})
+			}
+			problem.Tasks = append(problem.Tasks, task)
+		}
+	}
+
+	var nodes corev1.NodeList
+	if err := r.List(ctx, &nodes, client.MatchingLabels{"aircraft.mil/status": "ready"}); err != nil {
+		return problem, fmt.Errorf("failed to list nodes: %w", err)
+	}
+	for _, node := range nodes.Items {
+		aircraft := planning.Aircraft{Name: node.Name, Type: node.Labels["aircraft.mil/type"]}
+		if p, ok := nodeLocation(&node); ok {
+			aircraft.Location = &p
+		}
+		if hp, err := strconv.ParseInt(node.Labels["aircraft.mil/hardpoint.available"], 10, 32); err == nil {
+			aircraft.Hardpoints = int32(hp)
+		}
+		problem.Aircraft = append(problem.Aircraft, aircraft)
+	}
+
+	var weapons airforcev1alpha1.WeaponList
+	if err := r.List(ctx, &weapons, client.InNamespace(mission.Namespace)); err != nil {
+		return problem, fmt.Errorf("failed to list weapons: %w", err)
+	}
+	for _, w := range weapons.Items {
+		if w.Status.Phase == airforcev1alpha1.WeaponPhaseDeprecated {
+			continue
+		}
+		weapon := planning.Weapon{Name: w.Name}
+		if spec := w.Spec.Specifications; spec != nil {
+			weapon.RangeKm = weaponRangeKm(&w)
+			weapon.Guidance = spec.Guidance
+			weapon.Warhead = spec.Warhead
+		}
+		if w.Spec.Resources != nil {
+			weapon.Hardpoints = w.Spec.Resources.Hardpoints
+		}
+		if w.Spec.Compatibility != nil {
+			weapon.AircraftTypes = w.Spec.Compatibility.AircraftTypes
+		}
+		problem.Weapons = append(problem.Weapons, weapon)
+	}
+	return problem, nil
+}
+
+// applyAssignmentPlan fills targetRef, weaponLoadout and the planned aircraft node of a
+// stage's tasks from the plan when the Mission runs the planner in apply mode. Values set
+// in the spec win.
+func applyAssignmentPlan(mission *airforcev1alpha1.Mission, stageName string, tasks []airforcev1alpha1.MissionStageFlightTaskTemplate) []airforcev1alpha1.MissionStageFlightTaskTemplate {
+	cfg := assignmentConfig(mission)
+	plan := mission.Status.AssignmentPlan
+	if cfg == nil || cfg.Mode != airforcev1alpha1.AssignmentModeApply || plan == nil {
+		return tasks
+	}
+	for _, a := range plan.Assignments {
+		if a.Stage != stageName {
+			continue
+		}
+		for i := range tasks {
+			if tasks[i].Name != a.Task {
+				continue
+			}
+			if tasks[i].TargetRef == "" {
+				tasks[i].TargetRef = a.Target
+				if len(tasks[i].WeaponLoadout) == 0 {
+					tasks[i].WeaponLoadout = append([]airforcev1alpha1.WeaponLoadoutItem(nil), a.WeaponLoadout...)
+				}
+			}
+			// 计划的飞机写入调度约束，Pod 只能调度到该飞机
+			if a.Aircraft != "" && (tasks[i].AircraftRequirement == nil || tasks[i].AircraftRequirement.Node == "") {
+				if tasks[i].AircraftRequirement == nil {
+					tasks[i].AircraftRequirement = &airforcev1alpha1.AircraftRequirement{}
+				}
+				tasks[i].AircraftRequirement.Node = a.Aircraft
+			}
+		}
+	}
+	return tasks
+}
@@ -0,0 +1,195 @@
+/*
+Copyright 2026 yydashuai.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	airforcev1alpha1 "github.com/yydashuai/mission-system/api/v1alpha1"
+)
+
+const (
+	// briefingVolumeName is the ConfigMap volume holding the task briefing. It is mounted
+	// without subPath so the kubelet refreshes the file when the ConfigMap changes.
+	briefingVolumeName = "briefing"
+	briefingMountPath  = interfaceMountPath + "/briefing"
+	briefingKey        = "briefing.json"
+)
+
+// taskBriefing is the document task containers read from /interface/briefing/briefing.json.
+type taskBriefing struct {
+	Task         string                                         `json:"task"`
+	Role         string                                         `json:"role,omitempty"`
+	Mission      string                                         `json:"mission,omitempty"`
+	MissionName  string                                         `json:"missionName,omitempty"`
+	MissionType  airforcev1alpha1.MissionType                   `json:"missionType,omitempty"`
+	Priority     airforcev1alpha1.MissionPriority               `json:"priority,omitempty"`
+	Stage        string                                         `json:"stage,omitempty"`
+	Objective    *airforcev1alpha1.MissionObjective             `json:"objective,omitempty"`
+	Target       *airforcev1alpha1.MissionTarget                `json:"target,omitempty"`
+	Coordination *airforcev1alpha1.Coordination                 `json:"coordination,omitempty"`
+	TaskParams   *airforcev1alpha1.FlightTaskParams             `json:"taskParams,omitempty"`
+	Route        *airforcev1alpha1.TaskRoute                    `json:"route,omitempty"`
+	Loadout      []airforcev1alpha1.FlightTaskWeaponLoadoutItem `json:"loadout,omitempty"`
+}
+
+func briefingConfigMapName(task *airforcev1alpha1.FlightTask) string {
+	return task.Name + "-briefing"
+}
+
+// renderBriefing builds the briefing of a task from its Mission and spec. The target is
+// resolved as for TARGET_* and its coordinates are given in decimal degrees.
+func (r *FlightTaskReconciler) renderBriefing(ctx context.Context, task *airforcev1alpha1.FlightTask) (map[string]string, error) {
+	b := taskBriefing{
+		Task:       task.Name,
+		Role:       task.Spec.Role,
+		Stage:      task.Labels["stage"],
+		TaskParams: task.Spec.TaskParams,
+		Route:      task.Status.Route,
+		Loadout:    task.Spec.WeaponLoadout,
+	}
+	mission, err := r.taskMission(ctx, task)
+	if err != nil {
+		return nil, err
+	}
+	if mission != nil {
+		b.Mission = mission.Name
+		b.MissionName = mission.Spec.MissionName
+		b.MissionType = mission.Spec.MissionType
+		b.Priority = mission.Spec.Priority
+		b.Objective = mission.Spec.Objective
+		if mission.Spec.Config != nil {
+			b.Coordination = mission.Spec.Config.Coordination
+		}
+		target, err := resolveTaskTarget(mission, task.Spec.TargetRef)
+		var unknown *unknownTargetError
+		if err != nil && !errors.As(err, &unknown) {
+			return nil, err
+		}
+		if target != nil {
+			b.Target = target.DeepCopy()
+			if p, err := geoPoint(target.Coordinates); err == nil {
+				b.Target.Coordinates = &airforcev1alpha1.GeoCoordinates{
+					Latitude:  decimalDegrees(target.Coordinates.Latitude, p.Lat),
+					Longitude: decimalDegrees(target.Coordinates.Longitude, p.Lon),
+				}
+			}
+		}
+	}
+
+	data, err := json.MarshalIndent(b, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return map[string]string{briefingKey: string(data)}, nil
+}
+
+// syncBriefing creates the briefing ConfigMap of a task, owned by the task, or updates it
+// when the rendered briefing changed. It returns true if an existing briefing was updated.
+func (r *FlightTaskReconciler) syncBriefing(ctx context.Context, task *airforcev1alpha1.FlightTask) (bool, error) {
+	data, err := r.renderBriefing(ctx, task)
+	if err != nil {
+		return false, err
+	}
+
+	var cm corev1.ConfigMap
+	err = r.Get(ctx, client.ObjectKey{Namespace: task.Namespace, Name: briefingConfigMapName(task)}, &cm)
+	if apierrors.IsNotFound(err) {
+		cm = corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace: task.Namespace,
+				Name:      briefingConfigMapName(task),
+				Labels:    map[string]string{"flighttask": task.Name},
+			},
+			Data: data,
+		}
+		if err := controllerutil.SetControllerReference(task, &cm, r.Scheme); err != nil {
+			return false, err
+		}
+		if err := r.Create(ctx, &cm); err != nil && !apierrors.IsAlreadyExists(err) {
+			return false, err
+		}
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	if equality.Semantic.DeepEqual(cm.Data, data) {
+		return false, nil
+	}
+	patch := client.MergeFrom(cm.DeepCopy())
+	cm.Data = data
+	if err := r.Patch(ctx, &cm, patch); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// mountBriefing mounts the briefing ConfigMap read-only in every container and tells the
+// task container where to find it in TASK_BRIEFING.
+func mountBriefing(pod *corev1.Pod, task *airforcev1alpha1.FlightTask) {
+	found := false
+	for i := range pod.Spec.Volumes {
+		if pod.Spec.Volumes[i].Name == briefingVolumeName {
+			found = true
+			break
+		}
+	}
+	if !found {
+		pod.Spec.Volumes = append(pod.Spec.Volumes, corev1.Volume{
+			Name: briefingVolumeName,
+			VolumeSource: corev1.VolumeSource{
+				ConfigMap: &corev1.ConfigMapVolumeSource{
+					LocalObjectReference: corev1.LocalObjectReference{Name: briefingConfigMapName(task)},
+				},
+			},
+		})
+	}
+	ensureVolumeMountAllContainers(&pod.Spec, corev1.VolumeMount{Name: briefingVolumeName, MountPath: briefingMountPath, ReadOnly: true})
+	injectTaskEnv(pod, []corev1.EnvVar{{Name: "TASK_BRIEFING", Value: briefingMountPath + "/" + briefingKey}})
+}
+
+// flightTasksForMission requeues the unfinished tasks of a Mission, so their briefings
+// follow changes to the objective and coordination.
+func (r *FlightTaskReconciler) flightTasksForMission(ctx context.Context, obj client.Object) []reconcile.Request {
+	var tasks airforcev1alpha1.FlightTaskList
+	if err := r.List(ctx, &tasks, client.InNamespace(obj.GetNamespace()), client.MatchingLabels{"mission": obj.GetName()}); err != nil {
+		return nil
+	}
+	var requests []reconcile.Request
+	for i := range tasks.Items {
+		if isFlightTaskFinished(tasks.Items[i].Status.Phase) {
+			continue
+		}
+		requests = append(requests, reconcile.Request{NamespacedName: types.NamespacedName{
+			Namespace: tasks.Items[i].Namespace,
+			Name:      tasks.Items[i].Name,
+		}})
+	}
+	return requests
+}
@@ -0,0 +1,181 @@
+/*
+Copyright 2026 yydashuai.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	airforcev1alpha1 "github.com/yydashuai/mission-system/api/v1alpha1"
+)
+
+// missionTimeBudget returns spec.timeBudget.duration, or 0 if the Mission has none.
+func missionTimeBudget(mission *airforcev1alpha1.Mission) time.Duration {
+	if mission.Spec.TimeBudget == nil || mission.Spec.TimeBudget.Duration.Duration <= 0 {
+		return 0
+	}
+	return mission.Spec.TimeBudget.Duration.Duration
+}
+
+// timeBudgetAction returns spec.timeBudget.action, defaulting to fail.
+func timeBudgetAction(mission *airforcev1alpha1.Mission) airforcev1alpha1.TimeBudgetAction {
+	if mission.Spec.TimeBudget == nil || mission.Spec.TimeBudget.Action == "" {
+		return airforcev1alpha1.TimeBudgetActionFail
+	}
+	return mission.Spec.TimeBudget.Action
+}
+
+// budgetExceeded reports a Mission that was stopped for running out of its time budget.
+func budgetExceeded(mission *airforcev1alpha1.Mission) bool {
+	return mission.Status.Budget != nil && mission.Status.Budget.ExceededTime != nil
+}
+
+// missionBudgetStatus tracks a Mission against its time budget, or returns nil if it has
+// none or has not started. stages is keyed by stage name as in spec.stages.
+func missionBudgetStatus(mission *airforcev1alpha1.Mission, stages map[string]*airforcev1alpha1.MissionStage, schedule *missionSchedule, now time.Time) *airforcev1alpha1.MissionBudgetStatus {
+	budget := missionTimeBudget(mission)
+	if budget == 0 || mission.Status.StartTime == nil {
+		return nil
+	}
+	deadline := mission.Status.StartTime.Add(budget)
+	estimated, latest := schedule.completion, schedule.latestCompletion(deadline)
+
+	status := &airforcev1alpha1.MissionBudgetStatus{Deadline: &metav1.Time{Time: deadline}}
+	var end time.Time
+	var minSlack time.Duration
+	for _, tmpl := range mission.Spec.Stages {
+		if tmpl.Name == "" {
+			continue
+		}
+		if stage := stages[tmpl.Name]; stage != nil && isStageFinished(stage.Status.Phase) {
+			if stage.Status.CompletionTime != nil && stage.Status.CompletionTime.After(end) {
+				end = stage.Status.CompletionTime.Time
+			}
+			continue
+		}
+		slack := latest[tmpl.Name].Sub(estimated[tmpl.Name]).Truncate(time.Second)
+		if len(status.Stages) == 0 || slack < minSlack {
+			minSlack = slack
+		}
+		status.Stages = append(status.Stages, airforcev1alpha1.StageSlack{
+			Name:                    tmpl.Name,
+			EstimatedCompletionTime: &metav1.Time{Time: estimated[tmpl.Name]},
+			LatestCompletionTime:    &metav1.Time{Time: latest[tmpl.Name]},
+			Slack:                   &metav1.Duration{Duration: slack},
+		})
+	}
+	// 剩余时间只在最后一个阶段结束时记录一次，运行中由 deadline 推算，避免每次调谐都改写状态
+	if len(status.Stages) == 0 && !end.IsZero() {
+		status.Remaining = &metav1.Duration{Duration: deadline.Sub(end).Truncate(time.Second)}
+	}
+	if len(status.Stages) > 0 {
+		status.Slack = &metav1.Duration{Duration: minSlack}
+		status.Late = minSlack < 0
+		for i := range status.Stages {
+			status.Stages[i].Critical = status.Stages[i].Slack.Duration == minSlack
+		}
+	}
+	return status
+}
+
+// budgetChanged reports whether cur differs from prev by more than the drift of its slack
+// estimates: a new deadline, lateness, stopped or frozen budget, a change of the unfinished
+// or critical stages, or slack that moved by estimateRefreshInterval or more.
+func budgetChanged(prev, cur *airforcev1alpha1.MissionBudgetStatus) bool {
+	if prev == nil || cur == nil {
+		return prev != cur
+	}
+	if !prev.Deadline.Equal(cur.Deadline) || !prev.ExceededTime.Equal(cur.ExceededTime) || prev.Late != cur.Late ||
+		!durationEqual(prev.Remaining, cur.Remaining) || slackMoved(prev.Slack, cur.Slack) || len(prev.Stages) != len(cur.Stages) {
+		return true
+	}
+	for i := range cur.Stages {
+		p, c := prev.Stages[i], cur.Stages[i]
+		if p.Name != c.Name || p.Critical != c.Critical || slackMoved(p.Slack, c.Slack) {
+			return true
+		}
+	}
+	return false
+}
+
+func durationEqual(a, b *metav1.Duration) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return a.Duration == b.Duration
+}
+
+func slackMoved(a, b *metav1.Duration) bool {
+	if a == nil || b == nil {
+		return a != b
+	}
+	d := a.Duration - b.Duration
+	return d >= estimateRefreshInterval || -d >= estimateRefreshInterval
+}
+
+// enforceTimeBudget stops a Mission that ran out of its time budget by failing its
+// unfinished stages; the MissionStage controller then stops their tasks. stages is keyed
+// by MissionStage name. It returns whether the Mission is out of budget: it ran out in
+// this reconcile, or was stopped before. A Mission whose stages all finished in time is
+// never stopped.
+func (r *MissionReconciler) enforceTimeBudget(ctx context.Context, mission *airforcev1alpha1.Mission, stages map[string]*airforcev1alpha1.MissionStage) (bool, error) {
+	budget := missionTimeBudget(mission)
+	if budget == 0 || mission.Status.StartTime == nil {
+		return false, nil
+	}
+	exceeded := budgetExceeded(mission)
+	if !exceeded && time.Since(mission.Status.StartTime.Time) <= budget {
+		return false, nil
+	}
+
+	stopped := 0
+	for _, stage := range stages {
+		if isStageFinished(stage.Status.Phase) {
+			continue
+		}
+		wasRunning := stage.Status.Phase == airforcev1alpha1.MissionStagePhaseRunning
+		patch := client.MergeFrom(stage.DeepCopy())
+		stage.Status.Phase = airforcev1alpha1.MissionStagePhaseFailed
+		stage.Status.Reason = reasonMissionBudgetExceeded
+		stage.Status.Message = missionBudgetExceededMessage
+		now := metav1.Now()
+		stage.Status.CompletionTime = &now
+		if err := r.Status().Patch(ctx, stage, patch); err != nil {
+			return false, err
+		}
+		if wasRunning {
+			if err := observeStageFinished(ctx, r.Client, stage); err != nil {
+				return false, err
+			}
+		}
+		stopped++
+	}
+	if !exceeded && stopped > 0 {
+		recordWarning(r.Recorder, mission, eventReasonBudgetExceeded, "Mission exceeded its time budget of %s, action %s: stopped %d stage(s)",
+			budget, timeBudgetAction(mission), stopped)
+	}
+	return exceeded || stopped > 0, nil
+}
+
+// budgetExceededMessage is the Mission message once it ran out of its time budget.
+func budgetExceededMessage(mission *airforcev1alpha1.Mission) string {
+	return fmt.Sprintf("%s: the Mission did not finish within %s", missionBudgetExceededMessage, missionTimeBudget(mission))
+}
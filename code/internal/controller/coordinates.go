@@ -0,0 +1,87 @@
+/*
+Copyright 2026 yydashuai.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+
+	airforcev1alpha1 "github.com/yydashuai/mission-system/api/v1alpha1"
+	"github.com/yydashuai/mission-system/internal/geo"
+	"github.com/yydashuai/mission-system/internal/units"
+)
+
+// geoPoint parses GeoCoordinates given in decimal degrees or degrees, minutes and seconds.
+func geoPoint(coords *airforcev1alpha1.GeoCoordinates) (geo.Point, error) {
+	if coords == nil {
+		return geo.Point{}, fmt.Errorf("coordinates not set")
+	}
+	return geo.ParsePoint(coords.Latitude, coords.Longitude)
+}
+
+// nodeLocation reads the aircraft.mil/location.* labels of an aircraft node.
+func nodeLocation(node *corev1.Node) (geo.Point, bool) {
+	p, err := geo.ParsePoint(node.Labels["aircraft.mil/location.latitude"], node.Labels["aircraft.mil/location.longitude"])
+	return p, err == nil
+}
+
+// operationArea converts an OperationArea to a geo.Area: its polygon if it has one,
+// else the circle around its center. It returns nil for an unset area.
+func operationArea(area *airforcev1alpha1.OperationArea) (geo.Area, error) {
+	if area == nil {
+		return nil, nil
+	}
+	if len(area.Polygon) != 0 {
+		poly := make(geo.Polygon, 0, len(area.Polygon))
+		for i := range area.Polygon {
+			p, err := geoPoint(&area.Polygon[i])
+			if err != nil {
+				return nil, fmt.Errorf("polygon[%d]: %w", i, err)
+			}
+			poly = append(poly, p)
+		}
+		if err := poly.Validate(); err != nil {
+			return nil, err
+		}
+		return poly, nil
+	}
+	if area.Radius == "" {
+		return nil, fmt.Errorf("radius or polygon is required")
+	}
+	center, err := geoPoint(&area.Center)
+	if err != nil {
+		return nil, fmt.Errorf("center: %w", err)
+	}
+	meters, err := units.ParseDistance(area.Radius)
+	if err != nil {
+		return nil, err
+	}
+	return geo.Circle{Center: center, RadiusKm: meters / 1000}, nil
+}
+
+// taskOperationArea returns the operation area of a task, or nil if it has none.
+func taskOperationArea(task *airforcev1alpha1.FlightTask) (geo.Area, error) {
+	if task.Spec.TaskParams == nil {
+		return nil, nil
+	}
+	area, err := operationArea(task.Spec.TaskParams.OperationArea)
+	if err != nil {
+		return nil, fmt.Errorf("invalid taskParams.operationArea: %w", err)
+	}
+	return area, nil
+}
@@ -0,0 +1,94 @@
+/*
+Copyright 2026 yydashuai.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"fmt"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	apimeta "k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	airforcev1alpha1 "github.com/yydashuai/mission-system/api/v1alpha1"
+)
+
+// conditionDeadlineExceeded is True once a task ran for longer than its missionDuration,
+// or its pod was stopped by the kubelet for exceeding activeDeadlineSeconds.
+const conditionDeadlineExceeded = "DeadlineExceeded"
+
+// podReasonDeadlineExceeded is the pod status reason the kubelet sets when
+// activeDeadlineSeconds has passed.
+const podReasonDeadlineExceeded = "DeadlineExceeded"
+
+// taskDeadline returns taskParams.missionDuration, or 0 if the task has none.
+func taskDeadline(task *airforcev1alpha1.FlightTask) time.Duration {
+	if task.Spec.TaskParams == nil || task.Spec.TaskParams.MissionDuration == nil {
+		return 0
+	}
+	if d := task.Spec.TaskParams.MissionDuration.Duration; d > 0 {
+		return d
+	}
+	return 0
+}
+
+// syncDeadlineCondition checks the running time of a task's pod against its deadline and
+// records the outcome in the DeadlineExceeded condition. It returns whether the condition
+// changed and whether the deadline has passed, in which case the task must be failed and
+// its pod terminated.
+func syncDeadlineCondition(task *airforcev1alpha1.FlightTask, pod *corev1.Pod, now time.Time) (bool, bool) {
+	cond := metav1.Condition{
+		Type:               conditionDeadlineExceeded,
+		Status:             metav1.ConditionFalse,
+		Reason:             "WithinDeadline",
+		ObservedGeneration: task.Generation,
+	}
+	deadline := taskDeadline(task)
+	switch {
+	case pod.Status.Phase == corev1.PodFailed && pod.Status.Reason == podReasonDeadlineExceeded:
+		cond.Status, cond.Reason = metav1.ConditionTrue, "ActiveDeadlineExceeded"
+		cond.Message = fmt.Sprintf("pod %s was stopped by the kubelet: %s", pod.Name, pod.Status.Message)
+	case deadline == 0 || pod.Status.StartTime == nil:
+		return false, false
+	case pod.Status.Phase == corev1.PodSucceeded || pod.Status.Phase == corev1.PodFailed:
+		// 已结束的 Pod 不再判定超时，保留已有结论
+		if existing := apimeta.FindStatusCondition(task.Status.Conditions, conditionDeadlineExceeded); existing != nil {
+			return false, existing.Status == metav1.ConditionTrue
+		}
+		return false, false
+	case now.Sub(pod.Status.StartTime.Time) > deadline:
+		cond.Status, cond.Reason = metav1.ConditionTrue, "MissionDurationExceeded"
+		cond.Message = fmt.Sprintf("task ran for longer than its missionDuration of %s", deadline)
+	default:
+		cond.Message = fmt.Sprintf("task must finish by %s", pod.Status.StartTime.Add(deadline).UTC().Format(time.RFC3339))
+	}
+	// 超时结论一旦成立不再回退
+	if existing := apimeta.FindStatusCondition(task.Status.Conditions, conditionDeadlineExceeded); existing != nil &&
+		existing.Status == metav1.ConditionTrue {
+		return false, true
+	}
+	cond.LastTransitionTime = metav1.NewTime(now)
+	changed := apimeta.SetStatusCondition(&task.Status.Conditions, cond)
+	return changed, cond.Status == metav1.ConditionTrue
+}
+
+// deadlineExceeded reports a task that was failed for running past its deadline.
+func deadlineExceeded(task *airforcev1alpha1.FlightTask) bool {
+	cond := apimeta.FindStatusCondition(task.Status.Conditions, conditionDeadlineExceeded)
+	return task.Status.Phase == airforcev1alpha1.FlightTaskPhaseFailed &&
+		cond != nil && cond.Status == metav1.ConditionTrue
+}
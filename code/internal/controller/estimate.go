@@ -0,0 +1,310 @@
+/*
+Copyright 2026 yydashuai.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	airforcev1alpha1 "github.com/yydashuai/mission-system/api/v1alpha1"
+)
+
+// estimateRefreshInterval is how far an estimate of a Mission (a completion time, or the
+// slack of its time budget) must move before it is rewritten. Smaller drifts, as the
+// estimates of pending and overrunning stages follow the clock, keep the previous values so
+// the Mission status is not rewritten on every reconcile.
+const estimateRefreshInterval = time.Minute
+
+// taskTemplateDuration estimates how long a task runs: the sum of its phase durations,
+// bounded by its missionDuration, or 0 if it declares neither.
+func taskTemplateDuration(tmpl airforcev1alpha1.MissionStageFlightTaskTemplate) time.Duration {
+	params := templateTaskParams(tmpl)
+	if params == nil {
+		return 0
+	}
+	var d time.Duration
+	for _, phase := range params.Phases {
+		if phase.Duration != nil && phase.Duration.Duration > 0 {
+			d += phase.Duration.Duration
+		}
+	}
+	if limit := params.MissionDuration; limit != nil && limit.Duration > 0 && (d == 0 || limit.Duration < d) {
+		d = limit.Duration
+	}
+	return d
+}
+
+// stageHistory is the mean run time of the stages that succeeded in other Missions of the
+// namespace, by stage name.
+type stageHistory map[string]time.Duration
+
+// stageHistory collects the run times of the succeeded stages of the other Missions in the
+// namespace of mission.
+func (r *MissionReconciler) stageHistory(ctx context.Context, mission *airforcev1alpha1.Mission) (stageHistory, error) {
+	var stages airforcev1alpha1.MissionStageList
+	if err := r.List(ctx, &stages, client.InNamespace(mission.Namespace)); err != nil {
+		return nil, err
+	}
+	total := map[string]time.Duration{}
+	count := map[string]int{}
+	for _, stage := range stages.Items {
+		if stage.Labels["mission"] == mission.Name || stage.Labels["stage-name"] == "" ||
+			stage.Status.Phase != airforcev1alpha1.MissionStagePhaseSucceeded ||
+			stage.Status.StartTime == nil || stage.Status.CompletionTime == nil {
+			continue
+		}
+		name := stage.Labels["stage-name"]
+		total[name] += stage.Status.CompletionTime.Sub(stage.Status.StartTime.Time)
+		count[name]++
+	}
+	history := make(stageHistory, len(total))
+	for name, d := range total {
+		history[name] = d / time.Duration(count[name])
+	}
+	return history, nil
+}
+
+// stageDuration estimates how long a stage runs, from the first of: the durations its
+// tasks declare (summed for sequential stages, the longest otherwise), the mean run time
+// of the stage in earlier Missions, and its timeout. The timeout also bounds the estimate.
+func stageDuration(tmpl airforcev1alpha1.MissionStageTemplate, history stageHistory) time.Duration {
+	var d time.Duration
+	// normalizeStageFlightTasks 已按 count / withItems 展开架次
+	for _, task := range normalizeStageFlightTasks(tmpl.FlightTasks) {
+		td := taskTemplateDuration(task)
+		switch {
+		case tmpl.Type == airforcev1alpha1.StageExecutionTypeSequential:
+			d += td
+		case td > d:
+			d = td
+		}
+	}
+	if d == 0 {
+		d = history[tmpl.Name]
+	}
+	if tmpl.Timeout != nil && tmpl.Timeout.Duration > 0 && (d == 0 || tmpl.Timeout.Duration < d) {
+		d = tmpl.Timeout.Duration
+	}
+	return d
+}
+
+// missionSchedule is the estimated timeline of the stages of a Mission.
+type missionSchedule struct {
+	order     []airforcev1alpha1.MissionStageTemplate
+	durations map[string]time.Duration
+	// completion is when each stage completed, or is estimated to complete.
+	completion map[string]time.Time
+	// after is the dependency that completes last before each stage, the one that decides
+	// when the stage can start.
+	after map[string]string
+}
+
+// stageOrder sorts the stage templates so that every stage follows its dependencies.
+// Unknown dependencies are ignored, and cycles are broken where they are found.
+func stageOrder(templates []airforcev1alpha1.MissionStageTemplate) []airforcev1alpha1.MissionStageTemplate {
+	byName := make(map[string]airforcev1alpha1.MissionStageTemplate, len(templates))
+	for _, tmpl := range templates {
+		if tmpl.Name != "" {
+			byName[tmpl.Name] = tmpl
+		}
+	}
+	visited := make(map[string]bool, len(byName))
+	order := make([]airforcev1alpha1.MissionStageTemplate, 0, len(byName))
+	var visit func(name string)
+	visit = func(name string) {
+		tmpl, ok := byName[name]
+		if !ok || visited[name] {
+			return
+		}
+		visited[name] = true
+		for _, dep := range tmpl.DependsOn {
+			visit(dep)
+		}
+		order = append(order, tmpl)
+	}
+	for _, tmpl := range templates {
+		visit(tmpl.Name)
+	}
+	return order
+}
+
+// scheduleMission estimates when each stage of a Mission completes. Finished stages
+// completed at their completionTime; running ones complete their estimated duration after
+// they started, or now if that has passed; pending ones start once their dependencies
+// complete. stages is keyed by stage name as in spec.stages.
+func scheduleMission(mission *airforcev1alpha1.Mission, stages map[string]*airforcev1alpha1.MissionStage, history stageHistory, now time.Time) *missionSchedule {
+	s := &missionSchedule{
+		order:      stageOrder(mission.Spec.Stages),
+		durations:  map[string]time.Duration{},
+		completion: map[string]time.Time{},
+		after:      map[string]string{},
+	}
+	for _, tmpl := range s.order {
+		d := stageDuration(tmpl, history)
+		s.durations[tmpl.Name] = d
+		for _, dep := range tmpl.DependsOn {
+			if t, ok := s.completion[dep]; ok && (s.after[tmpl.Name] == "" || t.After(s.completion[s.after[tmpl.Name]])) {
+				s.after[tmpl.Name] = dep
+			}
+		}
+
+		stage := stages[tmpl.Name]
+		switch {
+		case stage != nil && isStageFinished(stage.Status.Phase):
+			s.completion[tmpl.Name] = now
+			if stage.Status.CompletionTime != nil {
+				s.completion[tmpl.Name] = stage.Status.CompletionTime.Time
+			}
+		case stage != nil && stage.Status.Phase == airforcev1alpha1.MissionStagePhaseRunning && stage.Status.StartTime != nil:
+			finish := stage.Status.StartTime.Add(d)
+			if finish.Before(now) {
+				finish = now
+			}
+			s.completion[tmpl.Name] = finish
+		default:
+			start := now
+			if dep := s.after[tmpl.Name]; dep != "" && s.completion[dep].After(start) {
+				start = s.completion[dep]
+			}
+			s.completion[tmpl.Name] = start.Add(d)
+		}
+	}
+	return s
+}
+
+// latestCompletion works dependsOn back from the deadline to the latest each stage may
+// complete for the Mission to meet it.
+func (s *missionSchedule) latestCompletion(deadline time.Time) map[string]time.Time {
+	latest := make(map[string]time.Time, len(s.order))
+	for i := len(s.order) - 1; i >= 0; i-- {
+		name := s.order[i].Name
+		finish := deadline
+		for _, next := range s.order[i+1:] {
+			if !containsString(next.DependsOn, name) {
+				continue
+			}
+			if t := latest[next.Name].Add(-s.durations[next.Name]); t.Before(finish) {
+				finish = t
+			}
+		}
+		latest[name] = finish
+	}
+	return latest
+}
+
+// estimated reports whether every unfinished stage has an estimated duration. Otherwise the
+// completion of the Mission is derived from the current time alone and is not published.
+func (s *missionSchedule) estimated(stages map[string]*airforcev1alpha1.MissionStage) bool {
+	for _, tmpl := range s.order {
+		if stage := stages[tmpl.Name]; stage != nil && isStageFinished(stage.Status.Phase) {
+			continue
+		}
+		if s.durations[tmpl.Name] <= 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// timeMoved reports whether an estimated time was set, cleared, or moved by
+// estimateRefreshInterval or more.
+func timeMoved(a, b *metav1.Time) bool {
+	if a == nil || b == nil {
+		return a != b
+	}
+	d := a.Sub(b.Time)
+	return d >= estimateRefreshInterval || -d >= estimateRefreshInterval
+}
+
+// stagesExist reports whether every name is a stage of mission.
+func stagesExist(mission *airforcev1alpha1.Mission, names []string) bool {
+	for _, name := range names {
+		found := false
+		for _, tmpl := range mission.Spec.Stages {
+			if tmpl.Name == name {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+// end is when the last stage completes, or is estimated to.
+func (s *missionSchedule) end() time.Time {
+	var end time.Time
+	for _, t := range s.completion {
+		if t.After(end) {
+			end = t
+		}
+	}
+	return end
+}
+
+// criticalPath is the chain of stages, along dependsOn, that ends with the stage that
+// completes last: the stages whose delay delays the Mission.
+func (s *missionSchedule) criticalPath() []string {
+	last := ""
+	for _, tmpl := range s.order {
+		if last == "" || s.completion[tmpl.Name].After(s.completion[last]) {
+			last = tmpl.Name
+		}
+	}
+	var path []string
+	for name := last; name != "" && !containsString(path, name); name = s.after[name] {
+		path = append([]string{name}, path...)
+	}
+	return path
+}
+
+// progress is the percentage of the estimated run time of the stages that is done. When no
+// stage has an estimate it is the percentage of finished stages.
+func (s *missionSchedule) progress(stages map[string]*airforcev1alpha1.MissionStage, now time.Time) int32 {
+	if len(s.order) == 0 {
+		return 0
+	}
+	var total, done time.Duration
+	finished := 0
+	for _, tmpl := range s.order {
+		d := s.durations[tmpl.Name]
+		total += d
+		stage := stages[tmpl.Name]
+		switch {
+		case stage == nil:
+		case isStageFinished(stage.Status.Phase):
+			done += d
+			finished++
+		case stage.Status.Phase == airforcev1alpha1.MissionStagePhaseRunning && stage.Status.StartTime != nil:
+			if elapsed := now.Sub(stage.Status.StartTime.Time); elapsed < d {
+				done += elapsed
+			} else {
+				// 超出预估的阶段在结束前不计为完成
+				done += d * 99 / 100
+			}
+		}
+	}
+	if total == 0 {
+		return int32(finished * 100 / len(s.order))
+	}
+	return int32(done * 100 / total)
+}
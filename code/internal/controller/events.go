@@ -0,0 +1,88 @@
+/*
+Copyright 2026 yydashuai.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/record"
+)
+
+// Event reasons emitted by the controllers. They are part of the user-facing surface
+// (`kubectl describe`), so keep them stable.
+const (
+	// Mission
+	eventReasonStageStarted     = "StageStarted"
+	eventReasonStageSucceeded   = "StageSucceeded"
+	eventReasonStageFailed      = "StageFailed"
+	eventReasonStageSkipped     = "StageSkipped"
+	eventReasonInvalidWhen      = "InvalidWhen"
+	eventReasonMissionSucceeded = "MissionSucceeded"
+	eventReasonMissionFailed    = "MissionFailed"
+	eventReasonMissionCancelled = "MissionCancelled"
+	eventReasonMissionLate      = "MissionLate"
+	eventReasonBudgetExceeded   = "BudgetExceeded"
+
+	// MissionStage
+	eventReasonTaskCreated   = "TaskCreated"
+	eventReasonTaskDeleted   = "TaskDeleted"
+	eventReasonStageTimedOut = "StageTimedOut"
+	eventReasonOutputMissing = "OutputMissing"
+	eventReasonTaskSkipped   = "TaskSkipped"
+
+	// FlightTask
+	eventReasonPodCreated                = "PodCreated"
+	eventReasonPodCreateFailed           = "PodCreateFailed"
+	eventReasonWeaponInjected            = "WeaponInjected"
+	eventReasonWeaponIncompatible        = "WeaponIncompatible"
+	eventReasonImagePullFailed           = "ImagePullFailed"
+	eventReasonSimulatedAircraftAssigned = "SimulatedAircraftAssigned"
+	eventReasonInvalidResult             = "InvalidResult"
+	eventReasonUnknownTarget             = "UnknownTarget"
+	eventReasonWeaponOutOfRange          = "WeaponOutOfRange"
+	eventReasonTargetOutsideArea         = "TargetOutsideArea"
+	eventReasonRouteOutOfRange           = "RouteOutOfRange"
+	eventReasonPhaseOverrun              = "PhaseOverrun"
+	eventReasonBriefingUpdated           = "BriefingUpdated"
+	eventReasonDeadlineExceeded          = "DeadlineExceeded"
+	eventReasonUnschedulable             = "Unschedulable"
+	eventReasonSchedulingRelaxed         = "SchedulingRelaxed"
+	eventReasonAircraftFallback          = "AircraftFallback"
+	eventReasonWeaponImageFallback       = "WeaponImageFallback"
+	eventReasonImagePullTimeout          = "ImagePullTimeout"
+	eventReasonWaypointSetMissing        = "WaypointSetMissing"
+
+	// Weapon
+	eventReasonWeaponAvailable = "WeaponAvailable"
+	eventReasonInvalidWeapon   = "InvalidWeapon"
+)
+
+// recordEvent emits an event if a recorder is configured; reconcilers built in tests may not have one.
+func recordEvent(recorder record.EventRecorder, obj runtime.Object, eventType, reason, messageFmt string, args ...interface{}) {
+	if recorder == nil || obj == nil {
+		return
+	}
+	recorder.Eventf(obj, eventType, reason, messageFmt, args...)
+}
+
+func recordNormal(recorder record.EventRecorder, obj runtime.Object, reason, messageFmt string, args ...interface{}) {
+	recordEvent(recorder, obj, corev1.EventTypeNormal, reason, messageFmt, args...)
+}
+
+func recordWarning(recorder record.EventRecorder, obj runtime.Object, reason, messageFmt string, args ...interface{}) {
+	recordEvent(recorder, obj, corev1.EventTypeWarning, reason, messageFmt, args...)
+}
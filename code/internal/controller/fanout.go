@@ -0,0 +1,120 @@
+/*
+Copyright 2026 yydashuai.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strconv"
+
+	"k8s.io/apimachinery/pkg/runtime"
+
+	airforcev1alpha1 "github.com/yydashuai/mission-system/api/v1alpha1"
+)
+
+// itemRefPattern matches {{ item.<key> }} and {{ index }} in fanned-out task templates.
+var itemRefPattern = regexp.MustCompile(`\{\{\s*(?:item\.([A-Za-z0-9_.-]+)|index)\s*\}\}`)
+
+// expandFlightTaskTemplate fans a template with count or withItems out into one
+// template per sortie, named <name>-<index>. Templates without either are returned as-is.
+func expandFlightTaskTemplate(tmpl airforcev1alpha1.MissionStageFlightTaskTemplate) []airforcev1alpha1.MissionStageFlightTaskTemplate {
+	n := len(tmpl.WithItems)
+	if n == 0 {
+		n = int(tmpl.Count)
+	}
+	if n <= 0 {
+		return []airforcev1alpha1.MissionStageFlightTaskTemplate{tmpl}
+	}
+
+	out := make([]airforcev1alpha1.MissionStageFlightTaskTemplate, 0, n)
+	for i := 0; i < n; i++ {
+		var item map[string]string
+		if len(tmpl.WithItems) != 0 {
+			item = tmpl.WithItems[i]
+		}
+		task := *tmpl.DeepCopy()
+		task.Name = fmt.Sprintf("%s-%d", tmpl.Name, i+1)
+		task.Count = 0
+		task.WithItems = nil
+		task.TargetRef = substituteItem(task.TargetRef, item, i+1, false)
+		task.When = substituteItem(task.When, item, i+1, true)
+		for k, v := range task.TaskParams {
+			task.TaskParams[k] = substituteItem(v, item, i+1, false)
+		}
+		if task.PodTemplate != nil && len(task.PodTemplate.Raw) != 0 {
+			task.PodTemplate = &runtime.RawExtension{Raw: []byte(substituteItem(string(task.PodTemplate.Raw), item, i+1, true))}
+		}
+		task.Params, _ = rewriteParams(task.Params, func(s string) (string, error) {
+			return substituteItem(s, item, i+1, true), nil
+		})
+		out = append(out, task)
+	}
+	return out
+}
+
+// stageFlightTaskTemplates returns the stage's task templates with count and withItems
+// expanded. Stages created by a Mission are expanded already; standalone stages are not.
+func stageFlightTaskTemplates(stage *airforcev1alpha1.MissionStage) []airforcev1alpha1.MissionStageFlightTaskTemplate {
+	out := make([]airforcev1alpha1.MissionStageFlightTaskTemplate, 0, len(stage.Spec.FlightTasks))
+	for _, tmpl := range stage.Spec.FlightTasks {
+		out = append(out, expandFlightTaskTemplate(tmpl)...)
+	}
+	return out
+}
+
+// substituteItem replaces item placeholders; references to keys the item does not
+// have are left in place so they stay visible in the generated task.
+func substituteItem(s string, item map[string]string, index int, jsonEscape bool) string {
+	return itemRefPattern.ReplaceAllStringFunc(s, func(ref string) string {
+		m := itemRefPattern.FindStringSubmatch(ref)
+		value := strconv.Itoa(index)
+		if m[1] != "" {
+			v, ok := item[m[1]]
+			if !ok {
+				return ref
+			}
+			value = v
+		}
+		if jsonEscape {
+			quoted, _ := json.Marshal(value)
+			value = string(quoted[1 : len(quoted)-1])
+		}
+		return value
+	})
+}
+
+// rewriteParams applies rewrite to the JSON form of typed task params, so placeholders are
+// replaced in every string field. Params that no longer decode afterwards are returned unchanged.
+func rewriteParams(params *airforcev1alpha1.FlightTaskParams, rewrite func(string) (string, error)) (*airforcev1alpha1.FlightTaskParams, error) {
+	if params == nil {
+		return nil, nil
+	}
+	data, err := json.Marshal(params)
+	if err != nil {
+		return params, err
+	}
+	out, err := rewrite(string(data))
+	if err != nil {
+		return params, err
+	}
+	var rewritten airforcev1alpha1.FlightTaskParams
+	if err := json.Unmarshal([]byte(out), &rewritten); err != nil {
+		return params, nil
+	}
+	return &rewritten, nil
+}
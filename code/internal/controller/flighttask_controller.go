@@ -19,8 +19,8 @@ package controller
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
-	"math"
 	"sort"
 	"strconv"
 	"strings"
@@ -34,22 +34,40 @@ import (
 	"k8s.io/apimachinery/pkg/fields"
 	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/record"
 	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
 	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
 
 	airforcev1alpha1 "github.com/yydashuai/mission-system/api/v1alpha1"
+	"github.com/yydashuai/mission-system/internal/geo"
+	"github.com/yydashuai/mission-system/internal/tracing"
 )
 
 // FlightTaskReconciler reconciles a FlightTask object
 type FlightTaskReconciler struct {
 	client.Client
-	Scheme *runtime.Scheme
+	Scheme   *runtime.Scheme
+	Recorder record.EventRecorder
 
 	// APIReader is used for direct apiserver reads (e.g. listing Events with field selectors),
 	// because cached clients do not support arbitrary field selectors.
 	APIReader client.Reader
+
+	// Telemetry, when set, gives task pods the endpoint and token to report telemetry with.
+	Telemetry TelemetryCredentials
+
+	// ImagePullTimeout is the default for tasks without spec.imagePullTimeout; 0 keeps
+	// tasks whose images cannot be pulled waiting.
+	ImagePullTimeout time.Duration
+
+	// UnschedulablePolicy is the default for tasks whose pods cannot be scheduled; a task's
+	// spec.unschedulablePolicy overrides it field by field. With no limit set, tasks wait.
+	UnschedulablePolicy airforcev1alpha1.UnschedulablePolicy
 }
 
 //+kubebuilder:rbac:groups=airforce.airforce.mil,resources=flighttasks,verbs=get;list;watch;create;update;patch;delete
@@ -57,9 +75,11 @@ type FlightTaskReconciler struct {
 //+kubebuilder:rbac:groups=airforce.airforce.mil,resources=flighttasks/finalizers,verbs=update
 //+kubebuilder:rbac:groups="",resources=pods,verbs=get;list;watch;create;update;patch;delete
 //+kubebuilder:rbac:groups="",resources=nodes,verbs=get;list;watch
-//+kubebuilder:rbac:groups="",resources=events,verbs=get;list;watch
+//+kubebuilder:rbac:groups="",resources=configmaps,verbs=get;list;watch;create;update;patch;delete
+//+kubebuilder:rbac:groups="",resources=events,verbs=get;list;watch;create;patch
 //+kubebuilder:rbac:groups=events.k8s.io,resources=events,verbs=get;list;watch
 //+kubebuilder:rbac:groups=airforce.airforce.mil,resources=weapons,verbs=get;list;watch
+//+kubebuilder:rbac:groups=airforce.airforce.mil,resources=waypointsets,verbs=get;list;watch
 //+kubebuilder:rbac:groups=airforce.airforce.mil,resources=missions,verbs=get;list;watch
 
 // Reconcile is part of the main kubernetes reconciliation loop which aims to
@@ -72,13 +92,15 @@ type FlightTaskReconciler struct {
 // For more details, check Reconcile and its Result here:
 // - https://pkg.go.dev/sigs.k8s.io/controller-runtime@v0.17.0/pkg/reconcile
 func (r *FlightTaskReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
-	logger := log.FromContext(ctx)
-
 	var task airforcev1alpha1.FlightTask
 	if err := r.Get(ctx, req.NamespacedName, &task); err != nil {
 		return ctrl.Result{}, client.IgnoreNotFound(err)
 	}
 
+	ctx, span := tracing.StartReconcile(ctx, "FlightTask", &task)
+	defer span.End()
+	logger := log.FromContext(ctx)
+
 	if task.Status.Phase == "" {
 		patch := client.MergeFrom(task.DeepCopy())
 		task.Status.Phase = airforcev1alpha1.FlightTaskPhasePending
@@ -102,6 +124,22 @@ func (r *FlightTaskReconciler) Reconcile(ctx context.Context, req ctrl.Request)
 		return ctrl.Result{Requeue: true}, nil
 	}
 
+	// 仿真模式：不创建Pod，由控制器推进任务阶段
+	if task.Status.PodRef == nil {
+		sim, err := r.simulationConfig(ctx, &task)
+		if err != nil {
+			return ctrl.Result{}, err
+		}
+		if sim != nil {
+			return r.reconcileSimulatedTask(ctx, &task, sim)
+		}
+	}
+
+	// 射程/航程校验失败、执行超时、无法调度、拉取镜像超时或被阶段中止的任务已删除 Pod，不再重建
+	if weaponOutOfRange(&task) || routeOutOfRange(&task) || deadlineExceeded(&task) || taskAborted(&task) || unschedulable(&task) || imagePullTimedOut(&task) {
+		return ctrl.Result{}, nil
+	}
+
 	podName := fmt.Sprintf("%s-pod", task.Name)
 	ensurePod := task.Status.PodRef != nil ||
 		task.Status.Phase == airforcev1alpha1.FlightTaskPhaseScheduled ||
@@ -114,15 +152,42 @@ func (r *FlightTaskReconciler) Reconcile(ctx context.Context, req ctrl.Request)
 		}
 
 		if apierrors.IsNotFound(err) {
-			desiredPod, err := r.buildPodForTask(ctx, &task, podName)
+			// buildPodForTask 会写入 status.route，补丁以构建前的状态为基准
+			base := task.DeepCopy()
+			buildCtx, buildSpan := tracing.Tracer().Start(ctx, "buildPodForTask")
+			desiredPod, err := r.buildPodForTask(buildCtx, &task, podName)
+			tracing.RecordError(buildSpan, err)
+			buildSpan.End()
+			var transient *transientError
+			if errors.As(err, &transient) {
+				return ctrl.Result{}, err
+			}
+			var missingSet *waypointSetMissingError
+			if errors.As(err, &missingSet) {
+				// 航路点集尚未创建，保持当前阶段等待，创建后由 WaypointSet 监听重新调谐
+				return ctrl.Result{}, r.waitForWaypointSet(ctx, &task, base, missingSet)
+			}
 			if err != nil {
 				logger.Error(err, "failed to build pod for FlightTask", "flightTask", task.Name)
-				patch := client.MergeFrom(task.DeepCopy())
+				reason := "InvalidSpec"
+				var compatErr *weaponCompatibilityError
+				var unknownTarget *unknownTargetError
+				switch {
+				case errors.As(err, &compatErr):
+					recordWarning(r.Recorder, &task, eventReasonWeaponIncompatible, "%s", err.Error())
+				case errors.As(err, &unknownTarget):
+					// 目标不在任务目标列表中，不能无目标出动
+					reason = "UnknownTarget"
+					recordWarning(r.Recorder, &task, eventReasonUnknownTarget, "%s", err.Error())
+				default:
+					recordWarning(r.Recorder, &task, eventReasonPodCreateFailed, "Invalid task spec: %s", err.Error())
+				}
+				patch := client.MergeFrom(base)
 				task.Status.Phase = airforcev1alpha1.FlightTaskPhaseFailed
 				meta := metav1.Condition{
 					Type:               "PodCreated",
 					Status:             metav1.ConditionFalse,
-					Reason:             "InvalidSpec",
+					Reason:             reason,
 					Message:            err.Error(),
 					ObservedGeneration: task.Generation,
 				}
@@ -133,9 +198,14 @@ func (r *FlightTaskReconciler) Reconcile(ctx context.Context, req ctrl.Request)
 			if err := controllerutil.SetControllerReference(&task, desiredPod, r.Scheme); err != nil {
 				return ctrl.Result{}, err
 			}
+			// 任务简报须先于 Pod 创建，Pod 挂载它
+			if _, err := r.syncBriefing(ctx, &task); err != nil {
+				return ctrl.Result{}, err
+			}
 			if err := r.Create(ctx, desiredPod); err != nil {
 				if apierrors.IsInvalid(err) {
-					patch := client.MergeFrom(task.DeepCopy())
+					recordWarning(r.Recorder, &task, eventReasonPodCreateFailed, "Pod rejected by apiserver: %s", err.Error())
+					patch := client.MergeFrom(base)
 					task.Status.Phase = airforcev1alpha1.FlightTaskPhaseFailed
 					meta := metav1.Condition{
 						Type:               "PodCreated",
@@ -165,7 +235,7 @@ func (r *FlightTaskReconciler) Reconcile(ctx context.Context, req ctrl.Request)
 				return ctrl.Result{RequeueAfter: 2 * time.Second}, nil
 			}
 
-			patch := client.MergeFrom(task.DeepCopy())
+			patch := client.MergeFrom(base)
 			task.Status.PodRef = podRef
 			task.Status.Phase = airforcev1alpha1.FlightTaskPhasePending
 			if task.Status.SchedulingInfo == nil {
@@ -185,19 +255,45 @@ func (r *FlightTaskReconciler) Reconcile(ctx context.Context, req ctrl.Request)
 			if err := r.Status().Patch(ctx, &task, patch); err != nil {
 				return ctrl.Result{}, err
 			}
+			recordNormal(r.Recorder, &task, eventReasonPodCreated, "Created pod %s", desiredPod.Name)
+			for _, item := range task.Spec.WeaponLoadout {
+				weaponSidecarsInjectedTotal.WithLabelValues(item.WeaponRef.Name).Inc()
+				recordNormal(r.Recorder, &task, eventReasonWeaponInjected, "Injected weapon %s (quantity %d) into pod %s",
+					item.WeaponRef.Name, item.Quantity, desiredPod.Name)
+			}
 			return ctrl.Result{Requeue: true}, nil
 		}
 
+		// 任务重新指派目标或任务信息变化时更新简报，运行中的容器可读取新内容
+		if !isFlightTaskFinished(task.Status.Phase) {
+			updated, err := r.syncBriefing(ctx, &task)
+			if err != nil {
+				return ctrl.Result{}, err
+			}
+			if updated {
+				recordNormal(r.Recorder, &task, eventReasonBriefingUpdated, "Updated briefing %s", briefingConfigMapName(&task))
+			}
+		}
+
 		original := task.DeepCopy()
 
 		samePod := task.Status.PodRef != nil && task.Status.PodRef.UID != "" && string(task.Status.PodRef.UID) == string(pod.UID)
 		if !samePod {
-			task.Status.SchedulingInfo = &airforcev1alpha1.SchedulingInfo{SchedulingAttempts: 1}
+			// 重建的 Pod 沿用此前放宽偏好、换用备选机型的决定
+			info := &airforcev1alpha1.SchedulingInfo{SchedulingAttempts: 1}
+			if prev := task.Status.SchedulingInfo; prev != nil {
+				info.PreferencesRelaxed = prev.PreferencesRelaxed
+				info.FallbackAircraftType = prev.FallbackAircraftType
+			}
+			task.Status.SchedulingInfo = info
 		}
 
 		desiredPhase := task.Status.Phase
 		podScheduled := pod.Spec.NodeName != "" || isPodScheduled(&pod)
-		pullReason, pullMessage, pullFailed := imagePullFailure(&pod)
+		pullContainer, pullReason, pullMessage, pullFailed := imagePullFailure(&pod)
+		if pullFailed {
+			pullMessage = imagePullMessage(&pod, pullContainer, pullMessage)
+		}
 		switch pod.Status.Phase {
 		case corev1.PodRunning:
 			desiredPhase = airforcev1alpha1.FlightTaskPhaseRunning
@@ -233,10 +329,54 @@ func (r *FlightTaskReconciler) Reconcile(ctx context.Context, req ctrl.Request)
 			}
 		}
 
+		// 飞机分配后校验目标是否在挂载武器的有效射程内、航线各航段是否在飞机航程内
+		rangeConditionChanged, routeConditionChanged, rangeFailed := false, false, false
+		assignedChanged := original.Status.SchedulingInfo == nil || original.Status.SchedulingInfo.AssignedNode != desiredAssignedNode
+		if desiredAssignedNode != "" && !isFlightTaskFinished(desiredPhase) &&
+			(assignedChanged || apimeta.FindStatusCondition(task.Status.Conditions, conditionWeaponInRange) == nil) {
+			cond, policy, err := r.checkWeaponRange(ctx, &task, desiredAssignedNode)
+			if err != nil {
+				return ctrl.Result{}, err
+			}
+			rangeConditionChanged = setConditionWithTime(&task.Status.Conditions, cond, metav1.Now())
+			if cond.Status == metav1.ConditionFalse && policy == airforcev1alpha1.RangeCheckFail {
+				desiredPhase = airforcev1alpha1.FlightTaskPhaseFailed
+				rangeFailed = true
+			}
+		}
+		if desiredAssignedNode != "" && !isFlightTaskFinished(desiredPhase) &&
+			(assignedChanged || apimeta.FindStatusCondition(task.Status.Conditions, conditionRouteInRange) == nil) {
+			cond, policy, err := r.checkRouteRange(ctx, &task, desiredAssignedNode)
+			if err != nil {
+				return ctrl.Result{}, err
+			}
+			routeConditionChanged = setConditionWithTime(&task.Status.Conditions, cond, metav1.Now())
+			if cond.Status == metav1.ConditionFalse && policy == airforcev1alpha1.RangeCheckFail {
+				desiredPhase = airforcev1alpha1.FlightTaskPhaseFailed
+				rangeFailed = true
+			}
+		}
+
+		// 超过 missionDuration 的任务判定失败，随后终止 Pod
+		deadlineConditionChanged, deadlinePassed := syncDeadlineCondition(&task, &pod, time.Now())
+		terminatePod := false
+		if deadlinePassed && desiredPhase != airforcev1alpha1.FlightTaskPhaseSucceeded {
+			terminatePod = desiredPhase != airforcev1alpha1.FlightTaskPhaseFailed
+			desiredPhase = airforcev1alpha1.FlightTaskPhaseFailed
+		}
+
 		podScheduledConditionChanged := syncPodScheduledCondition(&task, &pod)
 		failedSchedulingConditionChanged := syncFailedSchedulingCondition(&task, &pod, summary)
 		podCreatedConditionChanged := ensurePodCreatedCondition(&task, &pod)
 		imagePullConditionChanged := syncImagePullFailedCondition(&task, pullFailed, pullReason, pullMessage)
+		resultCaptured := captureTaskResult(&task, &pod)
+
+		// 按遥测或 Pod 启动后的耗时推进任务阶段
+		phaseProgressChanged := false
+		var overrunPhases []string
+		if pod.Status.StartTime != nil && (desiredPhase == airforcev1alpha1.FlightTaskPhaseRunning || isFlightTaskFinished(desiredPhase)) {
+			phaseProgressChanged, overrunPhases = syncPhaseProgress(&task, pod.Status.StartTime.Time, time.Now(), isFlightTaskFinished(desiredPhase))
+		}
 
 		desiredAttempts := int32(1)
 		if samePod && task.Status.SchedulingInfo != nil && task.Status.SchedulingInfo.SchedulingAttempts > desiredAttempts {
@@ -246,6 +386,51 @@ func (r *FlightTaskReconciler) Reconcile(ctx context.Context, req ctrl.Request)
 			desiredAttempts = summary.Attempts
 		}
 
+		// 长时间无法调度的任务按策略放宽调度偏好、换用备选机型或判定失败
+		var unschedulableAction airforcev1alpha1.UnschedulableAction
+		unschedulableConditionChanged := false
+		if policy := r.unschedulablePolicy(&task); policy != nil && !podScheduled && pod.Status.Phase == corev1.PodPending &&
+			pod.DeletionTimestamp == nil && !isFlightTaskFinished(desiredPhase) {
+			if reason, message, reached := unschedulableLimit(policy, desiredAttempts, &pod, time.Now()); reached {
+				unschedulableAction = nextUnschedulableAction(policy, &task)
+				if task.Status.SchedulingInfo == nil {
+					task.Status.SchedulingInfo = &airforcev1alpha1.SchedulingInfo{}
+				}
+				switch unschedulableAction {
+				case airforcev1alpha1.UnschedulableActionRelax:
+					task.Status.SchedulingInfo.PreferencesRelaxed = true
+				case airforcev1alpha1.UnschedulableActionFallback:
+					task.Status.SchedulingInfo.FallbackAircraftType = policy.FallbackAircraftType
+				default:
+					desiredPhase = airforcev1alpha1.FlightTaskPhaseFailed
+					unschedulableConditionChanged = setUnschedulableCondition(&task, reason, message)
+				}
+			}
+		}
+
+		// 镜像拉取失败：武器 sidecar 持续失败一段时间后换用备用镜像重建 Pod，超过 imagePullTimeout 仍失败的任务判定失败
+		fallbackWeapon, fallbackImage := "", ""
+		pullTimedOut := false
+		if pullFailed && pod.DeletionTimestamp == nil && !isFlightTaskFinished(desiredPhase) {
+			timeout := r.imagePullTimeout(&task)
+			if weapon := containerWeapon(&pod, pullContainer); weapon != "" && !containsString(task.Status.WeaponImageFallbacks, weapon) &&
+				imagePullFallbackDue(&pod, timeout, time.Now()) {
+				image, err := r.weaponFallbackImage(ctx, task.Namespace, weapon)
+				if err != nil {
+					return ctrl.Result{}, err
+				}
+				if image != "" {
+					fallbackWeapon, fallbackImage = weapon, image
+					task.Status.WeaponImageFallbacks = append(task.Status.WeaponImageFallbacks, weapon)
+				}
+			}
+			if fallbackWeapon == "" && imagePullExpired(&pod, timeout, time.Now()) {
+				desiredPhase = airforcev1alpha1.FlightTaskPhaseFailed
+				pullTimedOut = true
+				imagePullConditionChanged = setImagePullTimeoutCondition(&task, timeout, pullMessage) || imagePullConditionChanged
+			}
+		}
+
 		needsPatch := task.Status.PodRef == nil ||
 			task.Status.PodRef.Name != pod.Name ||
 			task.Status.PodRef.UID == "" ||
@@ -254,7 +439,15 @@ func (r *FlightTaskReconciler) Reconcile(ctx context.Context, req ctrl.Request)
 			podScheduledConditionChanged ||
 			failedSchedulingConditionChanged ||
 			podCreatedConditionChanged ||
-			imagePullConditionChanged
+			imagePullConditionChanged ||
+			rangeConditionChanged ||
+			routeConditionChanged ||
+			deadlineConditionChanged ||
+			unschedulableConditionChanged ||
+			unschedulableAction != "" ||
+			fallbackWeapon != "" ||
+			phaseProgressChanged ||
+			resultCaptured
 		if task.Status.SchedulingInfo == nil ||
 			task.Status.SchedulingInfo.SchedulingAttempts != desiredAttempts ||
 			task.Status.SchedulingInfo.AssignedNode != desiredAssignedNode ||
@@ -279,8 +472,89 @@ func (r *FlightTaskReconciler) Reconcile(ctx context.Context, req ctrl.Request)
 			if err := r.Status().Patch(ctx, &task, patch); err != nil {
 				return ctrl.Result{}, err
 			}
+			if rangeConditionChanged {
+				if cond := apimeta.FindStatusCondition(task.Status.Conditions, conditionWeaponInRange); cond != nil && cond.Status == metav1.ConditionFalse {
+					recordWarning(r.Recorder, &task, eventReasonWeaponOutOfRange, "%s", cond.Message)
+				}
+			}
+			if routeConditionChanged {
+				if cond := apimeta.FindStatusCondition(task.Status.Conditions, conditionRouteInRange); cond != nil && cond.Status == metav1.ConditionFalse {
+					recordWarning(r.Recorder, &task, eventReasonRouteOutOfRange, "%s", cond.Message)
+				}
+			}
+			if rangeFailed {
+				if err := r.Delete(ctx, &pod); err != nil && !apierrors.IsNotFound(err) {
+					return ctrl.Result{}, err
+				}
+			}
+			if deadlineConditionChanged && deadlinePassed {
+				if cond := apimeta.FindStatusCondition(task.Status.Conditions, conditionDeadlineExceeded); cond != nil {
+					recordWarning(r.Recorder, &task, eventReasonDeadlineExceeded, "%s", cond.Message)
+				}
+			}
+			if unschedulableAction != "" {
+				// 删除未调度的 Pod；放宽偏好或换用备选机型时按新的要求重建
+				if err := r.Delete(ctx, &pod); err != nil && !apierrors.IsNotFound(err) {
+					return ctrl.Result{}, err
+				}
+				switch unschedulableAction {
+				case airforcev1alpha1.UnschedulableActionRelax:
+					recordNormal(r.Recorder, &task, eventReasonSchedulingRelaxed, "Pod %s could not be scheduled after %d attempt(s), recreating it without scheduling preferences",
+						pod.Name, desiredAttempts)
+				case airforcev1alpha1.UnschedulableActionFallback:
+					recordNormal(r.Recorder, &task, eventReasonAircraftFallback, "Pod %s could not be scheduled on %s after %d attempt(s), recreating it for %s",
+						pod.Name, task.Spec.AircraftRequirement.Type, desiredAttempts, task.Status.SchedulingInfo.FallbackAircraftType)
+				default:
+					if cond := apimeta.FindStatusCondition(task.Status.Conditions, conditionUnschedulable); cond != nil {
+						recordWarning(r.Recorder, &task, eventReasonUnschedulable, "%s", cond.Message)
+					}
+				}
+			}
+			if fallbackWeapon != "" || pullTimedOut {
+				if err := r.Delete(ctx, &pod); err != nil && !apierrors.IsNotFound(err) {
+					return ctrl.Result{}, err
+				}
+				if fallbackWeapon != "" {
+					recordNormal(r.Recorder, &task, eventReasonWeaponImageFallback, "Recreating pod %s with the fallback image %s of weapon %s: %s",
+						pod.Name, fallbackImage, fallbackWeapon, pullMessage)
+				} else {
+					recordWarning(r.Recorder, &task, eventReasonImagePullTimeout, "%s", apimeta.FindStatusCondition(task.Status.Conditions, "NoImagePullError").Message)
+				}
+			}
+			if terminatePod {
+				// 按 Pod 的 terminationGracePeriodSeconds 优雅终止
+				if err := r.Delete(ctx, &pod); err != nil && !apierrors.IsNotFound(err) {
+					return ctrl.Result{}, err
+				}
+			}
+			for _, name := range overrunPhases {
+				recordWarning(r.Recorder, &task, eventReasonPhaseOverrun, "Phase %s exceeded its declared duration", name)
+			}
+			if imagePullConditionChanged && pullFailed && !pullTimedOut {
+				flightTaskImagePullFailuresTotal.WithLabelValues(pullReason).Inc()
+				recordWarning(r.Recorder, &task, eventReasonImagePullFailed, "%s: %s", pullReason, pullMessage)
+			}
+			if desiredAssignedNode != "" && (original.Status.SchedulingInfo == nil || original.Status.SchedulingInfo.AssignedNode == "") {
+				var assignedAt time.Time
+				if desiredAssignedTime != nil {
+					assignedAt = desiredAssignedTime.Time
+				}
+				r.observeFlightTaskScheduled(ctx, &task, pod.CreationTimestamp.Time, assignedAt)
+			}
+			if isFlightTaskFinished(desiredPhase) && !isFlightTaskFinished(original.Status.Phase) {
+				observeFlightTaskFinished(&task, desiredPhase)
+			}
+			if resultCaptured {
+				if cond := apimeta.FindStatusCondition(task.Status.Conditions, conditionResultCaptured); cond != nil && cond.Reason == "InvalidResult" {
+					recordWarning(r.Recorder, &task, eventReasonInvalidResult, "%s", cond.Message)
+				}
+			}
 		}
 
+		if unschedulableAction == airforcev1alpha1.UnschedulableActionRelax || unschedulableAction == airforcev1alpha1.UnschedulableActionFallback ||
+			fallbackWeapon != "" {
+			return ctrl.Result{RequeueAfter: 2 * time.Second}, nil
+		}
 		if pod.Status.Phase == corev1.PodPending && pod.Spec.NodeName == "" {
 			return ctrl.Result{RequeueAfter: 10 * time.Second}, nil
 		}
@@ -358,8 +632,10 @@ func (r *FlightTaskReconciler) buildPodForTask(ctx context.Context, task *airfor
 	if task.Spec.Role != "" {
 		labels["role"] = task.Spec.Role
 	}
-	if task.Spec.AircraftRequirement.Type != "" {
-		labels["aircraft"] = task.Spec.AircraftRequirement.Type
+	// 无法调度时可能已换用备选机型或去掉了位置偏好
+	req := effectiveAircraftRequirement(task)
+	if req.Type != "" {
+		labels["aircraft"] = req.Type
 	}
 
 	// 准备annotations，用于Webhook识别和处理
@@ -418,21 +694,108 @@ func (r *FlightTaskReconciler) buildPodForTask(ctx context.Context, task *airfor
 		}
 	}
 
-	applyAircraftSchedulingConstraints(pod, task.Spec.AircraftRequirement)
+	// 只接受能挂载全部武器的机型
+	aircraftTypes, err := r.loadoutAircraftTypes(ctx, task, acceptableAircraftTypes(req))
+	if err != nil {
+		return nil, err
+	}
+	if len(aircraftTypes) != 0 {
+		req.Type, req.AlternateTypes = aircraftTypes[0], aircraftTypes[1:]
+	}
+	applyAircraftSchedulingConstraints(pod, req)
+
+	// 解析任务对应的目标，下发给任务容器并用于距离调度
+	target, err := r.taskTarget(ctx, task)
+	if err != nil {
+		var unknown *unknownTargetError
+		if errors.As(err, &unknown) {
+			return nil, err
+		}
+		log.FromContext(ctx).Error(err, "failed to resolve task target, continuing without it")
+	}
+	injectTargetEnv(pod, target)
+
+	// 作战区域：区域无效时任务无法执行，目标在区域外只告警
+	area, err := taskOperationArea(task)
+	if err != nil {
+		return nil, err
+	}
+	if area != nil && target != nil && target.Coordinates != nil {
+		if p, perr := geoPoint(target.Coordinates); perr == nil && !area.Contains(p) {
+			recordWarning(r.Recorder, task, eventReasonTargetOutsideArea, "target %s (%s) is outside the task's operation area", target.Name, p)
+		}
+	}
+
+	// 航线：解析各阶段航路点，写入 status.route 并下发给任务容器
+	route, err := r.resolveTaskRoute(ctx, task, area)
+	if err != nil {
+		return nil, err
+	}
+	task.Status.Route = route
+	if err := injectRouteEnv(pod, route); err != nil {
+		return nil, err
+	}
 
-	// 应用距离优先调度
-	if err := r.applyDistanceBasedScheduling(ctx, pod, task); err != nil {
-		// 距离调度失败不影响Pod创建，只记录日志
-		log.FromContext(ctx).Error(err, "failed to apply distance-based scheduling, continuing without it")
+	// 应用距离优先调度，放宽调度偏好后不再应用
+	if !preferencesRelaxed(task) {
+		distanceCtx, distanceSpan := tracing.Tracer().Start(ctx, "applyDistanceBasedScheduling")
+		if err := r.applyDistanceBasedScheduling(distanceCtx, pod, target); err != nil {
+			// 距离调度失败不影响Pod创建，只记录日志
+			tracing.RecordError(distanceSpan, err)
+			log.FromContext(ctx).Error(err, "failed to apply distance-based scheduling, continuing without it")
+		}
+		distanceSpan.End()
 	}
 
-	if err := r.injectWeaponSidecars(ctx, pod, task); err != nil {
+	weaponCtx, weaponSpan := tracing.Tracer().Start(ctx, "injectWeaponSidecars")
+	err = r.injectWeaponSidecars(weaponCtx, pod, task, aircraftTypes)
+	tracing.RecordError(weaponSpan, err)
+	weaponSpan.End()
+	if err != nil {
 		return nil, err
 	}
 
+	// 任务容器与武器 sidecar 通过 /interface/result.json（终止消息）上报执行结果
+	ensureResultProtocol(pod)
+	// 飞行中的位置等遥测通过遥测端点上报
+	injectTelemetryEnv(pod, task, r.Telemetry)
+	// 任务简报（目标、协同、任务参数、挂载）挂载在 /interface/briefing
+	mountBriefing(pod, task)
+
+	// Pod 继承 FlightTask 的 trace，便于把 Pod 日志与调度链路关联
+	tracing.Inject(ctx, pod)
+
 	return pod, nil
 }
 
+// transientError marks a pod build error caused by the apiserver rather than by the task
+// spec. The reconcile is retried instead of failing the task.
+type transientError struct {
+	err error
+}
+
+func (e *transientError) Error() string { return e.err.Error() }
+
+func (e *transientError) Unwrap() error { return e.err }
+
+// waitForWaypointSet records that the task waits for its WaypointSet without failing it.
+func (r *FlightTaskReconciler) waitForWaypointSet(ctx context.Context, task, base *airforcev1alpha1.FlightTask, missing *waypointSetMissingError) error {
+	cond := apimeta.FindStatusCondition(task.Status.Conditions, "PodCreated")
+	if cond != nil && cond.Reason == "WaitingForWaypointSet" {
+		return nil
+	}
+	recordWarning(r.Recorder, task, eventReasonWaypointSetMissing, "%s, waiting for it to be created", missing.Error())
+	patch := client.MergeFrom(base)
+	apimeta.SetStatusCondition(&task.Status.Conditions, metav1.Condition{
+		Type:               "PodCreated",
+		Status:             metav1.ConditionFalse,
+		Reason:             "WaitingForWaypointSet",
+		Message:            missing.Error(),
+		ObservedGeneration: task.Generation,
+	})
+	return r.Status().Patch(ctx, task, patch)
+}
+
 func (r *FlightTaskReconciler) reader() client.Reader {
 	if r.APIReader != nil {
 		return r.APIReader
@@ -672,8 +1035,10 @@ func isPodScheduled(pod *corev1.Pod) bool {
 	return c != nil && c.Status == corev1.ConditionTrue
 }
 
-func imagePullFailure(pod *corev1.Pod) (string, string, bool) {
-	check := func(statuses []corev1.ContainerStatus) (string, string, bool) {
+// imagePullFailure returns the first container of the pod that fails to pull its image,
+// with the kubelet reason and message.
+func imagePullFailure(pod *corev1.Pod) (string, string, string, bool) {
+	check := func(statuses []corev1.ContainerStatus) (string, string, string, bool) {
 		for i := range statuses {
 			cs := statuses[i]
 			if cs.State.Waiting == nil {
@@ -689,19 +1054,19 @@ func imagePullFailure(pod *corev1.Pod) (string, string, bool) {
 				if reason == "" {
 					reason = "ImagePullFailed"
 				}
-				return reason, msg, true
+				return cs.Name, reason, msg, true
 			}
 		}
-		return "", "", false
+		return "", "", "", false
 	}
 
-	if reason, msg, failed := check(pod.Status.ContainerStatuses); failed {
-		return reason, msg, true
+	if container, reason, msg, failed := check(pod.Status.ContainerStatuses); failed {
+		return container, reason, msg, true
 	}
-	if reason, msg, failed := check(pod.Status.InitContainerStatuses); failed {
-		return reason, msg, true
+	if container, reason, msg, failed := check(pod.Status.InitContainerStatuses); failed {
+		return container, reason, msg, true
 	}
-	return "", "", false
+	return "", "", "", false
 }
 
 func syncImagePullFailedCondition(task *airforcev1alpha1.FlightTask, failed bool, reason, message string) bool {
@@ -797,21 +1162,62 @@ func removeCondition(conditions *[]metav1.Condition, condType string) bool {
 	return false
 }
 
+// aircraftTypePreferenceWeight is the weight of the preferred aircraft type of a task that
+// accepts several; the alternates get less in order, and the last none.
+const aircraftTypePreferenceWeight = 60
+
+// acceptableAircraftTypes is the requirement's type followed by its alternates, in order
+// of preference, without blanks and repeats.
+func acceptableAircraftTypes(req airforcev1alpha1.AircraftRequirement) []string {
+	var types []string
+	for _, t := range append([]string{req.Type}, req.AlternateTypes...) {
+		t = strings.TrimSpace(t)
+		if t != "" && !containsString(types, t) {
+			types = append(types, t)
+		}
+	}
+	return types
+}
+
 func applyAircraftSchedulingConstraints(pod *corev1.Pod, req airforcev1alpha1.AircraftRequirement) {
-	aircraftType := strings.TrimSpace(req.Type)
-	if aircraftType != "" {
+	var required, fields []corev1.NodeSelectorRequirement
+	var preferred []corev1.PreferredSchedulingTerm
+
+	aircraftTypes := acceptableAircraftTypes(req)
+	if pod.Spec.NodeSelector["aircraft.mil/type"] == "" && pod.Spec.NodeSelector["aircraft.type"] == "" &&
+		(len(aircraftTypes) != 0 || len(req.Capabilities) != 0) {
 		if pod.Spec.NodeSelector == nil {
 			pod.Spec.NodeSelector = map[string]string{}
 		}
-		if pod.Spec.NodeSelector["aircraft.mil/type"] == "" && pod.Spec.NodeSelector["aircraft.type"] == "" {
-			pod.Spec.NodeSelector["aircraft.mil/type"] = aircraftType
-			if pod.Spec.NodeSelector["aircraft.mil/status"] == "" && pod.Spec.NodeSelector["aircraft.status"] == "" {
-				pod.Spec.NodeSelector["aircraft.mil/status"] = "ready"
+		switch len(aircraftTypes) {
+		case 0:
+			// 未指定机型时按能力匹配任意就绪的飞机
+		case 1:
+			pod.Spec.NodeSelector["aircraft.mil/type"] = aircraftTypes[0]
+		default:
+			// 接受任一备选机型，按顺序优先
+			required = append(required, corev1.NodeSelectorRequirement{
+				Key:      "aircraft.mil/type",
+				Operator: corev1.NodeSelectorOpIn,
+				Values:   aircraftTypes,
+			})
+			last := len(aircraftTypes) - 1
+			for i, t := range aircraftTypes[:last] {
+				preferred = append(preferred, corev1.PreferredSchedulingTerm{
+					Weight: int32(aircraftTypePreferenceWeight * (last - i) / last),
+					Preference: corev1.NodeSelectorTerm{
+						MatchExpressions: []corev1.NodeSelectorRequirement{
+							{Key: "aircraft.mil/type", Operator: corev1.NodeSelectorOpIn, Values: []string{t}},
+						},
+					},
+				})
 			}
 		}
+		if pod.Spec.NodeSelector["aircraft.mil/status"] == "" && pod.Spec.NodeSelector["aircraft.status"] == "" {
+			pod.Spec.NodeSelector["aircraft.mil/status"] = "ready"
+		}
 	}
 
-	var required []corev1.NodeSelectorRequirement
 	if req.MinFuelLevel > 0 {
 		minFuel := int(req.MinFuelLevel) - 1
 		if minFuel < 0 {
@@ -846,8 +1252,16 @@ func applyAircraftSchedulingConstraints(pod *corev1.Pod, req airforcev1alpha1.Ai
 		})
 	}
 
+	// 固定到指定飞机（如分配计划选中的飞机）
+	if node := strings.TrimSpace(req.Node); node != "" {
+		fields = append(fields, corev1.NodeSelectorRequirement{
+			Key:      "metadata.name",
+			Operator: corev1.NodeSelectorOpIn,
+			Values:   []string{node},
+		})
+	}
+
 	preferredLocation := strings.TrimSpace(req.PreferredLocation)
-	var preferred []corev1.PreferredSchedulingTerm
 	if preferredLocation != "" {
 		preferred = append(preferred, corev1.PreferredSchedulingTerm{
 			Weight: 50,
@@ -863,7 +1277,7 @@ func applyAircraftSchedulingConstraints(pod *corev1.Pod, req airforcev1alpha1.Ai
 		})
 	}
 
-	if len(required) == 0 && len(preferred) == 0 {
+	if len(required) == 0 && len(fields) == 0 && len(preferred) == 0 {
 		return
 	}
 
@@ -880,10 +1294,10 @@ func applyAircraftSchedulingConstraints(pod *corev1.Pod, req airforcev1alpha1.Ai
 	if pod.Spec.Affinity.NodeAffinity == nil {
 		pod.Spec.Affinity.NodeAffinity = &corev1.NodeAffinity{}
 	}
-	if len(required) != 0 && pod.Spec.Affinity.NodeAffinity.RequiredDuringSchedulingIgnoredDuringExecution == nil {
+	if (len(required) != 0 || len(fields) != 0) && pod.Spec.Affinity.NodeAffinity.RequiredDuringSchedulingIgnoredDuringExecution == nil {
 		pod.Spec.Affinity.NodeAffinity.RequiredDuringSchedulingIgnoredDuringExecution = &corev1.NodeSelector{
 			NodeSelectorTerms: []corev1.NodeSelectorTerm{
-				{MatchExpressions: required},
+				{MatchExpressions: required, MatchFields: fields},
 			},
 		}
 	}
@@ -892,12 +1306,59 @@ func applyAircraftSchedulingConstraints(pod *corev1.Pod, req airforcev1alpha1.Ai
 	}
 }
 
-func (r *FlightTaskReconciler) injectWeaponSidecars(ctx context.Context, pod *corev1.Pod, task *airforcev1alpha1.FlightTask) error {
+// loadoutAircraftTypes narrows the aircraft types a task accepts to those that can carry
+// its whole loadout. A task that names no type, and is matched by capabilities only, is
+// limited to the types every weapon of its loadout is compatible with. Weapons that do not
+// exist are left to injectWeaponSidecars to report; other errors reading them are returned.
+func (r *FlightTaskReconciler) loadoutAircraftTypes(ctx context.Context, task *airforcev1alpha1.FlightTask, aircraftTypes []string) ([]string, error) {
+	for _, item := range task.Spec.WeaponLoadout {
+		weaponName := strings.TrimSpace(item.WeaponRef.Name)
+		if weaponName == "" {
+			continue
+		}
+		var weapon airforcev1alpha1.Weapon
+		if err := r.Get(ctx, client.ObjectKey{Namespace: task.Namespace, Name: weaponName}, &weapon); err != nil {
+			if apierrors.IsNotFound(err) {
+				continue
+			}
+			return nil, &transientError{err: fmt.Errorf("failed to get weapon %q: %w", weaponName, err)}
+		}
+		if weapon.Spec.Compatibility == nil || len(weapon.Spec.Compatibility.AircraftTypes) == 0 {
+			continue
+		}
+		if len(aircraftTypes) == 0 {
+			// 只按能力匹配时，机型限定为武器兼容的机型
+			aircraftTypes = append([]string(nil), weapon.Spec.Compatibility.AircraftTypes...)
+			continue
+		}
+		var compatible []string
+		for _, t := range aircraftTypes {
+			if containsString(weapon.Spec.Compatibility.AircraftTypes, t) {
+				compatible = append(compatible, t)
+			}
+		}
+		if len(compatible) == 0 {
+			if len(aircraftTypes) == 1 {
+				return nil, &weaponCompatibilityError{msg: fmt.Sprintf("weapon %q is not compatible with aircraft type %q", weaponName, aircraftTypes[0])}
+			}
+			return nil, &weaponCompatibilityError{msg: fmt.Sprintf("weapon %q is not compatible with any of aircraft types %s", weaponName, strings.Join(aircraftTypes, ", "))}
+		}
+		aircraftTypes = compatible
+	}
+	return aircraftTypes, nil
+}
+
+// injectWeaponSidecars adds a sidecar per loaded weapon. aircraftTypes are the types the
+// pod may be scheduled on, already narrowed to those that carry the loadout.
+func (r *FlightTaskReconciler) injectWeaponSidecars(ctx context.Context, pod *corev1.Pod, task *airforcev1alpha1.FlightTask, aircraftTypes []string) error {
 	if len(task.Spec.WeaponLoadout) == 0 {
 		return nil
 	}
 
-	aircraftType := strings.TrimSpace(task.Spec.AircraftRequirement.Type)
+	aircraftType := ""
+	if len(aircraftTypes) != 0 {
+		aircraftType = aircraftTypes[0]
+	}
 	for i := range task.Spec.WeaponLoadout {
 		item := task.Spec.WeaponLoadout[i]
 		weaponName := strings.TrimSpace(item.WeaponRef.Name)
@@ -920,16 +1381,13 @@ func (r *FlightTaskReconciler) injectWeaponSidecars(ctx context.Context, pod *co
 				image = repo
 			}
 		}
+		if weapon.Spec.Image != nil && weapon.Spec.Image.FallbackImage != "" && containsString(task.Status.WeaponImageFallbacks, weaponName) {
+			image = strings.TrimSpace(weapon.Spec.Image.FallbackImage)
+		}
 		if image == "" {
 			return fmt.Errorf("weapon %q missing spec.image.repository", weaponName)
 		}
 
-		if weapon.Spec.Compatibility != nil && len(weapon.Spec.Compatibility.AircraftTypes) != 0 && aircraftType != "" {
-			if !containsString(weapon.Spec.Compatibility.AircraftTypes, aircraftType) {
-				return fmt.Errorf("weapon %q is not compatible with aircraft type %q", weaponName, aircraftType)
-			}
-		}
-
 		if weapon.Spec.Compatibility != nil && len(weapon.Spec.Compatibility.HardpointTypes) != 0 {
 			for j, mp := range item.MountPoints {
 				mp = strings.TrimSpace(mp)
@@ -937,13 +1395,13 @@ func (r *FlightTaskReconciler) injectWeaponSidecars(ctx context.Context, pod *co
 					continue
 				}
 				if !containsString(weapon.Spec.Compatibility.HardpointTypes, mp) {
-					return fmt.Errorf("weapon %q is not compatible with mountPoint %q (index %d)", weaponName, mp, j)
+					return &weaponCompatibilityError{msg: fmt.Sprintf("weapon %q is not compatible with mountPoint %q (index %d)", weaponName, mp, j)}
 				}
 			}
 		}
 
 		baseName := sanitizeDNSLabel("weapon-" + weaponName)
-		containerName := uniqueContainerName(pod.Spec.Containers, baseName)
+		containerName := uniqueContainerName(append(append([]corev1.Container{}, pod.Spec.Containers...), pod.Spec.InitContainers...), baseName)
 
 		env := []corev1.EnvVar{
 			{Name: "WEAPON_NAME", Value: weaponName},
@@ -951,19 +1409,24 @@ func (r *FlightTaskReconciler) injectWeaponSidecars(ctx context.Context, pod *co
 			{Name: "QUANTITY", Value: strconv.FormatInt(int64(item.Quantity), 10)},
 			{Name: "MOUNT_POINTS", Value: strings.Join(item.MountPoints, ",")},
 			{Name: "AIRCRAFT_TYPE", Value: aircraftType},
+			{Name: "AIRCRAFT_TYPES", Value: strings.Join(aircraftTypes, ",")},
 		}
 
-		volumeMounts := []corev1.VolumeMount{{Name: "weapon-interface", MountPath: "/interface"}}
+		volumeMounts := []corev1.VolumeMount{{Name: interfaceVolumeName, MountPath: interfaceMountPath}}
 		if weapon.Spec.Container != nil {
 			env = append(env, weapon.Spec.Container.Env...)
 			volumeMounts = append(volumeMounts, weapon.Spec.Container.VolumeMounts...)
 		}
 
+		// 武器以原生 sidecar（可重启的 init 容器）运行：Pod 的结束只取决于任务容器，
+		// 任务容器退出后 kubelet 终止 sidecar；提前退出的武器会被重启，结果取最后一次上报
+		restartAlways := corev1.ContainerRestartPolicyAlways
 		sidecar := corev1.Container{
-			Name:         containerName,
-			Image:        image,
-			Env:          env,
-			VolumeMounts: volumeMounts,
+			Name:          containerName,
+			Image:         image,
+			Env:           env,
+			VolumeMounts:  volumeMounts,
+			RestartPolicy: &restartAlways,
 		}
 		if weapon.Spec.Image != nil && weapon.Spec.Image.PullPolicy != "" {
 			sidecar.ImagePullPolicy = weapon.Spec.Image.PullPolicy
@@ -977,14 +1440,23 @@ func (r *FlightTaskReconciler) injectWeaponSidecars(ctx context.Context, pod *co
 			}
 		}
 
-		pod.Spec.Containers = append(pod.Spec.Containers, sidecar)
+		pod.Spec.InitContainers = append(pod.Spec.InitContainers, sidecar)
 	}
 
-	ensureEmptyDirVolume(&pod.Spec, "weapon-interface")
-	ensureVolumeMountAllContainers(&pod.Spec, corev1.VolumeMount{Name: "weapon-interface", MountPath: "/interface"})
+	ensureEmptyDirVolume(&pod.Spec, interfaceVolumeName)
+	ensureVolumeMountAllContainers(&pod.Spec, corev1.VolumeMount{Name: interfaceVolumeName, MountPath: interfaceMountPath})
 	return nil
 }
 
+// weaponCompatibilityError marks a loadout rejected because a weapon does not fit the aircraft or mount point.
+type weaponCompatibilityError struct {
+	msg string
+}
+
+func (e *weaponCompatibilityError) Error() string {
+	return e.msg
+}
+
 func containsString(values []string, needle string) bool {
 	for _, v := range values {
 		if strings.EqualFold(strings.TrimSpace(v), needle) {
@@ -1108,6 +1580,12 @@ func ensureVolumeMountAllContainers(podSpec *corev1.PodSpec, mount corev1.Volume
 		}
 		podSpec.Containers[ci].VolumeMounts = append(podSpec.Containers[ci].VolumeMounts, mount)
 	}
+	for ci := range podSpec.InitContainers {
+		if hasVolumeMount(podSpec.InitContainers[ci].VolumeMounts, mount.Name, mount.MountPath) {
+			continue
+		}
+		podSpec.InitContainers[ci].VolumeMounts = append(podSpec.InitContainers[ci].VolumeMounts, mount)
+	}
 }
 
 func hasVolumeMount(mounts []corev1.VolumeMount, name, mountPath string) bool {
@@ -1120,41 +1598,26 @@ func hasVolumeMount(mounts []corev1.VolumeMount, name, mountPath string) bool {
 }
 
 // applyDistanceBasedScheduling 应用基于距离的调度偏好
-func (r *FlightTaskReconciler) applyDistanceBasedScheduling(ctx context.Context, pod *corev1.Pod, task *airforcev1alpha1.FlightTask) error {
+// 按任务自己的目标（targetRef）计算各节点距离
+func (r *FlightTaskReconciler) applyDistanceBasedScheduling(ctx context.Context, pod *corev1.Pod, target *airforcev1alpha1.MissionTarget) error {
 	log := log.FromContext(ctx)
 
-	// 1. 获取Mission名称
-	missionName := task.Labels["mission"]
-	if missionName == "" {
-		return nil // 没有关联Mission，跳过
-	}
-
-	// 2. 查询Mission
-	mission := &airforcev1alpha1.Mission{}
-	err := r.Client.Get(ctx, client.ObjectKey{
-		Namespace: task.Namespace,
-		Name:      missionName,
-	}, mission)
-	if err != nil {
-		return fmt.Errorf("failed to get mission %s: %w", missionName, err)
-	}
-
-	// 3. 检查是否有目标坐标
-	if mission.Spec.Objective == nil || mission.Spec.Objective.TargetCoordinates == nil {
+	// 1. 检查是否有目标坐标
+	if target == nil || target.Coordinates == nil {
 		return nil // 没有目标坐标，跳过
 	}
 
-	targetCoords := mission.Spec.Objective.TargetCoordinates
-	log.Info("found target coordinates", "mission", missionName,
+	targetCoords := target.Coordinates
+	log.Info("found target coordinates", "target", target.Name,
 		"latitude", targetCoords.Latitude, "longitude", targetCoords.Longitude)
 
-	// 4. 解析目标坐标
-	targetLat, targetLon, err := parseCoordinates(targetCoords)
+	// 2. 解析目标坐标
+	targetPoint, err := geoPoint(targetCoords)
 	if err != nil {
 		return fmt.Errorf("failed to parse target coordinates: %w", err)
 	}
 
-	// 5. 获取所有符合条件的节点
+	// 3. 获取所有符合条件的节点
 	nodeSelector := pod.Spec.NodeSelector
 	if nodeSelector == nil {
 		nodeSelector = make(map[string]string)
@@ -1169,7 +1632,7 @@ func (r *FlightTaskReconciler) applyDistanceBasedScheduling(ctx context.Context,
 		return fmt.Errorf("failed to list nodes: %w", err)
 	}
 
-	// 6. 计算每个节点的距离
+	// 4. 计算每个节点的距离
 	type nodeDistance struct {
 		name     string
 		distance float64
@@ -1178,24 +1641,13 @@ func (r *FlightTaskReconciler) applyDistanceBasedScheduling(ctx context.Context,
 	var distances []nodeDistance
 
 	for _, node := range nodeList.Items {
-		latStr := node.Labels["aircraft.mil/location.latitude"]
-		lonStr := node.Labels["aircraft.mil/location.longitude"]
-
-		if latStr == "" || lonStr == "" {
+		nodePoint, ok := nodeLocation(&node)
+		if !ok {
 			continue // 跳过没有坐标的节点
 		}
 
-		nodeLat, err := strconv.ParseFloat(latStr, 64)
-		if err != nil {
-			continue
-		}
-		nodeLon, err := strconv.ParseFloat(lonStr, 64)
-		if err != nil {
-			continue
-		}
-
 		// 计算距离
-		distance := haversineDistance(targetLat, targetLon, nodeLat, nodeLon)
+		distance := geo.Distance(targetPoint, nodePoint)
 		weight := distanceToWeight(distance)
 
 		distances = append(distances, nodeDistance{
@@ -1210,7 +1662,7 @@ func (r *FlightTaskReconciler) applyDistanceBasedScheduling(ctx context.Context,
 		return nil
 	}
 
-	// 7. 按距离排序
+	// 5. 按距离排序
 	sort.Slice(distances, func(i, j int) bool {
 		return distances[i].distance < distances[j].distance
 	})
@@ -1218,7 +1670,7 @@ func (r *FlightTaskReconciler) applyDistanceBasedScheduling(ctx context.Context,
 	log.Info("calculated node distances", "count", len(distances),
 		"nearest", distances[0].name, "distance", distances[0].distance)
 
-	// 8. 生成PreferredSchedulingTerms（最多8个）
+	// 6. 生成PreferredSchedulingTerms（最多8个）
 	maxTerms := 8
 	if len(distances) < maxTerms {
 		maxTerms = len(distances)
@@ -1242,7 +1694,7 @@ func (r *FlightTaskReconciler) applyDistanceBasedScheduling(ctx context.Context,
 		preferences = append(preferences, term)
 	}
 
-	// 9. 注入到Pod的Affinity中
+	// 7. 注入到Pod的Affinity中
 	if pod.Spec.Affinity == nil {
 		pod.Spec.Affinity = &corev1.Affinity{}
 	}
@@ -1261,48 +1713,6 @@ func (r *FlightTaskReconciler) applyDistanceBasedScheduling(ctx context.Context,
 	return nil
 }
 
-// parseCoordinates 解析GeoCoordinates为浮点数
-func parseCoordinates(coords *airforcev1alpha1.GeoCoordinates) (lat, lon float64, err error) {
-	if coords == nil {
-		return 0, 0, fmt.Errorf("coordinates is nil")
-	}
-
-	if coords.Latitude == "" || coords.Longitude == "" {
-		return 0, 0, fmt.Errorf("latitude or longitude is empty")
-	}
-
-	lat, err = strconv.ParseFloat(coords.Latitude, 64)
-	if err != nil {
-		return 0, 0, fmt.Errorf("invalid latitude: %w", err)
-	}
-
-	lon, err = strconv.ParseFloat(coords.Longitude, 64)
-	if err != nil {
-		return 0, 0, fmt.Errorf("invalid longitude: %w", err)
-	}
-
-	return lat, lon, nil
-}
-
-// haversineDistance 使用Haversine公式计算球面距离（公里）
-func haversineDistance(lat1, lon1, lat2, lon2 float64) float64 {
-	const earthRadiusKm = 6371.0
-
-	// 转换为弧度
-	lat1Rad := lat1 * math.Pi / 180
-	lat2Rad := lat2 * math.Pi / 180
-	deltaLat := (lat2 - lat1) * math.Pi / 180
-	deltaLon := (lon2 - lon1) * math.Pi / 180
-
-	// Haversine公式
-	a := math.Sin(deltaLat/2)*math.Sin(deltaLat/2) +
-		math.Cos(lat1Rad)*math.Cos(lat2Rad)*
-			math.Sin(deltaLon/2)*math.Sin(deltaLon/2)
-	c := 2 * math.Atan2(math.Sqrt(a), math.Sqrt(1-a))
-
-	return earthRadiusKm * c
-}
-
 // distanceToWeight 将距离转换为调度权重（5-100）
 func distanceToWeight(distanceKm float64) int32 {
 	weight := 100 - int32(distanceKm/10)
@@ -1320,5 +1730,10 @@ func (r *FlightTaskReconciler) SetupWithManager(mgr ctrl.Manager) error {
 	return ctrl.NewControllerManagedBy(mgr).
 		For(&airforcev1alpha1.FlightTask{}).
 		Owns(&corev1.Pod{}).
+		Owns(&corev1.ConfigMap{}).
+		// 简报只依赖 Mission spec，忽略 Mission 状态的频繁更新
+		Watches(&airforcev1alpha1.Mission{}, handler.EnqueueRequestsFromMapFunc(r.flightTasksForMission),
+			builder.WithPredicates(predicate.GenerationChangedPredicate{})).
+		Watches(&airforcev1alpha1.WaypointSet{}, handler.EnqueueRequestsFromMapFunc(r.flightTasksForWaypointSet)).
 		Complete(r)
 }
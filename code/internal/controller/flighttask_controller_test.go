@@ -18,11 +18,14 @@ package controller
 
 import (
 	"context"
+	"encoding/json"
+	"time"
 
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
+	apimeta "k8s.io/apimachinery/pkg/api/meta"
 	"k8s.io/apimachinery/pkg/types"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
@@ -30,6 +33,7 @@ import (
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 
 	airforcev1alpha1 "github.com/yydashuai/mission-system/api/v1alpha1"
+	"github.com/yydashuai/mission-system/internal/geo"
 )
 
 var _ = Describe("FlightTask Controller", func() {
@@ -142,14 +146,19 @@ var _ = Describe("FlightTask Controller", func() {
 			Expect(pod.Spec.Affinity.NodeAffinity.RequiredDuringSchedulingIgnoredDuringExecution).NotTo(BeNil())
 			Expect(pod.Spec.Affinity.NodeAffinity.RequiredDuringSchedulingIgnoredDuringExecution.NodeSelectorTerms).NotTo(BeEmpty())
 
+			// 武器以原生 sidecar 注入，不参与 Pod 的完成判定
 			foundSidecar := false
-			for i := range pod.Spec.Containers {
-				if pod.Spec.Containers[i].Name == "weapon-pl-15" {
+			for i := range pod.Spec.InitContainers {
+				if pod.Spec.InitContainers[i].Name == "weapon-pl-15" {
 					foundSidecar = true
+					Expect(pod.Spec.InitContainers[i].RestartPolicy).To(HaveValue(Equal(corev1.ContainerRestartPolicyAlways)))
 					break
 				}
 			}
 			Expect(foundSidecar).To(BeTrue())
+			for i := range pod.Spec.Containers {
+				Expect(pod.Spec.Containers[i].Name).NotTo(Equal("weapon-pl-15"))
+			}
 
 			var updated airforcev1alpha1.FlightTask
 			Expect(k8sClient.Get(ctx, typeNamespacedName, &updated)).To(Succeed())
@@ -158,5 +167,993 @@ var _ = Describe("FlightTask Controller", func() {
 			Expect(updated.Status.PodRef.Name).To(Equal(pod.Name))
 			Expect(updated.Status.PodRef.UID).NotTo(BeEmpty())
 		})
+
+		It("should capture the task result from termination messages", func() {
+			controllerReconciler := &FlightTaskReconciler{
+				Client: k8sClient,
+				Scheme: k8sClient.Scheme(),
+			}
+
+			_, err := controllerReconciler.Reconcile(ctx, reconcile.Request{NamespacedName: typeNamespacedName})
+			Expect(err).NotTo(HaveOccurred())
+
+			var pod corev1.Pod
+			Expect(k8sClient.Get(ctx, types.NamespacedName{Name: resourceName + "-pod", Namespace: "default"}, &pod)).To(Succeed())
+			for _, c := range append(pod.Spec.Containers, pod.Spec.InitContainers...) {
+				Expect(c.TerminationMessagePath).To(Equal(taskResultPath))
+			}
+
+			terminated := func(name, message string) corev1.ContainerStatus {
+				return corev1.ContainerStatus{
+					Name:  name,
+					Image: "busybox:1.36",
+					State: corev1.ContainerState{Terminated: &corev1.ContainerStateTerminated{
+						ExitCode: 0,
+						Reason:   "Completed",
+						Message:  message,
+					}},
+				}
+			}
+			pod.Status.Phase = corev1.PodSucceeded
+			pod.Status.ContainerStatuses = []corev1.ContainerStatus{
+				terminated("task", `{"fuelRemaining": 35, "weaponsRemaining": {"pl-15": 2}, "outputs": {"bda": "destroyed", "kills": 1}}`),
+			}
+			pod.Status.InitContainerStatuses = []corev1.ContainerStatus{
+				terminated("weapon-pl-15", `{"remaining": 1, "outputs": {"hits": "1"}}`),
+			}
+			Expect(k8sClient.Status().Update(ctx, &pod)).To(Succeed())
+
+			_, err = controllerReconciler.Reconcile(ctx, reconcile.Request{NamespacedName: typeNamespacedName})
+			Expect(err).NotTo(HaveOccurred())
+
+			var updated airforcev1alpha1.FlightTask
+			Expect(k8sClient.Get(ctx, typeNamespacedName, &updated)).To(Succeed())
+			Expect(updated.Status.Phase).To(Equal(airforcev1alpha1.FlightTaskPhaseSucceeded))
+			Expect(updated.Status.ExecutionStatus).NotTo(BeNil())
+			Expect(updated.Status.ExecutionStatus.FuelRemaining).To(Equal(int32(35)))
+			Expect(updated.Status.ExecutionStatus.WeaponsRemaining).To(HaveKeyWithValue("pl-15", int32(1)))
+			Expect(updated.Status.Outputs).To(HaveKeyWithValue("bda", "destroyed"))
+			Expect(updated.Status.Outputs).To(HaveKeyWithValue("kills", "1"))
+			Expect(updated.Status.Outputs).To(HaveKeyWithValue("pl-15.hits", "1"))
+		})
+
+		It("should keep the result of a weapon that exits before the task container", func() {
+			controllerReconciler := &FlightTaskReconciler{
+				Client: k8sClient,
+				Scheme: k8sClient.Scheme(),
+			}
+
+			_, err := controllerReconciler.Reconcile(ctx, reconcile.Request{NamespacedName: typeNamespacedName})
+			Expect(err).NotTo(HaveOccurred())
+
+			var pod corev1.Pod
+			Expect(k8sClient.Get(ctx, types.NamespacedName{Name: resourceName + "-pod", Namespace: "default"}, &pod)).To(Succeed())
+
+			// 武器先于任务容器退出并被 kubelet 重启：Pod 仍在运行
+			early := &corev1.ContainerStateTerminated{ExitCode: 0, Reason: "Completed", Message: `{"remaining": 0, "outputs": {"hits": "2"}}`}
+			pod.Status.Phase = corev1.PodRunning
+			pod.Status.ContainerStatuses = []corev1.ContainerStatus{{
+				Name: "task", Image: "busybox:1.36", State: corev1.ContainerState{Running: &corev1.ContainerStateRunning{}},
+			}}
+			pod.Status.InitContainerStatuses = []corev1.ContainerStatus{{
+				Name:                 "weapon-pl-15",
+				Image:                "example.com/weapons/pl-15:v1",
+				RestartCount:         1,
+				State:                corev1.ContainerState{Running: &corev1.ContainerStateRunning{}},
+				LastTerminationState: corev1.ContainerState{Terminated: early},
+			}}
+			Expect(k8sClient.Status().Update(ctx, &pod)).To(Succeed())
+
+			_, err = controllerReconciler.Reconcile(ctx, reconcile.Request{NamespacedName: typeNamespacedName})
+			Expect(err).NotTo(HaveOccurred())
+			var updated airforcev1alpha1.FlightTask
+			Expect(k8sClient.Get(ctx, typeNamespacedName, &updated)).To(Succeed())
+			Expect(updated.Status.Phase).To(Equal(airforcev1alpha1.FlightTaskPhaseRunning))
+
+			// 任务容器结束后 kubelet 终止重启后的武器，它没有再上报结果
+			Expect(k8sClient.Get(ctx, types.NamespacedName{Name: resourceName + "-pod", Namespace: "default"}, &pod)).To(Succeed())
+			pod.Status.Phase = corev1.PodSucceeded
+			pod.Status.ContainerStatuses[0].State = corev1.ContainerState{Terminated: &corev1.ContainerStateTerminated{
+				ExitCode: 0, Reason: "Completed", Message: `{"fuelRemaining": 20}`,
+			}}
+			pod.Status.InitContainerStatuses[0].State = corev1.ContainerState{Terminated: &corev1.ContainerStateTerminated{
+				ExitCode: 143, Reason: "Error",
+			}}
+			Expect(k8sClient.Status().Update(ctx, &pod)).To(Succeed())
+
+			_, err = controllerReconciler.Reconcile(ctx, reconcile.Request{NamespacedName: typeNamespacedName})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(k8sClient.Get(ctx, typeNamespacedName, &updated)).To(Succeed())
+			Expect(updated.Status.Phase).To(Equal(airforcev1alpha1.FlightTaskPhaseSucceeded))
+			Expect(updated.Status.ExecutionStatus.FuelRemaining).To(Equal(int32(20)))
+			Expect(updated.Status.ExecutionStatus.WeaponsRemaining).To(HaveKeyWithValue("pl-15", int32(0)))
+			Expect(updated.Status.Outputs).To(HaveKeyWithValue("pl-15.hits", "2"))
+			Expect(apimeta.FindStatusCondition(updated.Status.Conditions, conditionResultCaptured).Reason).To(Equal("Captured"))
+		})
+	})
+
+	Context("When the Mission runs in simulation mode", func() {
+		const (
+			missionName = "sim-mission"
+			taskName    = "sim-task"
+			nodeName    = "sim-j20-01"
+		)
+
+		ctx := context.Background()
+		taskKey := types.NamespacedName{Name: taskName, Namespace: "default"}
+
+		BeforeEach(func() {
+			node := &corev1.Node{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: nodeName,
+					Labels: map[string]string{
+						"aircraft.mil/type":   "j20",
+						"aircraft.mil/status": "ready",
+					},
+				},
+			}
+			Expect(k8sClient.Create(ctx, node)).To(Succeed())
+
+			mission := &airforcev1alpha1.Mission{
+				ObjectMeta: metav1.ObjectMeta{Name: missionName, Namespace: "default"},
+				Spec: airforcev1alpha1.MissionSpec{
+					Config: &airforcev1alpha1.MissionConfig{
+						Simulation: &airforcev1alpha1.SimulationConfig{
+							Enabled:   true,
+							TimeScale: 100000,
+						},
+					},
+				},
+			}
+			Expect(k8sClient.Create(ctx, mission)).To(Succeed())
+
+			task := &airforcev1alpha1.FlightTask{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      taskName,
+					Namespace: "default",
+					Labels:    map[string]string{"mission": missionName, "stage": "s1"},
+				},
+				Spec: airforcev1alpha1.FlightTaskSpec{
+					StageRef:            airforcev1alpha1.MissionStageRef{Name: "s1"},
+					AircraftRequirement: airforcev1alpha1.AircraftRequirement{Type: "j20"},
+					TaskParams: &airforcev1alpha1.FlightTaskParams{
+						Phases: []airforcev1alpha1.TaskPhase{
+							{Name: "ingress", Duration: &metav1.Duration{Duration: time.Minute}},
+							{Name: "egress", Duration: &metav1.Duration{Duration: time.Minute}},
+						},
+					},
+				},
+			}
+			Expect(k8sClient.Create(ctx, task)).To(Succeed())
+			patch := client.MergeFrom(task.DeepCopy())
+			task.Status.Phase = airforcev1alpha1.FlightTaskPhaseScheduled
+			Expect(k8sClient.Status().Patch(ctx, task, patch)).To(Succeed())
+		})
+
+		AfterEach(func() {
+			_ = k8sClient.Delete(ctx, &airforcev1alpha1.FlightTask{ObjectMeta: metav1.ObjectMeta{Name: taskName, Namespace: "default"}})
+			_ = k8sClient.Delete(ctx, &airforcev1alpha1.Mission{ObjectMeta: metav1.ObjectMeta{Name: missionName, Namespace: "default"}})
+			_ = k8sClient.Delete(ctx, &corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: nodeName}})
+		})
+
+		It("should assign a simulated aircraft and complete without creating a pod", func() {
+			controllerReconciler := &FlightTaskReconciler{
+				Client: k8sClient,
+				Scheme: k8sClient.Scheme(),
+			}
+
+			_, err := controllerReconciler.Reconcile(ctx, reconcile.Request{NamespacedName: taskKey})
+			Expect(err).NotTo(HaveOccurred())
+
+			var updated airforcev1alpha1.FlightTask
+			Expect(k8sClient.Get(ctx, taskKey, &updated)).To(Succeed())
+			Expect(updated.Status.Phase).To(Equal(airforcev1alpha1.FlightTaskPhaseRunning))
+			Expect(updated.Status.PodRef).To(BeNil())
+			Expect(updated.Status.SchedulingInfo).NotTo(BeNil())
+			Expect(updated.Status.SchedulingInfo.AssignedNode).To(Equal(nodeName))
+
+			var pod corev1.Pod
+			err = k8sClient.Get(ctx, types.NamespacedName{Name: taskName + "-pod", Namespace: "default"}, &pod)
+			Expect(errors.IsNotFound(err)).To(BeTrue())
+
+			Eventually(func() airforcev1alpha1.FlightTaskPhase {
+				_, err := controllerReconciler.Reconcile(ctx, reconcile.Request{NamespacedName: taskKey})
+				Expect(err).NotTo(HaveOccurred())
+				Expect(k8sClient.Get(ctx, taskKey, &updated)).To(Succeed())
+				return updated.Status.Phase
+			}, 5*time.Second, 100*time.Millisecond).Should(Equal(airforcev1alpha1.FlightTaskPhaseSucceeded))
+			Expect(updated.Status.ExecutionStatus).NotTo(BeNil())
+			Expect(updated.Status.ExecutionStatus.CurrentPhase).To(Equal("egress"))
+		})
+
+		It("should wait for a missing WaypointSet and record the route of a simulated task", func() {
+			controllerReconciler := &FlightTaskReconciler{
+				Client: k8sClient,
+				Scheme: k8sClient.Scheme(),
+			}
+			var task airforcev1alpha1.FlightTask
+			Expect(k8sClient.Get(ctx, taskKey, &task)).To(Succeed())
+			task.Spec.TaskParams.WaypointSetRef = "sim-route"
+			task.Spec.TaskParams.Phases[0].Waypoints = []string{"ip"}
+			Expect(k8sClient.Update(ctx, &task)).To(Succeed())
+
+			_, err := controllerReconciler.Reconcile(ctx, reconcile.Request{NamespacedName: taskKey})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(k8sClient.Get(ctx, taskKey, &task)).To(Succeed())
+			Expect(task.Status.Phase).To(Equal(airforcev1alpha1.FlightTaskPhaseScheduled))
+			cond := apimeta.FindStatusCondition(task.Status.Conditions, "PodCreated")
+			Expect(cond).NotTo(BeNil())
+			Expect(cond.Reason).To(Equal("WaitingForWaypointSet"))
+
+			set := &airforcev1alpha1.WaypointSet{
+				ObjectMeta: metav1.ObjectMeta{Name: "sim-route", Namespace: "default"},
+				Spec: airforcev1alpha1.WaypointSetSpec{Waypoints: []airforcev1alpha1.Waypoint{
+					{Name: "ip", Coordinates: airforcev1alpha1.GeoCoordinates{Latitude: "30.5", Longitude: "120.0"}},
+				}},
+			}
+			Expect(k8sClient.Create(ctx, set)).To(Succeed())
+			DeferCleanup(func() { _ = k8sClient.Delete(ctx, set) })
+
+			_, err = controllerReconciler.Reconcile(ctx, reconcile.Request{NamespacedName: taskKey})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(k8sClient.Get(ctx, taskKey, &task)).To(Succeed())
+			Expect(task.Status.Phase).To(Equal(airforcev1alpha1.FlightTaskPhaseRunning))
+			Expect(task.Status.Route).NotTo(BeNil())
+		})
+	})
+
+	Context("When the Mission objective has several targets", func() {
+		const (
+			missionName = "multi-target"
+			taskName    = "multi-target-strike"
+		)
+
+		ctx := context.Background()
+		taskKey := types.NamespacedName{Name: taskName, Namespace: "default"}
+		nodes := map[string][2]string{
+			"mt-j20-north": {"40.0", "116.0"},
+			"mt-j20-south": {"22.5", "114.0"},
+		}
+
+		BeforeEach(func() {
+			for name, loc := range nodes {
+				node := &corev1.Node{
+					ObjectMeta: metav1.ObjectMeta{
+						Name: name,
+						Labels: map[string]string{
+							"aircraft.mil/type":               "j20",
+							"aircraft.mil/status":             "ready",
+							"aircraft.mil/location.latitude":  loc[0],
+							"aircraft.mil/location.longitude": loc[1],
+						},
+					},
+				}
+				Expect(k8sClient.Create(ctx, node)).To(Succeed())
+			}
+
+			mission := &airforcev1alpha1.Mission{
+				ObjectMeta: metav1.ObjectMeta{Name: missionName, Namespace: "default"},
+				Spec: airforcev1alpha1.MissionSpec{
+					Objective: &airforcev1alpha1.MissionObjective{
+						Targets: []airforcev1alpha1.MissionTarget{
+							{Name: "radar", Priority: airforcev1alpha1.MissionPriorityCritical,
+								Coordinates: &airforcev1alpha1.GeoCoordinates{Latitude: "41.0", Longitude: "117.0"}},
+							{Name: "port", Priority: airforcev1alpha1.MissionPriorityHigh, Description: "harbour",
+								Coordinates: &airforcev1alpha1.GeoCoordinates{Latitude: "22.0", Longitude: "113.5"}},
+						},
+					},
+				},
+			}
+			Expect(k8sClient.Create(ctx, mission)).To(Succeed())
+
+			task := &airforcev1alpha1.FlightTask{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      taskName,
+					Namespace: "default",
+					Labels:    map[string]string{"mission": missionName, "stage": "s1"},
+				},
+				Spec: airforcev1alpha1.FlightTaskSpec{
+					StageRef:            airforcev1alpha1.MissionStageRef{Name: "s1"},
+					AircraftRequirement: airforcev1alpha1.AircraftRequirement{Type: "j20"},
+					TargetRef:           "port",
+				},
+			}
+			Expect(k8sClient.Create(ctx, task)).To(Succeed())
+			patch := client.MergeFrom(task.DeepCopy())
+			task.Status.Phase = airforcev1alpha1.FlightTaskPhaseScheduled
+			Expect(k8sClient.Status().Patch(ctx, task, patch)).To(Succeed())
+		})
+
+		AfterEach(func() {
+			_ = k8sClient.Delete(ctx, &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: taskName + "-pod", Namespace: "default"}})
+			_ = k8sClient.Delete(ctx, &airforcev1alpha1.FlightTask{ObjectMeta: metav1.ObjectMeta{Name: taskName, Namespace: "default"}})
+			_ = k8sClient.Delete(ctx, &airforcev1alpha1.Mission{ObjectMeta: metav1.ObjectMeta{Name: missionName, Namespace: "default"}})
+			for name := range nodes {
+				_ = k8sClient.Delete(ctx, &corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: name}})
+			}
+		})
+
+		It("should schedule against the task's own target and pass it to the task container", func() {
+			controllerReconciler := &FlightTaskReconciler{
+				Client: k8sClient,
+				Scheme: k8sClient.Scheme(),
+			}
+			_, err := controllerReconciler.Reconcile(ctx, reconcile.Request{NamespacedName: taskKey})
+			Expect(err).NotTo(HaveOccurred())
+
+			var pod corev1.Pod
+			Expect(k8sClient.Get(ctx, types.NamespacedName{Name: taskName + "-pod", Namespace: "default"}, &pod)).To(Succeed())
+			preferred := pod.Spec.Affinity.NodeAffinity.PreferredDuringSchedulingIgnoredDuringExecution
+			Expect(preferred).NotTo(BeEmpty())
+			Expect(preferred[0].Preference.MatchExpressions[0].Values).To(Equal([]string{"mt-j20-south"}))
+
+			env := map[string]string{}
+			for _, e := range pod.Spec.Containers[0].Env {
+				env[e.Name] = e.Value
+			}
+			Expect(env).To(HaveKeyWithValue("TARGET_NAME", "port"))
+			Expect(env).To(HaveKeyWithValue("TARGET_DESCRIPTION", "harbour"))
+			Expect(env).To(HaveKeyWithValue("TARGET_LATITUDE", "22.0"))
+		})
+
+		It("should pin the pod to the aircraft node the task names", func() {
+			task := &airforcev1alpha1.FlightTask{}
+			Expect(k8sClient.Get(ctx, taskKey, task)).To(Succeed())
+			task.Spec.AircraftRequirement.Node = "mt-j20-north"
+			Expect(k8sClient.Update(ctx, task)).To(Succeed())
+
+			controllerReconciler := &FlightTaskReconciler{
+				Client: k8sClient,
+				Scheme: k8sClient.Scheme(),
+			}
+			_, err := controllerReconciler.Reconcile(ctx, reconcile.Request{NamespacedName: taskKey})
+			Expect(err).NotTo(HaveOccurred())
+
+			var pod corev1.Pod
+			Expect(k8sClient.Get(ctx, types.NamespacedName{Name: taskName + "-pod", Namespace: "default"}, &pod)).To(Succeed())
+			terms := pod.Spec.Affinity.NodeAffinity.RequiredDuringSchedulingIgnoredDuringExecution.NodeSelectorTerms
+			Expect(terms).To(HaveLen(1))
+			Expect(terms[0].MatchFields).To(Equal([]corev1.NodeSelectorRequirement{
+				{Key: "metadata.name", Operator: corev1.NodeSelectorOpIn, Values: []string{"mt-j20-north"}},
+			}))
+		})
+
+		It("should fail the task without creating a pod when targetRef names no target", func() {
+			task := &airforcev1alpha1.FlightTask{}
+			Expect(k8sClient.Get(ctx, taskKey, task)).To(Succeed())
+			task.Spec.TargetRef = "bunker"
+			Expect(k8sClient.Update(ctx, task)).To(Succeed())
+
+			controllerReconciler := &FlightTaskReconciler{
+				Client: k8sClient,
+				Scheme: k8sClient.Scheme(),
+			}
+			_, err := controllerReconciler.Reconcile(ctx, reconcile.Request{NamespacedName: taskKey})
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(k8sClient.Get(ctx, taskKey, task)).To(Succeed())
+			Expect(task.Status.Phase).To(Equal(airforcev1alpha1.FlightTaskPhaseFailed))
+			cond := apimeta.FindStatusCondition(task.Status.Conditions, "PodCreated")
+			Expect(cond).NotTo(BeNil())
+			Expect(cond.Reason).To(Equal("UnknownTarget"))
+			err = k8sClient.Get(ctx, types.NamespacedName{Name: taskName + "-pod", Namespace: "default"}, &corev1.Pod{})
+			Expect(errors.IsNotFound(err)).To(BeTrue())
+		})
+	})
+
+	Context("When the target is beyond the range of the loaded weapon", func() {
+		ctx := context.Background()
+
+		BeforeEach(func() {
+			node := &corev1.Node{ObjectMeta: metav1.ObjectMeta{
+				Name: "rc-j20",
+				Labels: map[string]string{
+					"aircraft.mil/type":               "j20",
+					"aircraft.mil/location.latitude":  "40.0",
+					"aircraft.mil/location.longitude": "116.0",
+				},
+			}}
+			Expect(k8sClient.Create(ctx, node)).To(Succeed())
+			weapon := &airforcev1alpha1.Weapon{
+				ObjectMeta: metav1.ObjectMeta{Name: "rc-pl15", Namespace: "default"},
+				Spec: airforcev1alpha1.WeaponSpec{
+					Specifications: &airforcev1alpha1.WeaponSpecifications{Range: "200km"},
+				},
+			}
+			Expect(k8sClient.Create(ctx, weapon)).To(Succeed())
+			mission := &airforcev1alpha1.Mission{
+				ObjectMeta: metav1.ObjectMeta{Name: "range-check", Namespace: "default"},
+				Spec: airforcev1alpha1.MissionSpec{
+					Objective: &airforcev1alpha1.MissionObjective{
+						Targets: []airforcev1alpha1.MissionTarget{
+							{Name: "far", Coordinates: &airforcev1alpha1.GeoCoordinates{Latitude: "30.0", Longitude: "116.0"}},
+						},
+					},
+					Config: &airforcev1alpha1.MissionConfig{RangeCheck: airforcev1alpha1.RangeCheckFail},
+				},
+			}
+			Expect(k8sClient.Create(ctx, mission)).To(Succeed())
+		})
+
+		AfterEach(func() {
+			_ = k8sClient.Delete(ctx, &airforcev1alpha1.Mission{ObjectMeta: metav1.ObjectMeta{Name: "range-check", Namespace: "default"}})
+			_ = k8sClient.Delete(ctx, &airforcev1alpha1.Weapon{ObjectMeta: metav1.ObjectMeta{Name: "rc-pl15", Namespace: "default"}})
+			_ = k8sClient.Delete(ctx, &corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "rc-j20"}})
+		})
+
+		It("should report the task out of range with the mission's policy", func() {
+			controllerReconciler := &FlightTaskReconciler{
+				Client: k8sClient,
+				Scheme: k8sClient.Scheme(),
+			}
+			task := &airforcev1alpha1.FlightTask{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "range-check-strike",
+					Namespace: "default",
+					Labels:    map[string]string{"mission": "range-check"},
+				},
+				Spec: airforcev1alpha1.FlightTaskSpec{
+					TargetRef: "far",
+					WeaponLoadout: []airforcev1alpha1.FlightTaskWeaponLoadoutItem{
+						{WeaponRef: airforcev1alpha1.WeaponRef{Name: "rc-pl15"}, Quantity: 2},
+					},
+				},
+			}
+
+			cond, policy, err := controllerReconciler.checkWeaponRange(ctx, task, "rc-j20")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(policy).To(Equal(airforcev1alpha1.RangeCheckFail))
+			Expect(cond.Status).To(Equal(metav1.ConditionFalse))
+			Expect(cond.Reason).To(Equal("OutOfRange"))
+			Expect(cond.Message).To(ContainSubstring("rc-pl15 (200 km)"))
+
+			task.Spec.WeaponLoadout = nil
+			cond, _, err = controllerReconciler.checkWeaponRange(ctx, task, "rc-j20")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(cond.Status).To(Equal(metav1.ConditionUnknown))
+		})
+	})
+
+	Context("When the task phases fly a route of named waypoints", func() {
+		ctx := context.Background()
+
+		BeforeEach(func() {
+			node := &corev1.Node{ObjectMeta: metav1.ObjectMeta{
+				Name: "route-j20",
+				Labels: map[string]string{
+					"aircraft.mil/type":               "j20",
+					"aircraft.mil/location.latitude":  "30.0",
+					"aircraft.mil/location.longitude": "120.0",
+					"aircraft.mil/range":              "150km",
+				},
+			}}
+			Expect(k8sClient.Create(ctx, node)).To(Succeed())
+			set := &airforcev1alpha1.WaypointSet{
+				ObjectMeta: metav1.ObjectMeta{Name: "route-east", Namespace: "default"},
+				Spec: airforcev1alpha1.WaypointSetSpec{
+					Waypoints: []airforcev1alpha1.Waypoint{
+						{Name: "ip", Coordinates: airforcev1alpha1.GeoCoordinates{Latitude: "30.5", Longitude: "120.0"}},
+						{Name: "far", Coordinates: airforcev1alpha1.GeoCoordinates{Latitude: "32.0", Longitude: "120.0"}},
+					},
+				},
+			}
+			Expect(k8sClient.Create(ctx, set)).To(Succeed())
+		})
+
+		AfterEach(func() {
+			_ = k8sClient.Delete(ctx, &airforcev1alpha1.WaypointSet{ObjectMeta: metav1.ObjectMeta{Name: "route-east", Namespace: "default"}})
+			_ = k8sClient.Delete(ctx, &corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "route-j20"}})
+		})
+
+		It("should resolve the route, compute ETAs and check legs against the aircraft range", func() {
+			controllerReconciler := &FlightTaskReconciler{
+				Client: k8sClient,
+				Scheme: k8sClient.Scheme(),
+			}
+			task := &airforcev1alpha1.FlightTask{
+				ObjectMeta: metav1.ObjectMeta{Name: "route-strike", Namespace: "default"},
+				Spec: airforcev1alpha1.FlightTaskSpec{
+					TaskParams: &airforcev1alpha1.FlightTaskParams{
+						Speed:          "900km/h",
+						WaypointSetRef: "route-east",
+						Waypoints: []airforcev1alpha1.Waypoint{
+							{Name: "home", Coordinates: airforcev1alpha1.GeoCoordinates{Latitude: "30°N", Longitude: "120°E"}},
+						},
+						Phases: []airforcev1alpha1.TaskPhase{
+							{Name: "ingress", Waypoints: []string{"home", "ip"}},
+							{Name: "combat"},
+							{Name: "strike", Waypoints: []string{"far"}},
+						},
+					},
+				},
+			}
+
+			route, err := controllerReconciler.resolveTaskRoute(ctx, task, nil)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(route.Waypoints).To(HaveLen(3))
+			Expect(route.Waypoints[0].Coordinates.Latitude).To(Equal("30.000000"))
+			Expect(route.Waypoints[1].LegKm).To(BeEquivalentTo(56))
+			Expect(route.DistanceKm).To(BeEquivalentTo(222))
+			Expect(route.Phases).To(HaveLen(2))
+			Expect(route.Phases[1].Name).To(Equal("strike"))
+			Expect(route.Phases[1].ETA.Duration.Round(time.Minute)).To(Equal(15 * time.Minute))
+
+			area := geo.Circle{Center: geo.Point{Lat: 30, Lon: 120}, RadiusKm: 100}
+			_, err = controllerReconciler.resolveTaskRoute(ctx, task, area)
+			Expect(err).To(MatchError(ContainSubstring(`waypoint "far"`)))
+
+			task.Spec.TaskParams.Phases[0].Waypoints = []string{"home", "nowhere"}
+			_, err = controllerReconciler.resolveTaskRoute(ctx, task, nil)
+			Expect(err).To(MatchError(ContainSubstring(`unknown waypoint "nowhere"`)))
+
+			task.Status.Route = route
+			cond, policy, err := controllerReconciler.checkRouteRange(ctx, task, "route-j20")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(policy).To(Equal(airforcev1alpha1.RangeCheckWarn))
+			Expect(cond.Status).To(Equal(metav1.ConditionFalse))
+			Expect(cond.Reason).To(Equal("LegTooLong"))
+			Expect(cond.Message).To(ContainSubstring("ip -> far (167 km)"))
+		})
+
+		It("should wait for a missing WaypointSet instead of failing the task", func() {
+			controllerReconciler := &FlightTaskReconciler{
+				Client: k8sClient,
+				Scheme: k8sClient.Scheme(),
+			}
+			taskKey := types.NamespacedName{Name: "route-later", Namespace: "default"}
+			task := &airforcev1alpha1.FlightTask{
+				ObjectMeta: metav1.ObjectMeta{Name: taskKey.Name, Namespace: taskKey.Namespace},
+				Spec: airforcev1alpha1.FlightTaskSpec{
+					TaskParams: &airforcev1alpha1.FlightTaskParams{
+						WaypointSetRef: "route-later",
+						Phases:         []airforcev1alpha1.TaskPhase{{Name: "ingress", Waypoints: []string{"ip"}}},
+					},
+				},
+			}
+			Expect(k8sClient.Create(ctx, task)).To(Succeed())
+			DeferCleanup(func() {
+				_ = k8sClient.Delete(ctx, &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: taskKey.Name + "-pod", Namespace: "default"}})
+				_ = k8sClient.Delete(ctx, task)
+				_ = k8sClient.Delete(ctx, &airforcev1alpha1.WaypointSet{ObjectMeta: metav1.ObjectMeta{Name: "route-later", Namespace: "default"}})
+			})
+			patch := client.MergeFrom(task.DeepCopy())
+			task.Status.Phase = airforcev1alpha1.FlightTaskPhaseScheduled
+			Expect(k8sClient.Status().Patch(ctx, task, patch)).To(Succeed())
+
+			_, err := controllerReconciler.Reconcile(ctx, reconcile.Request{NamespacedName: taskKey})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(k8sClient.Get(ctx, taskKey, task)).To(Succeed())
+			Expect(task.Status.Phase).To(Equal(airforcev1alpha1.FlightTaskPhaseScheduled))
+			cond := apimeta.FindStatusCondition(task.Status.Conditions, "PodCreated")
+			Expect(cond).NotTo(BeNil())
+			Expect(cond.Reason).To(Equal("WaitingForWaypointSet"))
+			var pod corev1.Pod
+			Expect(errors.IsNotFound(k8sClient.Get(ctx, types.NamespacedName{Name: taskKey.Name + "-pod", Namespace: "default"}, &pod))).To(BeTrue())
+
+			set := &airforcev1alpha1.WaypointSet{
+				ObjectMeta: metav1.ObjectMeta{Name: "route-later", Namespace: "default"},
+				Spec: airforcev1alpha1.WaypointSetSpec{Waypoints: []airforcev1alpha1.Waypoint{
+					{Name: "ip", Coordinates: airforcev1alpha1.GeoCoordinates{Latitude: "30.5", Longitude: "120.0"}},
+				}},
+			}
+			Expect(k8sClient.Create(ctx, set)).To(Succeed())
+			Expect(controllerReconciler.flightTasksForWaypointSet(ctx, set)).To(ConsistOf(reconcile.Request{NamespacedName: taskKey}))
+
+			_, err = controllerReconciler.Reconcile(ctx, reconcile.Request{NamespacedName: taskKey})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(k8sClient.Get(ctx, types.NamespacedName{Name: taskKey.Name + "-pod", Namespace: "default"}, &pod)).To(Succeed())
+			Expect(k8sClient.Get(ctx, taskKey, task)).To(Succeed())
+			Expect(task.Status.Route).NotTo(BeNil())
+		})
+	})
+
+	Context("When the task declares phases", func() {
+		It("should advance the current phase by elapsed time or telemetry and flag overruns", func() {
+			task := &airforcev1alpha1.FlightTask{
+				ObjectMeta: metav1.ObjectMeta{Name: "phased", Namespace: "default"},
+				Spec: airforcev1alpha1.FlightTaskSpec{
+					TaskParams: &airforcev1alpha1.FlightTaskParams{
+						Phases: []airforcev1alpha1.TaskPhase{
+							{Name: "ingress", Duration: &metav1.Duration{Duration: 10 * time.Minute}},
+							{Name: "combat", Duration: &metav1.Duration{Duration: 20 * time.Minute}},
+							{Name: "egress", Duration: &metav1.Duration{Duration: 10 * time.Minute}},
+						},
+					},
+				},
+			}
+			started := time.Date(2026, 1, 1, 8, 0, 0, 0, time.UTC)
+
+			changed, overrun := syncPhaseProgress(task, started, started.Add(15*time.Minute), false)
+			Expect(changed).To(BeTrue())
+			Expect(overrun).To(BeEmpty())
+			exec := task.Status.ExecutionStatus
+			Expect(exec.CurrentPhase).To(Equal("combat"))
+			Expect(exec.Phases).To(HaveLen(2))
+			Expect(exec.Phases[0].EndTime.Time).To(Equal(started.Add(10 * time.Minute)))
+			Expect(exec.Phases[1].StartTime.Time).To(Equal(started.Add(10 * time.Minute)))
+
+			changed, _ = syncPhaseProgress(task, started, started.Add(16*time.Minute), false)
+			Expect(changed).To(BeFalse())
+
+			// 遥测上报仍在 combat，超过其 20 分钟时长
+			exec.ReportedPhase = "combat"
+			_, overrun = syncPhaseProgress(task, started, started.Add(45*time.Minute), false)
+			Expect(overrun).To(Equal([]string{"combat"}))
+			Expect(exec.CurrentPhase).To(Equal("combat"))
+			cond := apimeta.FindStatusCondition(task.Status.Conditions, conditionPhaseOverrun)
+			Expect(cond).NotTo(BeNil())
+			Expect(cond.Status).To(Equal(metav1.ConditionTrue))
+
+			exec.ReportedPhase = "egress"
+			_, overrun = syncPhaseProgress(task, started, started.Add(50*time.Minute), true)
+			Expect(overrun).To(BeEmpty())
+			Expect(exec.CurrentPhase).To(Equal("egress"))
+			Expect(exec.Phases[1].EndTime.Time).To(Equal(started.Add(50 * time.Minute)))
+			Expect(exec.Phases[2].EndTime).NotTo(BeNil())
+			Expect(exec.Phases[1].Overrun).To(BeTrue())
+		})
+	})
+
+	Context("When the task is briefed", func() {
+		ctx := context.Background()
+
+		AfterEach(func() {
+			_ = k8sClient.Delete(ctx, &airforcev1alpha1.FlightTask{ObjectMeta: metav1.ObjectMeta{Name: "briefed-strike", Namespace: "default"}})
+			_ = k8sClient.Delete(ctx, &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: "briefed-strike-briefing", Namespace: "default"}})
+			_ = k8sClient.Delete(ctx, &airforcev1alpha1.Mission{ObjectMeta: metav1.ObjectMeta{Name: "briefed", Namespace: "default"}})
+		})
+
+		It("should render the briefing into a ConfigMap and update it when the mission is re-targeted", func() {
+			mission := &airforcev1alpha1.Mission{
+				ObjectMeta: metav1.ObjectMeta{Name: "briefed", Namespace: "default"},
+				Spec: airforcev1alpha1.MissionSpec{
+					MissionName: "east strike",
+					Objective: &airforcev1alpha1.MissionObjective{
+						Targets: []airforcev1alpha1.MissionTarget{
+							{Name: "radar", Coordinates: &airforcev1alpha1.GeoCoordinates{Latitude: `30°30'N`, Longitude: "120.5"}},
+						},
+					},
+					Config: &airforcev1alpha1.MissionConfig{
+						Coordination: &airforcev1alpha1.Coordination{DataLinkProtocol: "link16", CommandFrequency: "251.0MHz"},
+					},
+				},
+			}
+			Expect(k8sClient.Create(ctx, mission)).To(Succeed())
+			task := &airforcev1alpha1.FlightTask{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "briefed-strike",
+					Namespace: "default",
+					Labels:    map[string]string{"mission": "briefed"},
+				},
+				Spec: airforcev1alpha1.FlightTaskSpec{
+					TargetRef:  "radar",
+					TaskParams: &airforcev1alpha1.FlightTaskParams{Altitude: "9000m"},
+					WeaponLoadout: []airforcev1alpha1.FlightTaskWeaponLoadoutItem{
+						{WeaponRef: airforcev1alpha1.WeaponRef{Name: "pl-15"}, Quantity: 2},
+					},
+				},
+			}
+			Expect(k8sClient.Create(ctx, task)).To(Succeed())
+
+			controllerReconciler := &FlightTaskReconciler{
+				Client: k8sClient,
+				Scheme: k8sClient.Scheme(),
+			}
+			updated, err := controllerReconciler.syncBriefing(ctx, task)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(updated).To(BeFalse())
+
+			var cm corev1.ConfigMap
+			Expect(k8sClient.Get(ctx, types.NamespacedName{Name: "briefed-strike-briefing", Namespace: "default"}, &cm)).To(Succeed())
+			Expect(metav1.IsControlledBy(&cm, task)).To(BeTrue())
+			var briefing taskBriefing
+			Expect(json.Unmarshal([]byte(cm.Data[briefingKey]), &briefing)).To(Succeed())
+			Expect(briefing.MissionName).To(Equal("east strike"))
+			Expect(briefing.Coordination.CommandFrequency).To(Equal("251.0MHz"))
+			Expect(briefing.Target.Coordinates.Latitude).To(Equal("30.500000"))
+			Expect(briefing.TaskParams.Altitude).To(Equal("9000m"))
+			Expect(briefing.Loadout).To(HaveLen(1))
+
+			// 重新指派目标坐标后简报随之更新
+			mission.Spec.Objective.Targets[0].Coordinates = &airforcev1alpha1.GeoCoordinates{Latitude: "31.0", Longitude: "121.0"}
+			Expect(k8sClient.Update(ctx, mission)).To(Succeed())
+			updated, err = controllerReconciler.syncBriefing(ctx, task)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(updated).To(BeTrue())
+			Expect(k8sClient.Get(ctx, types.NamespacedName{Name: "briefed-strike-briefing", Namespace: "default"}, &cm)).To(Succeed())
+			Expect(cm.Data[briefingKey]).To(ContainSubstring(`"latitude": "31.0"`))
+
+			updated, err = controllerReconciler.syncBriefing(ctx, task)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(updated).To(BeFalse())
+
+			pod := &corev1.Pod{Spec: corev1.PodSpec{Containers: []corev1.Container{{Name: "task"}}}}
+			mountBriefing(pod, task)
+			Expect(pod.Spec.Volumes).To(HaveLen(1))
+			Expect(pod.Spec.Volumes[0].ConfigMap.Name).To(Equal("briefed-strike-briefing"))
+			Expect(pod.Spec.Containers[0].VolumeMounts[0].MountPath).To(Equal("/interface/briefing"))
+		})
+	})
+
+	Context("When the task runs past its missionDuration", func() {
+		It("should report the deadline as exceeded and keep the verdict", func() {
+			task := &airforcev1alpha1.FlightTask{
+				ObjectMeta: metav1.ObjectMeta{Name: "deadline", Namespace: "default"},
+				Spec: airforcev1alpha1.FlightTaskSpec{
+					TaskParams: &airforcev1alpha1.FlightTaskParams{MissionDuration: &metav1.Duration{Duration: time.Hour}},
+				},
+			}
+			started := time.Date(2026, 1, 1, 8, 0, 0, 0, time.UTC)
+			pod := &corev1.Pod{
+				ObjectMeta: metav1.ObjectMeta{Name: "deadline-pod", Namespace: "default"},
+				Status:     corev1.PodStatus{Phase: corev1.PodRunning, StartTime: &metav1.Time{Time: started}},
+			}
+
+			changed, exceeded := syncDeadlineCondition(task, pod, started.Add(30*time.Minute))
+			Expect(changed).To(BeTrue())
+			Expect(exceeded).To(BeFalse())
+			cond := apimeta.FindStatusCondition(task.Status.Conditions, conditionDeadlineExceeded)
+			Expect(cond.Reason).To(Equal("WithinDeadline"))
+			Expect(cond.Message).To(ContainSubstring("2026-01-01T09:00:00Z"))
+
+			changed, exceeded = syncDeadlineCondition(task, pod, started.Add(61*time.Minute))
+			Expect(changed).To(BeTrue())
+			Expect(exceeded).To(BeTrue())
+			cond = apimeta.FindStatusCondition(task.Status.Conditions, conditionDeadlineExceeded)
+			Expect(cond.Status).To(Equal(metav1.ConditionTrue))
+			Expect(cond.Reason).To(Equal("MissionDurationExceeded"))
+
+			// Pod 终止后结论保持不变
+			task.Status.Phase = airforcev1alpha1.FlightTaskPhaseFailed
+			pod.Status.Phase = corev1.PodFailed
+			changed, exceeded = syncDeadlineCondition(task, pod, started.Add(62*time.Minute))
+			Expect(changed).To(BeFalse())
+			Expect(exceeded).To(BeTrue())
+			Expect(deadlineExceeded(task)).To(BeTrue())
+
+			// 由 kubelet 按 activeDeadlineSeconds 终止的 Pod
+			other := &airforcev1alpha1.FlightTask{ObjectMeta: metav1.ObjectMeta{Name: "active-deadline", Namespace: "default"}}
+			pod.Status.Reason = "DeadlineExceeded"
+			pod.Status.Message = "Pod was active on the node longer than the specified deadline"
+			_, exceeded = syncDeadlineCondition(other, pod, started.Add(62*time.Minute))
+			Expect(exceeded).To(BeTrue())
+			Expect(apimeta.FindStatusCondition(other.Status.Conditions, conditionDeadlineExceeded).Reason).To(Equal("ActiveDeadlineExceeded"))
+		})
+	})
+
+	Context("When the task's pod stays unschedulable", func() {
+		It("should relax its preferences, fall back to another aircraft, then fail", func() {
+			r := &FlightTaskReconciler{UnschedulablePolicy: airforcev1alpha1.UnschedulablePolicy{
+				Timeout: &metav1.Duration{Duration: 10 * time.Minute},
+				Action:  airforcev1alpha1.UnschedulableActionFail,
+			}}
+			task := &airforcev1alpha1.FlightTask{
+				ObjectMeta: metav1.ObjectMeta{Name: "unschedulable", Namespace: "default"},
+				Spec: airforcev1alpha1.FlightTaskSpec{
+					AircraftRequirement: airforcev1alpha1.AircraftRequirement{Type: "j-20", PreferredLocation: "north"},
+					UnschedulablePolicy: &airforcev1alpha1.UnschedulablePolicy{
+						MaxAttempts: 3,
+						Action:      airforcev1alpha1.UnschedulableActionRelax,
+					},
+				},
+			}
+			created := time.Date(2026, 1, 1, 8, 0, 0, 0, time.UTC)
+			pod := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{
+				Name: "unschedulable-pod", Namespace: "default", CreationTimestamp: metav1.Time{Time: created},
+			}}
+
+			// 任务设置的字段覆盖控制器默认值，未设置的沿用默认值
+			policy := r.unschedulablePolicy(task)
+			Expect(policy.MaxAttempts).To(Equal(int32(3)))
+			Expect(policy.Timeout.Duration).To(Equal(10 * time.Minute))
+			Expect(policy.Action).To(Equal(airforcev1alpha1.UnschedulableActionRelax))
+			Expect((&FlightTaskReconciler{}).unschedulablePolicy(&airforcev1alpha1.FlightTask{})).To(BeNil())
+
+			_, _, reached := unschedulableLimit(policy, 2, pod, created.Add(time.Minute))
+			Expect(reached).To(BeFalse())
+			reason, _, reached := unschedulableLimit(policy, 3, pod, created.Add(time.Minute))
+			Expect(reached).To(BeTrue())
+			Expect(reason).To(Equal("SchedulingAttemptsExceeded"))
+			reason, _, reached = unschedulableLimit(policy, 1, pod, created.Add(10*time.Minute))
+			Expect(reached).To(BeTrue())
+			Expect(reason).To(Equal("SchedulingTimeout"))
+
+			// 放宽偏好：重建的 Pod 不再带位置偏好
+			Expect(nextUnschedulableAction(policy, task)).To(Equal(airforcev1alpha1.UnschedulableActionRelax))
+			task.Status.SchedulingInfo = &airforcev1alpha1.SchedulingInfo{PreferencesRelaxed: true}
+			Expect(preferencesRelaxed(task)).To(BeTrue())
+			Expect(effectiveAircraftRequirement(task)).To(Equal(airforcev1alpha1.AircraftRequirement{Type: "j-20"}))
+			Expect(nextUnschedulableAction(policy, task)).To(Equal(airforcev1alpha1.UnschedulableActionFail))
+
+			// 换用备选机型，仅一次
+			policy.Action = airforcev1alpha1.UnschedulableActionFallback
+			policy.FallbackAircraftType = "j-16"
+			Expect(nextUnschedulableAction(policy, task)).To(Equal(airforcev1alpha1.UnschedulableActionFallback))
+			task.Status.SchedulingInfo.FallbackAircraftType = "j-16"
+			Expect(effectiveAircraftRequirement(task).Type).To(Equal("j-16"))
+			Expect(nextUnschedulableAction(policy, task)).To(Equal(airforcev1alpha1.UnschedulableActionFail))
+
+			// 判定失败时条件带上调度器最后的消息
+			apimeta.SetStatusCondition(&task.Status.Conditions, metav1.Condition{
+				Type: "NoFailedScheduling", Status: metav1.ConditionFalse, Reason: "FailedScheduling",
+				Message: "0/3 nodes are available: 3 node(s) didn't match Pod's node affinity/selector.",
+			})
+			Expect(setUnschedulableCondition(task, "SchedulingAttemptsExceeded", "pod unschedulable-pod failed to schedule 3 time(s)")).To(BeTrue())
+			cond := apimeta.FindStatusCondition(task.Status.Conditions, conditionUnschedulable)
+			Expect(cond.Message).To(Equal("pod unschedulable-pod failed to schedule 3 time(s): 0/3 nodes are available: 3 node(s) didn't match Pod's node affinity/selector."))
+			Expect(unschedulable(task)).To(BeFalse())
+			task.Status.Phase = airforcev1alpha1.FlightTaskPhaseFailed
+			Expect(unschedulable(task)).To(BeTrue())
+		})
+	})
+
+	Context("When the task accepts alternate aircraft types", func() {
+		ctx := context.Background()
+
+		It("should require any of them, prefer them in order and keep those that carry the loadout", func() {
+			weapon := &airforcev1alpha1.Weapon{
+				ObjectMeta: metav1.ObjectMeta{Name: "alt-pl10", Namespace: "default"},
+				Spec: airforcev1alpha1.WeaponSpec{
+					Image:         &airforcev1alpha1.WeaponSpecImage{Repository: "example.com/weapons/pl-10", Tag: "v1"},
+					Compatibility: &airforcev1alpha1.WeaponCompatibility{AircraftTypes: []string{"j16", "j10", "j20"}},
+				},
+			}
+			Expect(k8sClient.Create(ctx, weapon)).To(Succeed())
+			task := &airforcev1alpha1.FlightTask{
+				ObjectMeta: metav1.ObjectMeta{Name: "alt", Namespace: "default"},
+				Spec: airforcev1alpha1.FlightTaskSpec{
+					AircraftRequirement: airforcev1alpha1.AircraftRequirement{Type: "j20", AlternateTypes: []string{"j16", "j11", "j10"}},
+					WeaponLoadout: []airforcev1alpha1.FlightTaskWeaponLoadoutItem{
+						{WeaponRef: airforcev1alpha1.WeaponRef{Name: "alt-pl10"}, Quantity: 2},
+					},
+				},
+			}
+			r := &FlightTaskReconciler{Client: k8sClient, Scheme: k8sClient.Scheme()}
+
+			// j11 挂载不了武器，不在可接受的机型中
+			pod, err := r.buildPodForTask(ctx, task, "alt-pod")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(pod.Spec.NodeSelector).NotTo(HaveKey("aircraft.mil/type"))
+			Expect(pod.Spec.NodeSelector["aircraft.mil/status"]).To(Equal("ready"))
+			affinity := pod.Spec.Affinity.NodeAffinity
+			Expect(affinity.RequiredDuringSchedulingIgnoredDuringExecution.NodeSelectorTerms[0].MatchExpressions).To(ContainElement(
+				corev1.NodeSelectorRequirement{Key: "aircraft.mil/type", Operator: corev1.NodeSelectorOpIn, Values: []string{"j20", "j16", "j10"}}))
+			var weights []int32
+			var preferredTypes []string
+			for _, term := range affinity.PreferredDuringSchedulingIgnoredDuringExecution {
+				weights = append(weights, term.Weight)
+				preferredTypes = append(preferredTypes, term.Preference.MatchExpressions[0].Values...)
+			}
+			Expect(weights).To(Equal([]int32{60, 30}))
+			Expect(preferredTypes).To(Equal([]string{"j20", "j16"}))
+
+			// 没有一种机型能挂载时任务无法执行
+			task.Spec.AircraftRequirement = airforcev1alpha1.AircraftRequirement{Type: "j11", AlternateTypes: []string{"j15"}}
+			_, err = r.buildPodForTask(ctx, task, "alt-pod")
+			Expect(err).To(BeAssignableToTypeOf(&weaponCompatibilityError{}))
+			Expect(err.Error()).To(ContainSubstring("j11, j15"))
+
+			// 未指定机型时按能力匹配任意就绪的飞机
+			bare := &corev1.Pod{}
+			applyAircraftSchedulingConstraints(bare, airforcev1alpha1.AircraftRequirement{Capabilities: []string{"stealth"}})
+			Expect(bare.Spec.NodeSelector).To(Equal(map[string]string{"aircraft.mil/status": "ready"}))
+			Expect(bare.Spec.Affinity.NodeAffinity.RequiredDuringSchedulingIgnoredDuringExecution.NodeSelectorTerms[0].MatchExpressions).To(Equal(
+				[]corev1.NodeSelectorRequirement{{Key: "aircraft.mil/capability.stealth", Operator: corev1.NodeSelectorOpIn, Values: []string{"true"}}}))
+
+			// 只按能力匹配时仍只能调度到武器兼容的机型
+			task.Spec.AircraftRequirement = airforcev1alpha1.AircraftRequirement{Capabilities: []string{"stealth"}}
+			pod, err = r.buildPodForTask(ctx, task, "alt-pod")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(pod.Spec.Affinity.NodeAffinity.RequiredDuringSchedulingIgnoredDuringExecution.NodeSelectorTerms[0].MatchExpressions).To(ContainElements(
+				corev1.NodeSelectorRequirement{Key: "aircraft.mil/type", Operator: corev1.NodeSelectorOpIn, Values: []string{"j16", "j10", "j20"}},
+				corev1.NodeSelectorRequirement{Key: "aircraft.mil/capability.stealth", Operator: corev1.NodeSelectorOpIn, Values: []string{"true"}}))
+
+			Expect(k8sClient.Delete(ctx, weapon)).To(Succeed())
+		})
+	})
+
+	Context("When a container of the task pod cannot pull its image", func() {
+		ctx := context.Background()
+
+		It("should fall back to the weapon's fallback image, then fail the task after imagePullTimeout", func() {
+			weapon := &airforcev1alpha1.Weapon{
+				ObjectMeta: metav1.ObjectMeta{Name: "pull-pl10", Namespace: "default"},
+				Spec: airforcev1alpha1.WeaponSpec{
+					Image: &airforcev1alpha1.WeaponSpecImage{
+						Repository:    "example.com/weapons/pl-10",
+						Tag:           "v2",
+						FallbackImage: "example.com/weapons/pl-10:v1",
+					},
+				},
+			}
+			Expect(k8sClient.Create(ctx, weapon)).To(Succeed())
+			task := &airforcev1alpha1.FlightTask{
+				ObjectMeta: metav1.ObjectMeta{Name: "pull", Namespace: "default"},
+				Spec: airforcev1alpha1.FlightTaskSpec{
+					WeaponLoadout: []airforcev1alpha1.FlightTaskWeaponLoadoutItem{
+						{WeaponRef: airforcev1alpha1.WeaponRef{Name: "pull-pl10"}, Quantity: 2},
+					},
+					ImagePullTimeout: &metav1.Duration{Duration: time.Minute},
+				},
+			}
+			Expect(k8sClient.Create(ctx, task)).To(Succeed())
+			key := types.NamespacedName{Name: "pull", Namespace: "default"}
+			podKey := types.NamespacedName{Name: "pull-pod", Namespace: "default"}
+			r := &FlightTaskReconciler{Client: k8sClient, Scheme: k8sClient.Scheme()}
+			reconcileTask := func() {
+				_, err := r.Reconcile(ctx, reconcile.Request{NamespacedName: key})
+				Expect(err).NotTo(HaveOccurred())
+				Expect(k8sClient.Get(ctx, key, task)).To(Succeed())
+			}
+			failPull := func(container string, scheduled time.Time) {
+				var pod corev1.Pod
+				Expect(k8sClient.Get(ctx, podKey, &pod)).To(Succeed())
+				pod.Status.Phase = corev1.PodPending
+				pod.Status.Conditions = []corev1.PodCondition{{
+					Type: corev1.PodScheduled, Status: corev1.ConditionTrue, LastTransitionTime: metav1.Time{Time: scheduled},
+				}}
+				waiting := []corev1.ContainerStatus{{
+					Name: container,
+					State: corev1.ContainerState{Waiting: &corev1.ContainerStateWaiting{
+						Reason: "ImagePullBackOff", Message: "Back-off pulling image",
+					}},
+				}}
+				pod.Status.ContainerStatuses, pod.Status.InitContainerStatuses = waiting, nil
+				if container != "task" {
+					pod.Status.ContainerStatuses, pod.Status.InitContainerStatuses = nil, waiting
+				}
+				Expect(k8sClient.Status().Update(ctx, &pod)).To(Succeed())
+			}
+			podImage := func(container string) string {
+				var pod corev1.Pod
+				Expect(k8sClient.Get(ctx, podKey, &pod)).To(Succeed())
+				for _, c := range append(pod.Spec.Containers, pod.Spec.InitContainers...) {
+					if c.Name == container {
+						return c.Image
+					}
+				}
+				return ""
+			}
+
+			reconcileTask()
+			reconcileTask()
+			Expect(podImage("weapon-pull-pl10")).To(Equal("example.com/weapons/pl-10:v2"))
+
+			// 武器 sidecar 首次拉取失败时仍等待 kubelet 重试
+			failPull("weapon-pull-pl10", time.Now())
+			reconcileTask()
+			Expect(task.Status.WeaponImageFallbacks).To(BeEmpty())
+			Expect(podImage("weapon-pull-pl10")).To(Equal("example.com/weapons/pl-10:v2"))
+
+			// 持续失败超过 imagePullTimeout 的四分之一：换用备用镜像重建 Pod
+			failPull("weapon-pull-pl10", time.Now().Add(-20*time.Second))
+			reconcileTask()
+			Expect(task.Status.WeaponImageFallbacks).To(Equal([]string{"pull-pl10"}))
+			cond := apimeta.FindStatusCondition(task.Status.Conditions, "NoImagePullError")
+			Expect(cond.Status).To(Equal(metav1.ConditionFalse))
+			Expect(cond.Message).To(Equal("container weapon-pull-pl10 (weapon pull-pl10): Back-off pulling image"))
+			Expect(k8sClient.Get(ctx, podKey, &corev1.Pod{})).NotTo(Succeed())
+			reconcileTask()
+			Expect(podImage("weapon-pull-pl10")).To(Equal("example.com/weapons/pl-10:v1"))
+
+			// 任务容器拉取失败，未超时时保持已调度
+			failPull("task", time.Now())
+			reconcileTask()
+			Expect(task.Status.Phase).To(Equal(airforcev1alpha1.FlightTaskPhaseScheduled))
+			Expect(apimeta.FindStatusCondition(task.Status.Conditions, "NoImagePullError").Message).To(Equal("container task: Back-off pulling image"))
+
+			// 超过 imagePullTimeout 后判定失败并删除 Pod，不再重建
+			failPull("task", time.Now().Add(-2*time.Minute))
+			reconcileTask()
+			Expect(task.Status.Phase).To(Equal(airforcev1alpha1.FlightTaskPhaseFailed))
+			cond = apimeta.FindStatusCondition(task.Status.Conditions, "NoImagePullError")
+			Expect(cond.Reason).To(Equal("ImagePullTimeout"))
+			Expect(cond.Message).To(Equal("image not pulled within 1m0s: container task: Back-off pulling image"))
+			reconcileTask()
+			Expect(k8sClient.Get(ctx, podKey, &corev1.Pod{})).NotTo(Succeed())
+
+			Expect(k8sClient.Delete(ctx, task)).To(Succeed())
+			Expect(k8sClient.Delete(ctx, weapon)).To(Succeed())
+		})
 	})
 })
@@ -0,0 +1,127 @@
+/*
+Copyright 2026 yydashuai.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	apimeta "k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	airforcev1alpha1 "github.com/yydashuai/mission-system/api/v1alpha1"
+)
+
+// reasonImagePullTimeout is the NoImagePullError reason of a task failed because a
+// container could not pull its image within the task's imagePullTimeout.
+const reasonImagePullTimeout = "ImagePullTimeout"
+
+// containerWeapon returns the weapon a sidecar container of the pod was injected for, or
+// "" for the task container and containers of the pod template.
+func containerWeapon(pod *corev1.Pod, container string) string {
+	for _, c := range weaponSidecars(pod) {
+		if c.Name == container {
+			return weaponSidecarName(c)
+		}
+	}
+	return ""
+}
+
+// imagePullMessage names the container that fails to pull its image, and its weapon.
+func imagePullMessage(pod *corev1.Pod, container, message string) string {
+	if weapon := containerWeapon(pod, container); weapon != "" {
+		return fmt.Sprintf("container %s (weapon %s): %s", container, weapon, message)
+	}
+	return fmt.Sprintf("container %s: %s", container, message)
+}
+
+// imagePullTimeout returns spec.imagePullTimeout, else the manager default, or 0 if the
+// task waits for the image indefinitely.
+func (r *FlightTaskReconciler) imagePullTimeout(task *airforcev1alpha1.FlightTask) time.Duration {
+	if task.Spec.ImagePullTimeout != nil {
+		return task.Spec.ImagePullTimeout.Duration
+	}
+	return r.ImagePullTimeout
+}
+
+// imagePullFallbackDelay is how long a weapon image must fail to pull before a task without
+// an imagePullTimeout switches the weapon to its fallback image. It spans the kubelet's
+// first back-offs so that a registry hiccup does not replace the image.
+const imagePullFallbackDelay = time.Minute
+
+// imagePullExpired reports whether a pod that fails to pull an image has done so for
+// longer than timeout. Pulls start once the pod is scheduled.
+func imagePullExpired(pod *corev1.Pod, timeout time.Duration, now time.Time) bool {
+	if timeout <= 0 {
+		return false
+	}
+	return now.Sub(imagePullStart(pod)) >= timeout
+}
+
+// imagePullFallbackDue reports whether a pod has failed to pull an image long enough for a
+// weapon to switch to its fallback image: a quarter of timeout, or imagePullFallbackDelay
+// if the task waits for its images indefinitely.
+func imagePullFallbackDue(pod *corev1.Pod, timeout time.Duration, now time.Time) bool {
+	delay := imagePullFallbackDelay
+	if timeout > 0 {
+		delay = timeout / 4
+	}
+	return now.Sub(imagePullStart(pod)) >= delay
+}
+
+// imagePullStart is when the pod started pulling its images: when it was scheduled, or
+// created if it has no scheduled time.
+func imagePullStart(pod *corev1.Pod) time.Time {
+	if scheduled := podScheduledTime(pod); scheduled != nil {
+		return scheduled.Time
+	}
+	return pod.CreationTimestamp.Time
+}
+
+// weaponFallbackImage returns the fallback image of a weapon, or "" if it has none.
+func (r *FlightTaskReconciler) weaponFallbackImage(ctx context.Context, namespace, name string) (string, error) {
+	var weapon airforcev1alpha1.Weapon
+	if err := r.Get(ctx, client.ObjectKey{Namespace: namespace, Name: name}, &weapon); err != nil {
+		return "", client.IgnoreNotFound(err)
+	}
+	if weapon.Spec.Image == nil {
+		return "", nil
+	}
+	return strings.TrimSpace(weapon.Spec.Image.FallbackImage), nil
+}
+
+// setImagePullTimeoutCondition marks the image pull failure of the task as final.
+func setImagePullTimeoutCondition(task *airforcev1alpha1.FlightTask, timeout time.Duration, message string) bool {
+	return apimeta.SetStatusCondition(&task.Status.Conditions, metav1.Condition{
+		Type:               "NoImagePullError",
+		Status:             metav1.ConditionFalse,
+		Reason:             reasonImagePullTimeout,
+		Message:            fmt.Sprintf("image not pulled within %s: %s", timeout, message),
+		ObservedGeneration: task.Generation,
+	})
+}
+
+// imagePullTimedOut reports a task that was failed because an image could not be pulled.
+func imagePullTimedOut(task *airforcev1alpha1.FlightTask) bool {
+	cond := apimeta.FindStatusCondition(task.Status.Conditions, "NoImagePullError")
+	return task.Status.Phase == airforcev1alpha1.FlightTaskPhaseFailed &&
+		cond != nil && cond.Status == metav1.ConditionFalse && cond.Reason == reasonImagePullTimeout
+}
@@ -0,0 +1,261 @@
+/*
+Copyright 2026 yydashuai.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	corev1 "k8s.io/api/core/v1"
+	apimeta "k8s.io/apimachinery/pkg/api/meta"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+
+	airforcev1alpha1 "github.com/yydashuai/mission-system/api/v1alpha1"
+	"github.com/yydashuai/mission-system/internal/geo"
+)
+
+const metricsNamespace = "airforce"
+
+var (
+	stageDurationSeconds = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: metricsNamespace,
+		Name:      "mission_stage_duration_seconds",
+		Help:      "Wall-clock duration of finished MissionStages.",
+		Buckets:   prometheus.ExponentialBuckets(30, 2, 10),
+	}, []string{"phase"})
+
+	flightTaskDurationSeconds = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: metricsNamespace,
+		Name:      "flighttask_duration_seconds",
+		Help:      "Duration of finished FlightTasks, measured from aircraft assignment.",
+		Buckets:   prometheus.ExponentialBuckets(30, 2, 10),
+	}, []string{"phase", "role"})
+
+	flightTaskSchedulingAttempts = prometheus.NewHistogram(prometheus.HistogramOpts{
+		Namespace: metricsNamespace,
+		Name:      "flighttask_scheduling_attempts",
+		Help:      "Scheduling attempts a FlightTask pod needed before it was bound to an aircraft node.",
+		Buckets:   []float64{1, 2, 3, 5, 8, 13, 21},
+	})
+
+	flightTaskTimeToScheduleSeconds = prometheus.NewHistogram(prometheus.HistogramOpts{
+		Namespace: metricsNamespace,
+		Name:      "flighttask_time_to_schedule_seconds",
+		Help:      "Time from FlightTask pod creation until it was bound to an aircraft node.",
+		Buckets:   prometheus.ExponentialBuckets(1, 2, 12),
+	})
+
+	flightTaskImagePullFailuresTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: metricsNamespace,
+		Name:      "flighttask_image_pull_failures_total",
+		Help:      "Image pull failures observed on FlightTask pods.",
+	}, []string{"reason"})
+
+	weaponSidecarsInjectedTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: metricsNamespace,
+		Name:      "weapon_sidecars_injected_total",
+		Help:      "Weapon sidecars injected into FlightTask pods.",
+	}, []string{"weapon"})
+
+	flightTaskTargetDistanceKm = prometheus.NewHistogram(prometheus.HistogramOpts{
+		Namespace: metricsNamespace,
+		Name:      "flighttask_target_distance_km",
+		Help:      "Distance between the aircraft node a FlightTask was scheduled to and the mission target.",
+		Buckets:   []float64{25, 50, 100, 200, 400, 800, 1600, 3200},
+	})
+)
+
+func init() {
+	metrics.Registry.MustRegister(
+		stageDurationSeconds,
+		flightTaskDurationSeconds,
+		flightTaskSchedulingAttempts,
+		flightTaskTimeToScheduleSeconds,
+		flightTaskImagePullFailuresTotal,
+		weaponSidecarsInjectedTotal,
+		flightTaskTargetDistanceKm,
+	)
+}
+
+// missionCollector reports point-in-time gauges computed from the informer cache on every scrape.
+type missionCollector struct {
+	reader client.Reader
+
+	missions          *prometheus.Desc
+	flightTasks       *prometheus.Desc
+	unscheduledAge    *prometheus.Desc
+	runningAge        *prometheus.Desc
+	collectionTimeout time.Duration
+}
+
+func newMissionCollector(reader client.Reader) *missionCollector {
+	return &missionCollector{
+		reader: reader,
+		missions: prometheus.NewDesc(metricsNamespace+"_missions",
+			"Number of Missions by phase, type and priority.",
+			[]string{"phase", "type", "priority"}, nil),
+		flightTasks: prometheus.NewDesc(metricsNamespace+"_flighttasks",
+			"Number of FlightTasks by phase.",
+			[]string{"phase"}, nil),
+		unscheduledAge: prometheus.NewDesc(metricsNamespace+"_flighttask_unscheduled_seconds",
+			"Seconds a FlightTask pod has existed without being bound to an aircraft node.",
+			[]string{"namespace", "flighttask"}, nil),
+		runningAge: prometheus.NewDesc(metricsNamespace+"_flighttask_running_seconds",
+			"Seconds a FlightTask has been running since aircraft assignment.",
+			[]string{"namespace", "flighttask"}, nil),
+		collectionTimeout: 5 * time.Second,
+	}
+}
+
+func (c *missionCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.missions
+	ch <- c.flightTasks
+	ch <- c.unscheduledAge
+	ch <- c.runningAge
+}
+
+func (c *missionCollector) Collect(ch chan<- prometheus.Metric) {
+	ctx, cancel := context.WithTimeout(context.Background(), c.collectionTimeout)
+	defer cancel()
+
+	var missionList airforcev1alpha1.MissionList
+	if err := c.reader.List(ctx, &missionList); err == nil {
+		type key struct {
+			phase    airforcev1alpha1.MissionPhase
+			typ      airforcev1alpha1.MissionType
+			priority airforcev1alpha1.MissionPriority
+		}
+		counts := map[key]float64{}
+		for _, m := range missionList.Items {
+			counts[key{m.Status.Phase, m.Spec.MissionType, m.Spec.Priority}]++
+		}
+		for k, v := range counts {
+			ch <- prometheus.MustNewConstMetric(c.missions, prometheus.GaugeValue, v, string(k.phase), string(k.typ), string(k.priority))
+		}
+	}
+
+	var taskList airforcev1alpha1.FlightTaskList
+	if err := c.reader.List(ctx, &taskList); err == nil {
+		now := time.Now()
+		counts := map[airforcev1alpha1.FlightTaskPhase]float64{}
+		for i := range taskList.Items {
+			task := &taskList.Items[i]
+			counts[task.Status.Phase]++
+
+			switch task.Status.Phase {
+			case airforcev1alpha1.FlightTaskPhasePending, airforcev1alpha1.FlightTaskPhaseScheduled:
+				if task.Status.PodRef == nil {
+					continue
+				}
+				if task.Status.SchedulingInfo != nil && task.Status.SchedulingInfo.AssignedNode != "" {
+					continue
+				}
+				created := apimeta.FindStatusCondition(task.Status.Conditions, "PodCreated")
+				if created == nil || created.LastTransitionTime.IsZero() {
+					continue
+				}
+				ch <- prometheus.MustNewConstMetric(c.unscheduledAge, prometheus.GaugeValue,
+					now.Sub(created.LastTransitionTime.Time).Seconds(), task.Namespace, task.Name)
+			case airforcev1alpha1.FlightTaskPhaseRunning:
+				if task.Status.SchedulingInfo == nil || task.Status.SchedulingInfo.AssignedTime == nil {
+					continue
+				}
+				ch <- prometheus.MustNewConstMetric(c.runningAge, prometheus.GaugeValue,
+					now.Sub(task.Status.SchedulingInfo.AssignedTime.Time).Seconds(), task.Namespace, task.Name)
+			}
+		}
+		for phase, v := range counts {
+			ch <- prometheus.MustNewConstMetric(c.flightTasks, prometheus.GaugeValue, v, string(phase))
+		}
+	}
+}
+
+// observeFlightTaskFinished records the duration of a FlightTask that just reached a terminal phase.
+// Simulated tasks are left out so they don't skew the alerting histograms.
+func observeFlightTaskFinished(task *airforcev1alpha1.FlightTask, phase airforcev1alpha1.FlightTaskPhase) {
+	if simulatedTask(task) || task.Status.SchedulingInfo == nil || task.Status.SchedulingInfo.AssignedTime == nil {
+		return
+	}
+	flightTaskDurationSeconds.WithLabelValues(string(phase), task.Spec.Role).
+		Observe(time.Since(task.Status.SchedulingInfo.AssignedTime.Time).Seconds())
+}
+
+// observeStageFinished records the duration of a MissionStage that just reached a terminal phase.
+// Stages of a simulated Mission are left out; an error is returned if the Mission cannot be read.
+func observeStageFinished(ctx context.Context, reader client.Reader, stage *airforcev1alpha1.MissionStage) error {
+	if stage.Status.StartTime == nil || stage.Status.CompletionTime == nil {
+		return nil
+	}
+	sim, err := missionSimulation(ctx, reader, stage.Namespace, stage.Labels["mission"])
+	if err != nil || sim != nil {
+		return err
+	}
+	stageDurationSeconds.WithLabelValues(string(stage.Status.Phase)).
+		Observe(stage.Status.CompletionTime.Sub(stage.Status.StartTime.Time).Seconds())
+	return nil
+}
+
+// observeFlightTaskScheduled records scheduling metrics once a FlightTask is bound to an aircraft node.
+// Simulated tasks are left out.
+func (r *FlightTaskReconciler) observeFlightTaskScheduled(ctx context.Context, task *airforcev1alpha1.FlightTask, createdAt, assignedAt time.Time) {
+	if simulatedTask(task) {
+		return
+	}
+	if task.Status.SchedulingInfo != nil && task.Status.SchedulingInfo.SchedulingAttempts > 0 {
+		flightTaskSchedulingAttempts.Observe(float64(task.Status.SchedulingInfo.SchedulingAttempts))
+	}
+	if !createdAt.IsZero() && !assignedAt.IsZero() && !assignedAt.Before(createdAt) {
+		flightTaskTimeToScheduleSeconds.Observe(assignedAt.Sub(createdAt).Seconds())
+	}
+	if task.Status.SchedulingInfo == nil || task.Status.SchedulingInfo.AssignedNode == "" {
+		return
+	}
+
+	target, err := r.taskTarget(ctx, task)
+	if err != nil || target == nil || target.Coordinates == nil {
+		return
+	}
+	targetPoint, err := geoPoint(target.Coordinates)
+	if err != nil {
+		return
+	}
+	var node corev1.Node
+	if err := r.Get(ctx, client.ObjectKey{Name: task.Status.SchedulingInfo.AssignedNode}, &node); err != nil {
+		return
+	}
+	nodePoint, ok := nodeLocation(&node)
+	if !ok {
+		return
+	}
+	flightTaskTargetDistanceKm.Observe(geo.Distance(targetPoint, nodePoint))
+}
+
+// registerMissionCollector adds the cache-backed gauges to the controller-runtime registry.
+func registerMissionCollector(reader client.Reader) error {
+	if err := metrics.Registry.Register(newMissionCollector(reader)); err != nil {
+		if _, ok := err.(prometheus.AlreadyRegisteredError); !ok {
+			return err
+		}
+	}
+	return nil
+}
+
+func isFlightTaskFinished(phase airforcev1alpha1.FlightTaskPhase) bool {
+	return phase == airforcev1alpha1.FlightTaskPhaseSucceeded || phase == airforcev1alpha1.FlightTaskPhaseFailed
+}
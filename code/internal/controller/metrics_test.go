@@ -0,0 +1,153 @@
+/*
+Copyright 2026 yydashuai.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	dto "github.com/prometheus/client_model/go"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	airforcev1alpha1 "github.com/yydashuai/mission-system/api/v1alpha1"
+)
+
+// sampleCount returns the number of observations of a histogram series.
+func sampleCount(o prometheus.Observer) uint64 {
+	var m dto.Metric
+	Expect(o.(prometheus.Metric).Write(&m)).To(Succeed())
+	return m.GetHistogram().GetSampleCount()
+}
+
+func metricsTestClient(objs ...client.Object) client.Client {
+	s := runtime.NewScheme()
+	Expect(clientgoscheme.AddToScheme(s)).To(Succeed())
+	Expect(airforcev1alpha1.AddToScheme(s)).To(Succeed())
+	return fake.NewClientBuilder().WithScheme(s).WithObjects(objs...).Build()
+}
+
+var _ = Describe("Metrics", func() {
+	ctx := context.Background()
+
+	It("counts Missions and FlightTasks by phase from the cache", func() {
+		reader := metricsTestClient(
+			&airforcev1alpha1.Mission{
+				ObjectMeta: metav1.ObjectMeta{Name: "m1", Namespace: "default"},
+				Spec:       airforcev1alpha1.MissionSpec{MissionType: airforcev1alpha1.MissionTypeStrike, Priority: airforcev1alpha1.MissionPriorityHigh},
+				Status:     airforcev1alpha1.MissionStatus{Phase: airforcev1alpha1.MissionPhaseRunning},
+			},
+			&airforcev1alpha1.FlightTask{
+				ObjectMeta: metav1.ObjectMeta{Name: "t1", Namespace: "default"},
+				Status:     airforcev1alpha1.FlightTaskStatus{Phase: airforcev1alpha1.FlightTaskPhaseRunning},
+			},
+			&airforcev1alpha1.FlightTask{
+				ObjectMeta: metav1.ObjectMeta{Name: "t2", Namespace: "default"},
+				Status:     airforcev1alpha1.FlightTaskStatus{Phase: airforcev1alpha1.FlightTaskPhaseRunning},
+			},
+		)
+
+		expected := `
+# HELP airforce_missions Number of Missions by phase, type and priority.
+# TYPE airforce_missions gauge
+airforce_missions{phase="运行中",priority="high",type="strike"} 1
+# HELP airforce_flighttasks Number of FlightTasks by phase.
+# TYPE airforce_flighttasks gauge
+airforce_flighttasks{phase="运行中"} 2
+`
+		Expect(testutil.CollectAndCompare(newMissionCollector(reader), strings.NewReader(expected),
+			"airforce_missions", "airforce_flighttasks")).To(Succeed())
+	})
+
+	It("reports how long a FlightTask pod has waited for an aircraft", func() {
+		created := metav1.NewTime(time.Now().Add(-time.Minute))
+		reader := metricsTestClient(&airforcev1alpha1.FlightTask{
+			ObjectMeta: metav1.ObjectMeta{Name: "waiting", Namespace: "default"},
+			Status: airforcev1alpha1.FlightTaskStatus{
+				Phase:  airforcev1alpha1.FlightTaskPhaseScheduled,
+				PodRef: &corev1.ObjectReference{Name: "waiting-pod"},
+				Conditions: []metav1.Condition{{
+					Type: "PodCreated", Status: metav1.ConditionTrue, Reason: "Created", LastTransitionTime: created,
+				}},
+			},
+		})
+
+		Expect(testutil.CollectAndCount(newMissionCollector(reader), "airforce_flighttask_unscheduled_seconds")).To(Equal(1))
+		Expect(testutil.CollectAndCount(newMissionCollector(reader), "airforce_flighttask_running_seconds")).To(Equal(0))
+	})
+
+	It("observes finished FlightTasks but not simulated ones", func() {
+		assigned := metav1.NewTime(time.Now().Add(-time.Minute))
+		task := &airforcev1alpha1.FlightTask{
+			Spec: airforcev1alpha1.FlightTaskSpec{Role: "metrics-test"},
+			Status: airforcev1alpha1.FlightTaskStatus{
+				SchedulingInfo: &airforcev1alpha1.SchedulingInfo{AssignedTime: &assigned},
+			},
+		}
+		series := flightTaskDurationSeconds.WithLabelValues(string(airforcev1alpha1.FlightTaskPhaseSucceeded), "metrics-test")
+		before := sampleCount(series)
+
+		observeFlightTaskFinished(task, airforcev1alpha1.FlightTaskPhaseSucceeded)
+		Expect(sampleCount(series)).To(Equal(before + 1))
+
+		task.Status.Conditions = []metav1.Condition{{Type: "Simulated", Status: metav1.ConditionTrue, Reason: "Running"}}
+		observeFlightTaskFinished(task, airforcev1alpha1.FlightTaskPhaseSucceeded)
+		Expect(sampleCount(series)).To(Equal(before + 1))
+	})
+
+	It("observes finished stages but not those of a simulated Mission", func() {
+		reader := metricsTestClient(
+			&airforcev1alpha1.Mission{ObjectMeta: metav1.ObjectMeta{Name: "real", Namespace: "default"}},
+			&airforcev1alpha1.Mission{
+				ObjectMeta: metav1.ObjectMeta{Name: "simulated", Namespace: "default"},
+				Spec: airforcev1alpha1.MissionSpec{Config: &airforcev1alpha1.MissionConfig{
+					Simulation: &airforcev1alpha1.SimulationConfig{Enabled: true},
+				}},
+			},
+		)
+		start := metav1.NewTime(time.Now().Add(-time.Minute))
+		end := metav1.Now()
+		stage := func(mission string) *airforcev1alpha1.MissionStage {
+			return &airforcev1alpha1.MissionStage{
+				ObjectMeta: metav1.ObjectMeta{Namespace: "default", Labels: map[string]string{"mission": mission}},
+				Status: airforcev1alpha1.MissionStageStatus{
+					Phase: airforcev1alpha1.MissionStagePhaseSucceeded, StartTime: &start, CompletionTime: &end,
+				},
+			}
+		}
+		series := stageDurationSeconds.WithLabelValues(string(airforcev1alpha1.MissionStagePhaseSucceeded))
+		before := sampleCount(series)
+
+		Expect(observeStageFinished(ctx, reader, stage("simulated"))).To(Succeed())
+		Expect(sampleCount(series)).To(Equal(before))
+
+		Expect(observeStageFinished(ctx, reader, stage("real"))).To(Succeed())
+		Expect(sampleCount(series)).To(Equal(before + 1))
+
+		Expect(observeStageFinished(ctx, reader, stage("deleted"))).To(Succeed())
+		Expect(sampleCount(series)).To(Equal(before + 2))
+	})
+})
@@ -18,31 +18,40 @@ package controller
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"strings"
 	"time"
 
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/record"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
 	"sigs.k8s.io/controller-runtime/pkg/log"
 
 	airforcev1alpha1 "github.com/yydashuai/mission-system/api/v1alpha1"
+	"github.com/yydashuai/mission-system/internal/tracing"
 )
 
 // MissionReconciler reconciles a Mission object
 type MissionReconciler struct {
 	client.Client
-	Scheme *runtime.Scheme
+	Scheme   *runtime.Scheme
+	Recorder record.EventRecorder
 }
 
 //+kubebuilder:rbac:groups=airforce.airforce.mil,resources=missions,verbs=get;list;watch;create;update;patch;delete
 //+kubebuilder:rbac:groups=airforce.airforce.mil,resources=missions/status,verbs=get;update;patch
 //+kubebuilder:rbac:groups=airforce.airforce.mil,resources=missions/finalizers,verbs=update
+//+kubebuilder:rbac:groups="",resources=events,verbs=create;patch
+//+kubebuilder:rbac:groups="",resources=nodes,verbs=get;list;watch
+//+kubebuilder:rbac:groups=airforce.airforce.mil,resources=weapons,verbs=get;list;watch
 
 // Reconcile is part of the main kubernetes reconciliation loop which aims to
 // move the current state of the cluster closer to the desired state.
@@ -54,13 +63,15 @@ type MissionReconciler struct {
 // For more details, check Reconcile and its Result here:
 // - https://pkg.go.dev/sigs.k8s.io/controller-runtime@v0.17.0/pkg/reconcile
 func (r *MissionReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
-	logger := log.FromContext(ctx)
-
 	var mission airforcev1alpha1.Mission
 	if err := r.Get(ctx, req.NamespacedName, &mission); err != nil {
 		return ctrl.Result{}, client.IgnoreNotFound(err)
 	}
 
+	ctx, span := tracing.StartReconcile(ctx, "Mission", &mission)
+	defer span.End()
+	logger := log.FromContext(ctx)
+
 	if mission.Status.Phase == "" {
 		patch := client.MergeFrom(mission.DeepCopy())
 		mission.Status.Phase = airforcev1alpha1.MissionPhasePending
@@ -72,6 +83,11 @@ func (r *MissionReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ct
 		}
 	}
 
+	// 火力分配：按目标优先级为任务分配目标与挂载
+	if err := r.reconcileAssignmentPlan(ctx, &mission); err != nil {
+		return ctrl.Result{}, err
+	}
+
 	// 1) Ensure MissionStage resources exist (and update their Spec if needed).
 	for index, stage := range mission.Spec.Stages {
 		if stage.Name == "" {
@@ -86,7 +102,7 @@ func (r *MissionReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ct
 		}
 
 		if apierrors.IsNotFound(err) {
-			flightTasks := normalizeStageFlightTasks(stage.FlightTasks)
+			flightTasks := applyAssignmentPlan(&mission, stage.Name, normalizeStageFlightTasks(stage.FlightTasks))
 			missionStage = airforcev1alpha1.MissionStage{
 				ObjectMeta: metav1.ObjectMeta{
 					Namespace: mission.Namespace,
@@ -103,6 +119,7 @@ func (r *MissionReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ct
 					StageIndex:  int32(index + 1),
 					StageType:   stage.Type,
 					DependsOn:   stage.DependsOn,
+					When:        stage.When,
 					FlightTasks: flightTasks,
 					Config: &airforcev1alpha1.MissionStageConfig{
 						Timeout: stage.Timeout,
@@ -115,6 +132,7 @@ func (r *MissionReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ct
 			if err := controllerutil.SetControllerReference(&mission, &missionStage, r.Scheme); err != nil {
 				return ctrl.Result{}, err
 			}
+			tracing.Inject(ctx, &missionStage)
 			if err := r.Create(ctx, &missionStage); err != nil {
 				return ctrl.Result{}, err
 			}
@@ -164,7 +182,11 @@ func (r *MissionReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ct
 			missionStage.Spec.DependsOn = stage.DependsOn
 			changed = true
 		}
-		desiredFlightTasks := normalizeStageFlightTasks(stage.FlightTasks)
+		if missionStage.Spec.When != stage.When {
+			missionStage.Spec.When = stage.When
+			changed = true
+		}
+		desiredFlightTasks := applyAssignmentPlan(&mission, stage.Name, normalizeStageFlightTasks(stage.FlightTasks))
 		if !missionStageFlightTasksEqual(missionStage.Spec.FlightTasks, desiredFlightTasks) {
 			missionStage.Spec.FlightTasks = desiredFlightTasks
 			changed = true
@@ -222,6 +244,12 @@ func (r *MissionReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ct
 		stage := &existingMissionStages.Items[i]
 		stagesByName[stage.Name] = stage
 	}
+	// 总时间预算耗尽时终止未结束的阶段，不再推进
+	outOfBudget, err := r.enforceTimeBudget(ctx, &mission, stagesByName)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+	outputs := newOutputResolver(r.Client, mission.Namespace, mission.Name)
 	for _, stageTemplate := range mission.Spec.Stages {
 		if stageTemplate.Name == "" {
 			continue
@@ -255,7 +283,8 @@ func (r *MissionReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ct
 				depsMet = false
 				break
 			}
-			if depStage.Status.Phase != airforcev1alpha1.MissionStagePhaseSucceeded {
+			if depStage.Status.Phase != airforcev1alpha1.MissionStagePhaseSucceeded &&
+				depStage.Status.Phase != airforcev1alpha1.MissionStagePhaseSkipped {
 				if failureAction == airforcev1alpha1.StageFailureActionContinue &&
 					depStage.Status.Phase == airforcev1alpha1.MissionStagePhaseFailed {
 					continue
@@ -268,6 +297,40 @@ func (r *MissionReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ct
 			continue
 		}
 
+		// when 条件为假的阶段直接跳过；引用的阶段未结束时继续等待
+		run, ready, err := outputs.evaluateWhen(ctx, "stage "+stageTemplate.Name, stageTemplate.When)
+		var whenErr *whenError
+		if errors.As(err, &whenErr) {
+			patch := client.MergeFrom(stage.DeepCopy())
+			stage.Status.Phase = airforcev1alpha1.MissionStagePhaseFailed
+			stage.Status.Message = "Invalid when expression: " + whenErr.Error()
+			now := metav1.Now()
+			stage.Status.CompletionTime = &now
+			if err := r.Status().Patch(ctx, stage, patch); err != nil {
+				return ctrl.Result{}, err
+			}
+			recordWarning(r.Recorder, &mission, eventReasonInvalidWhen, "%s", whenErr.Error())
+			continue
+		}
+		if err != nil {
+			return ctrl.Result{}, err
+		}
+		if !ready {
+			continue
+		}
+		if !run {
+			patch := client.MergeFrom(stage.DeepCopy())
+			stage.Status.Phase = airforcev1alpha1.MissionStagePhaseSkipped
+			stage.Status.Message = fmt.Sprintf("Skipped: when %q evaluated to false", stageTemplate.When)
+			now := metav1.Now()
+			stage.Status.CompletionTime = &now
+			if err := r.Status().Patch(ctx, stage, patch); err != nil {
+				return ctrl.Result{}, err
+			}
+			recordNormal(r.Recorder, &mission, eventReasonStageSkipped, "Stage %s skipped: when %q evaluated to false", stageTemplate.Name, stageTemplate.When)
+			continue
+		}
+
 		patch := client.MergeFrom(stage.DeepCopy())
 		stage.Status.Phase = airforcev1alpha1.MissionStagePhaseRunning
 		now := metav1.Now()
@@ -278,6 +341,7 @@ func (r *MissionReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ct
 		if err := r.Status().Patch(ctx, stage, patch); err != nil {
 			return ctrl.Result{}, err
 		}
+		recordNormal(r.Recorder, &mission, eventReasonStageStarted, "Stage %s started", stageTemplate.Name)
 	}
 
 	// 3) Summarize stage phases back into Mission.status.
@@ -291,10 +355,28 @@ func (r *MissionReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ct
 	for _, ms := range existingMissionStages.Items {
 		missionStageByName[ms.Name] = ms
 	}
+	stagesByTemplateName := make(map[string]*airforcev1alpha1.MissionStage, len(existingMissionStages.Items))
+	for i := range existingMissionStages.Items {
+		ms := &existingMissionStages.Items[i]
+		stagesByTemplateName[ms.Labels["stage-name"]] = ms
+	}
 
-	patch := client.MergeFrom(mission.DeepCopy())
+	base := mission.DeepCopy()
+	patch := client.MergeFrom(base)
 	now := metav1.Now()
-	mission.Status.LastUpdateTime = &now
+	previousPhase := mission.Status.Phase
+	previousStagePhases := make(map[string]airforcev1alpha1.MissionPhase, len(mission.Status.StagesSummary))
+	previousStageETAs := make(map[string]*metav1.Time, len(mission.Status.StagesSummary))
+	for _, summary := range mission.Status.StagesSummary {
+		previousStagePhases[summary.Name] = summary.Phase
+		previousStageETAs[summary.Name] = summary.EstimatedCompletionTime
+	}
+	type pendingEvent struct {
+		eventType string
+		reason    string
+		message   string
+	}
+	var events []pendingEvent
 
 	summaries := make([]airforcev1alpha1.MissionStageSummary, 0, len(mission.Spec.Stages))
 	stagePhases := make([]airforcev1alpha1.MissionPhase, 0, len(mission.Spec.Stages))
@@ -322,6 +404,8 @@ func (r *MissionReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ct
 			phase = airforcev1alpha1.MissionPhaseSucceeded
 		case airforcev1alpha1.MissionStagePhaseFailed:
 			phase = airforcev1alpha1.MissionPhaseFailed
+		case airforcev1alpha1.MissionStagePhaseSkipped:
+			phase = airforcev1alpha1.MissionPhaseSkipped
 		}
 
 		summaries = append(summaries, airforcev1alpha1.MissionStageSummary{
@@ -331,18 +415,62 @@ func (r *MissionReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ct
 			CompletionTime: ms.Status.CompletionTime,
 		})
 		stagePhases = append(stagePhases, phase)
+		if previousStagePhases[stage.Name] != phase {
+			switch phase {
+			case airforcev1alpha1.MissionPhaseSucceeded:
+				events = append(events, pendingEvent{corev1.EventTypeNormal, eventReasonStageSucceeded,
+					fmt.Sprintf("Stage %s succeeded", stage.Name)})
+			case airforcev1alpha1.MissionPhaseFailed:
+				events = append(events, pendingEvent{corev1.EventTypeWarning, eventReasonStageFailed,
+					fmt.Sprintf("Stage %s failed: %s", stage.Name, ms.Status.Message)})
+			}
+		}
 		switch phase {
 		case airforcev1alpha1.MissionPhaseFailed:
 			failedStages++
 		case airforcev1alpha1.MissionPhaseRunning:
 			runningStages++
-		case airforcev1alpha1.MissionPhaseSucceeded:
+		case airforcev1alpha1.MissionPhaseSucceeded, airforcev1alpha1.MissionPhaseSkipped:
 			succeededStages++
 		default:
 			pendingStages++
 		}
 	}
+
+	// 关键路径、预计完成时间与进度随阶段推进刷新
+	history, err := r.stageHistory(ctx, &mission)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+	schedule := scheduleMission(&mission, stagesByTemplateName, history, now.Time)
+	for i := range summaries {
+		summary := &summaries[i]
+		d := schedule.durations[summary.Name]
+		if d.Truncate(time.Second) > 0 {
+			summary.EstimatedDuration = &metav1.Duration{Duration: d.Truncate(time.Second)}
+		}
+		// 没有预估时长的阶段只能由当前时间推算，不发布完成时间；小幅漂移保留上次的值
+		if d > 0 && (summary.Phase == airforcev1alpha1.MissionPhasePending || summary.Phase == airforcev1alpha1.MissionPhaseRunning) {
+			summary.EstimatedCompletionTime = &metav1.Time{Time: schedule.completion[summary.Name]}
+			if previous := previousStageETAs[summary.Name]; !timeMoved(previous, summary.EstimatedCompletionTime) {
+				summary.EstimatedCompletionTime = previous
+			}
+		}
+	}
 	mission.Status.StagesSummary = summaries
+	previousETA, previousPath := mission.Status.EstimatedCompletionTime, mission.Status.CriticalPath
+	mission.Status.CriticalPath = schedule.criticalPath()
+	mission.Status.EstimatedCompletionTime = nil
+	if end := schedule.end(); !end.IsZero() && schedule.estimated(stagesByTemplateName) {
+		mission.Status.EstimatedCompletionTime = &metav1.Time{Time: end}
+	}
+	if eta := mission.Status.EstimatedCompletionTime; eta != nil && previousETA != nil && !timeMoved(previousETA, eta) {
+		mission.Status.EstimatedCompletionTime = previousETA
+		if stagesExist(&mission, previousPath) {
+			mission.Status.CriticalPath = previousPath
+		}
+	}
+	mission.Status.Progress = schedule.progress(stagesByTemplateName, now.Time)
 
 	desiredMissionPhase := airforcev1alpha1.MissionPhasePending
 	if len(stagePhases) == 0 {
@@ -368,7 +496,48 @@ func (r *MissionReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ct
 			}
 		}
 	}
+
+	// 跟踪总时间预算：剩余时间与关键路径上各阶段的松弛时间
+	previousBudget := mission.Status.Budget
+	mission.Status.Budget = missionBudgetStatus(&mission, stagesByTemplateName, schedule, now.Time)
+	if budget := mission.Status.Budget; budget != nil {
+		if outOfBudget {
+			budget.ExceededTime = &now
+			if previousBudget != nil && previousBudget.ExceededTime != nil {
+				budget.ExceededTime = previousBudget.ExceededTime
+			}
+			budget.Late = true
+			desiredMissionPhase = airforcev1alpha1.MissionPhaseFailed
+			if timeBudgetAction(&mission) == airforcev1alpha1.TimeBudgetActionCancel {
+				desiredMissionPhase = airforcev1alpha1.MissionPhaseCancelled
+			}
+			mission.Status.Message = budgetExceededMessage(&mission)
+		} else if budget.Late && (previousBudget == nil || !previousBudget.Late) {
+			events = append(events, pendingEvent{corev1.EventTypeWarning, eventReasonMissionLate,
+				fmt.Sprintf("Mission is estimated to finish %s after its deadline %s", -budget.Slack.Duration,
+					budget.Deadline.UTC().Format(time.RFC3339))})
+		}
+		if !budgetChanged(previousBudget, budget) {
+			mission.Status.Budget = previousBudget
+		}
+	}
+
 	mission.Status.Phase = desiredMissionPhase
+	if previousPhase != desiredMissionPhase {
+		switch desiredMissionPhase {
+		case airforcev1alpha1.MissionPhaseSucceeded:
+			events = append(events, pendingEvent{corev1.EventTypeNormal, eventReasonMissionSucceeded, "Mission succeeded"})
+		case airforcev1alpha1.MissionPhaseFailed:
+			message := fmt.Sprintf("Mission failed: %d stage(s) failed", failedStages)
+			if outOfBudget {
+				message = "Mission failed: " + mission.Status.Message
+			}
+			events = append(events, pendingEvent{corev1.EventTypeWarning, eventReasonMissionFailed, message})
+		case airforcev1alpha1.MissionPhaseCancelled:
+			events = append(events, pendingEvent{corev1.EventTypeWarning, eventReasonMissionCancelled,
+				"Mission cancelled: " + mission.Status.Message})
+		}
+	}
 
 	// 4) Summarize FlightTask statistics (best effort).
 	var taskList airforcev1alpha1.FlightTaskList
@@ -397,9 +566,16 @@ func (r *MissionReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ct
 		mission.Status.Statistics = stats
 	}
 
-	if err := r.Status().Patch(ctx, &mission, patch); err != nil {
-		logger.Error(err, "failed to update Mission status")
-		return ctrl.Result{}, err
+	// 状态未变化时不改写，lastUpdateTime 只随实际变化推进
+	if !equality.Semantic.DeepEqual(base.Status, mission.Status) {
+		mission.Status.LastUpdateTime = &now
+		if err := r.Status().Patch(ctx, &mission, patch); err != nil {
+			logger.Error(err, "failed to update Mission status")
+			return ctrl.Result{}, err
+		}
+	}
+	for _, ev := range events {
+		recordEvent(r.Recorder, &mission, ev.eventType, ev.reason, "%s", ev.message)
 	}
 
 	return ctrl.Result{RequeueAfter: 5 * time.Second}, nil
@@ -412,13 +588,14 @@ func normalizeStageFlightTasks(tasks []airforcev1alpha1.MissionStageFlightTaskTe
 	out := make([]airforcev1alpha1.MissionStageFlightTaskTemplate, 0, len(tasks))
 	for i, task := range tasks {
 		if strings.TrimSpace(task.Name) == "" {
-			name := strings.TrimSpace(task.Aircraft)
+			name := strings.TrimSpace(templateAircraftType(task))
 			if name == "" {
 				name = "task"
 			}
 			task.Name = fmt.Sprintf("%s-%02d", name, i+1)
 		}
-		out = append(out, task)
+		// count / withItems 展开为多个架次
+		out = append(out, expandFlightTaskTemplate(task)...)
 	}
 	return out
 }
@@ -434,12 +611,21 @@ func missionStageFlightTasksEqual(a, b []airforcev1alpha1.MissionStageFlightTask
 		if a[i].Aircraft != b[i].Aircraft {
 			return false
 		}
+		if !equality.Semantic.DeepEqual(a[i].AircraftRequirement, b[i].AircraftRequirement) {
+			return false
+		}
 		if a[i].Role != b[i].Role {
 			return false
 		}
 		if a[i].Priority != b[i].Priority {
 			return false
 		}
+		if a[i].When != b[i].When {
+			return false
+		}
+		if a[i].TargetRef != b[i].TargetRef {
+			return false
+		}
 		if len(a[i].WeaponLoadout) != len(b[i].WeaponLoadout) {
 			return false
 		}
@@ -451,13 +637,20 @@ func missionStageFlightTasksEqual(a, b []airforcev1alpha1.MissionStageFlightTask
 				return false
 			}
 		}
-		if len(a[i].TaskParams) != len(b[i].TaskParams) {
+		if !mapsEqual(a[i].TaskParams, b[i].TaskParams) {
 			return false
 		}
-		for k, v := range a[i].TaskParams {
-			if b[i].TaskParams[k] != v {
-				return false
-			}
+		if !equality.Semantic.DeepEqual(a[i].Params, b[i].Params) {
+			return false
+		}
+		if !equality.Semantic.DeepEqual(a[i].UnschedulablePolicy, b[i].UnschedulablePolicy) {
+			return false
+		}
+		if !equality.Semantic.DeepEqual(a[i].ImagePullTimeout, b[i].ImagePullTimeout) {
+			return false
+		}
+		if !rawExtensionEqual(a[i].PodTemplate, b[i].PodTemplate) {
+			return false
 		}
 	}
 	return true
@@ -492,6 +685,9 @@ func stageFailureAction(mission *airforcev1alpha1.Mission) airforcev1alpha1.Stag
 
 // SetupWithManager sets up the controller with the Manager.
 func (r *MissionReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	if err := registerMissionCollector(mgr.GetClient()); err != nil {
+		return err
+	}
 	return ctrl.NewControllerManagedBy(mgr).
 		For(&airforcev1alpha1.Mission{}).
 		Owns(&airforcev1alpha1.MissionStage{}).
@@ -18,11 +18,15 @@ package controller
 
 import (
 	"context"
+	"time"
 
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -81,4 +85,401 @@ var _ = Describe("Mission Controller", func() {
 			// Example: If you expect a certain status condition after reconciliation, verify it here.
 		})
 	})
+
+	Context("When a stage has a when expression", func() {
+		ctx := context.Background()
+		missionKey := types.NamespacedName{Name: "m3", Namespace: "default"}
+
+		BeforeEach(func() {
+			mission := &airforcev1alpha1.Mission{
+				ObjectMeta: metav1.ObjectMeta{Name: missionKey.Name, Namespace: "default"},
+				Spec: airforcev1alpha1.MissionSpec{
+					Stages: []airforcev1alpha1.MissionStageTemplate{
+						{Name: "isr", Type: airforcev1alpha1.StageExecutionTypeParallel},
+						{
+							Name:      "strike",
+							Type:      airforcev1alpha1.StageExecutionTypeParallel,
+							DependsOn: []string{"isr"},
+							When:      `stages.isr.outputs.targetConfirmed == "true"`,
+						},
+					},
+				},
+			}
+			Expect(k8sClient.Create(ctx, mission)).To(Succeed())
+		})
+
+		AfterEach(func() {
+			task := &airforcev1alpha1.FlightTask{}
+			if err := k8sClient.Get(ctx, types.NamespacedName{Name: "m3-isr-j20-01", Namespace: "default"}, task); err == nil {
+				Expect(k8sClient.Delete(ctx, task)).To(Succeed())
+			}
+			for _, name := range []string{"m3-isr", "m3-strike"} {
+				stage := &airforcev1alpha1.MissionStage{}
+				if err := k8sClient.Get(ctx, types.NamespacedName{Name: name, Namespace: "default"}, stage); err == nil {
+					Expect(k8sClient.Delete(ctx, stage)).To(Succeed())
+				}
+			}
+			mission := &airforcev1alpha1.Mission{}
+			Expect(k8sClient.Get(ctx, missionKey, mission)).To(Succeed())
+			Expect(k8sClient.Delete(ctx, mission)).To(Succeed())
+		})
+
+		It("should skip the stage when reconnaissance does not confirm the target", func() {
+			controllerReconciler := &MissionReconciler{Client: k8sClient, Scheme: k8sClient.Scheme()}
+			_, err := controllerReconciler.Reconcile(ctx, reconcile.Request{NamespacedName: missionKey})
+			Expect(err).NotTo(HaveOccurred())
+
+			isr := &airforcev1alpha1.MissionStage{}
+			Expect(k8sClient.Get(ctx, types.NamespacedName{Name: "m3-isr", Namespace: "default"}, isr)).To(Succeed())
+			Expect(isr.Spec.When).To(BeEmpty())
+			patch := client.MergeFrom(isr.DeepCopy())
+			isr.Status.Phase = airforcev1alpha1.MissionStagePhaseSucceeded
+			Expect(k8sClient.Status().Patch(ctx, isr, patch)).To(Succeed())
+
+			isrTask := &airforcev1alpha1.FlightTask{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "m3-isr-j20-01",
+					Namespace: "default",
+					Labels:    map[string]string{"mission": "m3", "stage": "m3-isr", "task-name": "j20-01"},
+				},
+			}
+			Expect(k8sClient.Create(ctx, isrTask)).To(Succeed())
+			taskPatch := client.MergeFrom(isrTask.DeepCopy())
+			isrTask.Status.Outputs = map[string]string{"targetConfirmed": "false"}
+			Expect(k8sClient.Status().Patch(ctx, isrTask, taskPatch)).To(Succeed())
+
+			_, err = controllerReconciler.Reconcile(ctx, reconcile.Request{NamespacedName: missionKey})
+			Expect(err).NotTo(HaveOccurred())
+
+			strike := &airforcev1alpha1.MissionStage{}
+			Expect(k8sClient.Get(ctx, types.NamespacedName{Name: "m3-strike", Namespace: "default"}, strike)).To(Succeed())
+			Expect(strike.Spec.When).To(Equal(`stages.isr.outputs.targetConfirmed == "true"`))
+			Expect(strike.Status.Phase).To(Equal(airforcev1alpha1.MissionStagePhaseSkipped))
+			Expect(strike.Status.Message).To(ContainSubstring("evaluated to false"))
+
+			mission := &airforcev1alpha1.Mission{}
+			Expect(k8sClient.Get(ctx, missionKey, mission)).To(Succeed())
+			Expect(mission.Status.StagesSummary).To(HaveLen(2))
+			Expect(mission.Status.StagesSummary[1].Phase).To(Equal(airforcev1alpha1.MissionPhaseSkipped))
+			Expect(mission.Status.Phase).To(Equal(airforcev1alpha1.MissionPhaseSucceeded))
+		})
+	})
+
+	Context("When a task template uses count or withItems", func() {
+		It("should expand the template into indexed FlightTasks", func() {
+			tasks := normalizeStageFlightTasks([]airforcev1alpha1.MissionStageFlightTaskTemplate{
+				{
+					Name:     "patrol",
+					Aircraft: "j20",
+					WithItems: []map[string]string{
+						{"sector": "north", "altitude": "9000m"},
+						{"sector": "south", "altitude": "8500m"},
+					},
+					TaskParams:  map[string]string{"sector": "{{ item.sector }}", "altitude": "{{ item.altitude }}", "unknown": "{{ item.lat }}"},
+					PodTemplate: &runtime.RawExtension{Raw: []byte(`{"spec":{"containers":[{"name":"task","env":[{"name":"SORTIE","value":"{{ index }}/{{ item.sector }}"}]}]}}`)},
+				},
+				{Aircraft: "j16", Count: 3},
+				{Name: "tanker", Aircraft: "y20"},
+			})
+
+			names := make([]string, 0, len(tasks))
+			for _, task := range tasks {
+				names = append(names, task.Name)
+				Expect(task.Count).To(BeZero())
+				Expect(task.WithItems).To(BeNil())
+			}
+			Expect(names).To(Equal([]string{"patrol-1", "patrol-2", "j16-02-1", "j16-02-2", "j16-02-3", "tanker"}))
+			Expect(tasks[1].TaskParams).To(Equal(map[string]string{"sector": "south", "altitude": "8500m", "unknown": "{{ item.lat }}"}))
+			Expect(string(tasks[1].PodTemplate.Raw)).To(ContainSubstring(`"value":"2/south"`))
+		})
+	})
+
+	Context("When the assignment optimizer is enabled", func() {
+		ctx := context.Background()
+		missionKey := types.NamespacedName{Name: "m4", Namespace: "default"}
+
+		BeforeEach(func() {
+			node := &corev1.Node{ObjectMeta: metav1.ObjectMeta{
+				Name: "m4-j20-north",
+				Labels: map[string]string{
+					"aircraft.mil/type":                "j20",
+					"aircraft.mil/status":              "ready",
+					"aircraft.mil/location.latitude":   "40.1",
+					"aircraft.mil/location.longitude":  "116.1",
+					"aircraft.mil/hardpoint.available": "2",
+				},
+			}}
+			Expect(k8sClient.Create(ctx, node)).To(Succeed())
+			weapon := &airforcev1alpha1.Weapon{
+				ObjectMeta: metav1.ObjectMeta{Name: "m4-pl15", Namespace: "default"},
+				Spec: airforcev1alpha1.WeaponSpec{
+					Specifications: &airforcev1alpha1.WeaponSpecifications{Range: "200km", Guidance: "radar", Warhead: "he"},
+					Resources:      &airforcev1alpha1.WeaponResources{Hardpoints: 1},
+				},
+			}
+			Expect(k8sClient.Create(ctx, weapon)).To(Succeed())
+
+			mission := &airforcev1alpha1.Mission{
+				ObjectMeta: metav1.ObjectMeta{Name: missionKey.Name, Namespace: "default"},
+				Spec: airforcev1alpha1.MissionSpec{
+					Objective: &airforcev1alpha1.MissionObjective{
+						Targets: []airforcev1alpha1.MissionTarget{
+							{Name: "radar", Priority: airforcev1alpha1.MissionPriorityHigh, Coordinates: &airforcev1alpha1.GeoCoordinates{Latitude: "40.0", Longitude: "116.0"}},
+							{Name: "port", Priority: airforcev1alpha1.MissionPriorityLow, Coordinates: &airforcev1alpha1.GeoCoordinates{Latitude: "22.0", Longitude: "114.0"}},
+						},
+					},
+					Stages: []airforcev1alpha1.MissionStageTemplate{{
+						Name: "strike",
+						Type: airforcev1alpha1.StageExecutionTypeParallel,
+						FlightTasks: []airforcev1alpha1.MissionStageFlightTaskTemplate{
+							{Name: "s", Aircraft: "j20", Role: "strike", Count: 2},
+						},
+					}},
+					Config: &airforcev1alpha1.MissionConfig{
+						Assignment: &airforcev1alpha1.AssignmentConfig{Mode: airforcev1alpha1.AssignmentModeApply},
+					},
+				},
+			}
+			Expect(k8sClient.Create(ctx, mission)).To(Succeed())
+		})
+
+		AfterEach(func() {
+			stage := &airforcev1alpha1.MissionStage{}
+			if err := k8sClient.Get(ctx, types.NamespacedName{Name: "m4-strike", Namespace: "default"}, stage); err == nil {
+				Expect(k8sClient.Delete(ctx, stage)).To(Succeed())
+			}
+			mission := &airforcev1alpha1.Mission{}
+			Expect(k8sClient.Get(ctx, missionKey, mission)).To(Succeed())
+			Expect(k8sClient.Delete(ctx, mission)).To(Succeed())
+			Expect(k8sClient.Delete(ctx, &airforcev1alpha1.Weapon{ObjectMeta: metav1.ObjectMeta{Name: "m4-pl15", Namespace: "default"}})).To(Succeed())
+			Expect(k8sClient.Delete(ctx, &corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "m4-j20-north"}})).To(Succeed())
+		})
+
+		It("should assign the highest-priority target and explain the rest", func() {
+			controllerReconciler := &MissionReconciler{Client: k8sClient, Scheme: k8sClient.Scheme()}
+			_, err := controllerReconciler.Reconcile(ctx, reconcile.Request{NamespacedName: missionKey})
+			Expect(err).NotTo(HaveOccurred())
+
+			mission := &airforcev1alpha1.Mission{}
+			Expect(k8sClient.Get(ctx, missionKey, mission)).To(Succeed())
+			plan := mission.Status.AssignmentPlan
+			Expect(plan).NotTo(BeNil())
+			Expect(plan.Coverage).To(Equal("1/2"))
+			Expect(plan.Assignments).To(HaveLen(1))
+			Expect(plan.Assignments[0].Target).To(Equal("radar"))
+			Expect(plan.Assignments[0].Aircraft).To(Equal("m4-j20-north"))
+			Expect(plan.Unassigned).To(HaveLen(1))
+			Expect(plan.Unassigned[0].Target).To(Equal("port"))
+			Expect(plan.Unassigned[0].Reason).To(ContainSubstring("no available j20 aircraft"))
+
+			stage := &airforcev1alpha1.MissionStage{}
+			Expect(k8sClient.Get(ctx, types.NamespacedName{Name: "m4-strike", Namespace: "default"}, stage)).To(Succeed())
+			task := stage.Spec.FlightTasks[0]
+			Expect(task.TargetRef).To(Equal("radar"))
+			Expect(task.WeaponLoadout).To(Equal([]airforcev1alpha1.WeaponLoadoutItem{{Weapon: "m4-pl15", Quantity: 1}}))
+			Expect(task.AircraftRequirement).NotTo(BeNil())
+			Expect(task.AircraftRequirement.Node).To(Equal("m4-j20-north"))
+			Expect(stage.Spec.FlightTasks[1].TargetRef).To(BeEmpty())
+		})
+	})
+
+	Context("When the mission has a time budget", func() {
+		ctx := context.Background()
+		missionKey := types.NamespacedName{Name: "m5", Namespace: "default"}
+		stageNames := []string{"m5-isr", "m5-strike", "m5-escort"}
+
+		BeforeEach(func() {
+			mission := &airforcev1alpha1.Mission{
+				ObjectMeta: metav1.ObjectMeta{Name: missionKey.Name, Namespace: "default"},
+				Spec: airforcev1alpha1.MissionSpec{
+					Stages: []airforcev1alpha1.MissionStageTemplate{
+						{Name: "isr", Type: airforcev1alpha1.StageExecutionTypeParallel, Timeout: &metav1.Duration{Duration: 20 * time.Minute}},
+						{
+							Name:      "strike",
+							Type:      airforcev1alpha1.StageExecutionTypeParallel,
+							DependsOn: []string{"isr"},
+							Timeout:   &metav1.Duration{Duration: 30 * time.Minute},
+						},
+						{Name: "escort", Type: airforcev1alpha1.StageExecutionTypeParallel, Timeout: &metav1.Duration{Duration: 10 * time.Minute}},
+					},
+					TimeBudget: &airforcev1alpha1.TimeBudget{
+						Duration: metav1.Duration{Duration: time.Hour},
+						Action:   airforcev1alpha1.TimeBudgetActionCancel,
+					},
+				},
+			}
+			Expect(k8sClient.Create(ctx, mission)).To(Succeed())
+		})
+
+		AfterEach(func() {
+			for _, name := range stageNames {
+				stage := &airforcev1alpha1.MissionStage{}
+				if err := k8sClient.Get(ctx, types.NamespacedName{Name: name, Namespace: "default"}, stage); err == nil {
+					Expect(k8sClient.Delete(ctx, stage)).To(Succeed())
+				}
+			}
+			mission := &airforcev1alpha1.Mission{}
+			Expect(k8sClient.Get(ctx, missionKey, mission)).To(Succeed())
+			Expect(k8sClient.Delete(ctx, mission)).To(Succeed())
+		})
+
+		It("should report the slack of each stage along the critical path", func() {
+			controllerReconciler := &MissionReconciler{Client: k8sClient, Scheme: k8sClient.Scheme()}
+			_, err := controllerReconciler.Reconcile(ctx, reconcile.Request{NamespacedName: missionKey})
+			Expect(err).NotTo(HaveOccurred())
+
+			mission := &airforcev1alpha1.Mission{}
+			Expect(k8sClient.Get(ctx, missionKey, mission)).To(Succeed())
+			budget := mission.Status.Budget
+			Expect(budget).NotTo(BeNil())
+			Expect(budget.Deadline.Time).To(Equal(mission.Status.StartTime.Add(time.Hour)))
+			Expect(budget.Late).To(BeFalse())
+			Expect(budget.Slack.Duration).To(BeNumerically("~", 10*time.Minute, 5*time.Second))
+			Expect(budget.Stages).To(HaveLen(3))
+			slack := map[string]airforcev1alpha1.StageSlack{}
+			for _, s := range budget.Stages {
+				slack[s.Name] = s
+			}
+			Expect(slack["isr"].Critical).To(BeTrue())
+			Expect(slack["strike"].Critical).To(BeTrue())
+			Expect(slack["strike"].LatestCompletionTime.Time).To(Equal(budget.Deadline.Time))
+			Expect(slack["escort"].Critical).To(BeFalse())
+			Expect(slack["escort"].Slack.Duration).To(BeNumerically("~", 50*time.Minute, 5*time.Second))
+			Expect(budget.Remaining).To(BeNil())
+
+			// 松弛时间随时钟的微小漂移不改写预算状态
+			time.Sleep(time.Second)
+			_, err = controllerReconciler.Reconcile(ctx, reconcile.Request{NamespacedName: missionKey})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(k8sClient.Get(ctx, missionKey, mission)).To(Succeed())
+			Expect(mission.Status.Budget).To(Equal(budget))
+		})
+
+		It("should cancel the mission and stop its stages once the budget runs out", func() {
+			controllerReconciler := &MissionReconciler{Client: k8sClient, Scheme: k8sClient.Scheme()}
+			_, err := controllerReconciler.Reconcile(ctx, reconcile.Request{NamespacedName: missionKey})
+			Expect(err).NotTo(HaveOccurred())
+
+			mission := &airforcev1alpha1.Mission{}
+			Expect(k8sClient.Get(ctx, missionKey, mission)).To(Succeed())
+			patch := client.MergeFrom(mission.DeepCopy())
+			mission.Status.StartTime = &metav1.Time{Time: time.Now().Add(-2 * time.Hour)}
+			Expect(k8sClient.Status().Patch(ctx, mission, patch)).To(Succeed())
+
+			_, err = controllerReconciler.Reconcile(ctx, reconcile.Request{NamespacedName: missionKey})
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(k8sClient.Get(ctx, missionKey, mission)).To(Succeed())
+			Expect(mission.Status.Phase).To(Equal(airforcev1alpha1.MissionPhaseCancelled))
+			Expect(mission.Status.Message).To(HavePrefix(missionBudgetExceededMessage))
+			Expect(mission.Status.Budget.ExceededTime).NotTo(BeNil())
+			Expect(mission.Status.Budget.Remaining.Duration).To(BeNumerically("<", 0))
+			for _, name := range stageNames {
+				stage := &airforcev1alpha1.MissionStage{}
+				Expect(k8sClient.Get(ctx, types.NamespacedName{Name: name, Namespace: "default"}, stage)).To(Succeed())
+				Expect(stage.Status.Phase).To(Equal(airforcev1alpha1.MissionStagePhaseFailed))
+				Expect(stageStopped(stage)).To(Equal(reasonMissionBudgetExceeded))
+			}
+
+			// 已取消的 Mission 不会因阶段结论而恢复
+			_, err = controllerReconciler.Reconcile(ctx, reconcile.Request{NamespacedName: missionKey})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(k8sClient.Get(ctx, missionKey, mission)).To(Succeed())
+			Expect(mission.Status.Phase).To(Equal(airforcev1alpha1.MissionPhaseCancelled))
+		})
+	})
+
+	Context("When estimating when the mission completes", func() {
+		ctx := context.Background()
+		missionKey := types.NamespacedName{Name: "m6", Namespace: "default"}
+		stageNames := []string{"m6-isr", "m6-strike", "m6-escort"}
+		minutes := func(m int) *metav1.Duration { return &metav1.Duration{Duration: time.Duration(m) * time.Minute} }
+
+		BeforeEach(func() {
+			mission := &airforcev1alpha1.Mission{
+				ObjectMeta: metav1.ObjectMeta{Name: missionKey.Name, Namespace: "default"},
+				Spec: airforcev1alpha1.MissionSpec{
+					Stages: []airforcev1alpha1.MissionStageTemplate{
+						{
+							Name: "isr",
+							Type: airforcev1alpha1.StageExecutionTypeSequential,
+							FlightTasks: []airforcev1alpha1.MissionStageFlightTaskTemplate{{
+								Name: "recon", Aircraft: "j20", Role: "reconnaissance", Count: 2,
+								Params: &airforcev1alpha1.FlightTaskParams{Phases: []airforcev1alpha1.TaskPhase{
+									{Name: "ingress", Duration: minutes(10)},
+									{Name: "search", Duration: minutes(5)},
+								}},
+							}},
+						},
+						{
+							Name:      "strike",
+							Type:      airforcev1alpha1.StageExecutionTypeParallel,
+							DependsOn: []string{"isr"},
+							FlightTasks: []airforcev1alpha1.MissionStageFlightTaskTemplate{
+								{Name: "lead", Aircraft: "j20", Role: "strike", Params: &airforcev1alpha1.FlightTaskParams{MissionDuration: minutes(20)}},
+								{Name: "wing", Aircraft: "j20", Role: "strike", Params: &airforcev1alpha1.FlightTaskParams{
+									MissionDuration: minutes(25),
+									Phases:          []airforcev1alpha1.TaskPhase{{Name: "attack", Duration: minutes(40)}},
+								}},
+							},
+						},
+						{Name: "escort", Type: airforcev1alpha1.StageExecutionTypeParallel, Timeout: minutes(15)},
+					},
+				},
+			}
+			Expect(k8sClient.Create(ctx, mission)).To(Succeed())
+		})
+
+		AfterEach(func() {
+			for _, name := range stageNames {
+				stage := &airforcev1alpha1.MissionStage{}
+				if err := k8sClient.Get(ctx, types.NamespacedName{Name: name, Namespace: "default"}, stage); err == nil {
+					Expect(k8sClient.Delete(ctx, stage)).To(Succeed())
+				}
+			}
+			mission := &airforcev1alpha1.Mission{}
+			Expect(k8sClient.Get(ctx, missionKey, mission)).To(Succeed())
+			Expect(k8sClient.Delete(ctx, mission)).To(Succeed())
+		})
+
+		It("should follow the critical path and refresh the estimate as stages finish", func() {
+			controllerReconciler := &MissionReconciler{Client: k8sClient, Scheme: k8sClient.Scheme()}
+			_, err := controllerReconciler.Reconcile(ctx, reconcile.Request{NamespacedName: missionKey})
+			Expect(err).NotTo(HaveOccurred())
+
+			mission := &airforcev1alpha1.Mission{}
+			Expect(k8sClient.Get(ctx, missionKey, mission)).To(Succeed())
+			Expect(mission.Status.CriticalPath).To(Equal([]string{"isr", "strike"}))
+			Expect(mission.Status.StagesSummary[0].EstimatedDuration.Duration).To(Equal(30 * time.Minute))
+			Expect(mission.Status.StagesSummary[1].EstimatedDuration.Duration).To(Equal(25 * time.Minute))
+			Expect(mission.Status.StagesSummary[2].EstimatedDuration.Duration).To(Equal(15 * time.Minute))
+			Expect(mission.Status.EstimatedCompletionTime.Time).To(BeTemporally("~", time.Now().Add(55*time.Minute), 5*time.Second))
+			Expect(mission.Status.Progress).To(BeNumerically("<", 5))
+
+			// 预计完成时间随时钟小幅漂移时保持不变
+			eta := mission.Status.EstimatedCompletionTime.DeepCopy()
+			time.Sleep(1100 * time.Millisecond)
+			_, err = controllerReconciler.Reconcile(ctx, reconcile.Request{NamespacedName: missionKey})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(k8sClient.Get(ctx, missionKey, mission)).To(Succeed())
+			Expect(mission.Status.EstimatedCompletionTime.Equal(eta)).To(BeTrue())
+
+			isr := &airforcev1alpha1.MissionStage{}
+			Expect(k8sClient.Get(ctx, types.NamespacedName{Name: "m6-isr", Namespace: "default"}, isr)).To(Succeed())
+			patch := client.MergeFrom(isr.DeepCopy())
+			isr.Status.Phase = airforcev1alpha1.MissionStagePhaseSucceeded
+			isr.Status.StartTime = &metav1.Time{Time: time.Now().Add(-30 * time.Minute)}
+			isr.Status.CompletionTime = &metav1.Time{Time: time.Now()}
+			Expect(k8sClient.Status().Patch(ctx, isr, patch)).To(Succeed())
+
+			_, err = controllerReconciler.Reconcile(ctx, reconcile.Request{NamespacedName: missionKey})
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(k8sClient.Get(ctx, missionKey, mission)).To(Succeed())
+			Expect(mission.Status.StagesSummary[0].EstimatedCompletionTime).To(BeNil())
+			Expect(mission.Status.StagesSummary[1].Phase).To(Equal(airforcev1alpha1.MissionPhaseRunning))
+			Expect(mission.Status.EstimatedCompletionTime.Time).To(BeTemporally("~", time.Now().Add(25*time.Minute), 5*time.Second))
+			Expect(mission.Status.Progress).To(BeNumerically("~", 42, 1))
+		})
+	})
 })
@@ -19,34 +19,43 @@ package controller
 import (
 	"bytes"
 	"context"
+	"errors"
 	"fmt"
 	"sort"
 	"strconv"
 	"time"
 
+	"k8s.io/apimachinery/pkg/api/equality"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	apimeta "k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/record"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
 	"sigs.k8s.io/controller-runtime/pkg/log"
 
 	airforcev1alpha1 "github.com/yydashuai/mission-system/api/v1alpha1"
+	"github.com/yydashuai/mission-system/internal/tracing"
 )
 
 // MissionStageReconciler reconciles a MissionStage object
 type MissionStageReconciler struct {
 	client.Client
-	Scheme *runtime.Scheme
+	Scheme   *runtime.Scheme
+	Recorder record.EventRecorder
 }
 
 //+kubebuilder:rbac:groups=airforce.airforce.mil,resources=missionstages,verbs=get;list;watch;create;update;patch;delete
 //+kubebuilder:rbac:groups=airforce.airforce.mil,resources=missionstages/status,verbs=get;update;patch
 //+kubebuilder:rbac:groups=airforce.airforce.mil,resources=missionstages/finalizers,verbs=update
+//+kubebuilder:rbac:groups="",resources=events,verbs=create;patch
 //+kubebuilder:rbac:groups=airforce.airforce.mil,resources=flighttasks,verbs=get;list;watch;create;update;patch;delete
 //+kubebuilder:rbac:groups=airforce.airforce.mil,resources=flighttasks/status,verbs=get;update;patch
+//+kubebuilder:rbac:groups=airforce.airforce.mil,resources=missions,verbs=get;list;watch
+//+kubebuilder:rbac:groups="",resources=pods,verbs=get;list;watch;delete
 
 // Reconcile is part of the main kubernetes reconciliation loop which aims to
 // move the current state of the cluster closer to the desired state.
@@ -58,13 +67,15 @@ type MissionStageReconciler struct {
 // For more details, check Reconcile and its Result here:
 // - https://pkg.go.dev/sigs.k8s.io/controller-runtime@v0.17.0/pkg/reconcile
 func (r *MissionStageReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
-	logger := log.FromContext(ctx)
-
 	var stage airforcev1alpha1.MissionStage
 	if err := r.Get(ctx, req.NamespacedName, &stage); err != nil {
 		return ctrl.Result{}, client.IgnoreNotFound(err)
 	}
 
+	ctx, span := tracing.StartReconcile(ctx, "MissionStage", &stage)
+	defer span.End()
+	logger := log.FromContext(ctx)
+
 	if stage.Status.Phase == "" {
 		patch := client.MergeFrom(stage.DeepCopy())
 		stage.Status.Phase = airforcev1alpha1.MissionStagePhasePending
@@ -73,8 +84,35 @@ func (r *MissionStageReconciler) Reconcile(ctx context.Context, req ctrl.Request
 		}
 	}
 
-	if err := r.reconcileFlightTasks(ctx, &stage); err != nil {
-		return ctrl.Result{}, err
+	skipped, err := r.reconcileFlightTasks(ctx, &stage)
+	if err != nil {
+		var refErr *outputReferenceError
+		var whenErr *whenError
+		message, reason := "", ""
+		switch {
+		case errors.As(err, &refErr):
+			message, reason = "Unresolved output reference: "+refErr.Error(), eventReasonOutputMissing
+		case errors.As(err, &whenErr):
+			message, reason = "Invalid when expression: "+whenErr.Error(), eventReasonInvalidWhen
+		default:
+			return ctrl.Result{}, err
+		}
+		if isStageFinished(stage.Status.Phase) {
+			return ctrl.Result{}, nil
+		}
+		patch := client.MergeFrom(stage.DeepCopy())
+		stage.Status.Phase = airforcev1alpha1.MissionStagePhaseFailed
+		stage.Status.Message = message
+		now := metav1.Now()
+		stage.Status.CompletionTime = &now
+		if err := r.Status().Patch(ctx, &stage, patch); err != nil {
+			return ctrl.Result{}, err
+		}
+		recordWarning(r.Recorder, &stage, reason, "%s", err.Error())
+		if err := observeStageFinished(ctx, r.Client, &stage); err != nil {
+			return ctrl.Result{}, err
+		}
+		return ctrl.Result{}, nil
 	}
 
 	tasks, err := r.listFlightTasks(ctx, &stage)
@@ -97,7 +135,7 @@ func (r *MissionStageReconciler) Reconcile(ctx context.Context, req ctrl.Request
 		}
 	}
 
-	if err := r.updateStageStatus(ctx, &stage, tasks); err != nil {
+	if err := r.updateStageStatus(ctx, &stage, tasks, skipped); err != nil {
 		logger.Error(err, "failed to update MissionStage status")
 		return ctrl.Result{}, err
 	}
@@ -105,12 +143,30 @@ func (r *MissionStageReconciler) Reconcile(ctx context.Context, req ctrl.Request
 	if stage.Status.Phase == airforcev1alpha1.MissionStagePhaseRunning && r.isStageTimeout(&stage) {
 		patch := client.MergeFrom(stage.DeepCopy())
 		stage.Status.Phase = airforcev1alpha1.MissionStagePhaseFailed
-		stage.Status.Message = "Stage timed out"
+		stage.Status.Reason = reasonStageTimedOut
+		stage.Status.Message = stageTimedOutMessage
 		now := metav1.Now()
 		stage.Status.CompletionTime = &now
 		if err := r.Status().Patch(ctx, &stage, patch); err != nil {
 			return ctrl.Result{}, err
 		}
+		if err := observeStageFinished(ctx, r.Client, &stage); err != nil {
+			return ctrl.Result{}, err
+		}
+		// 超时与任务失败一样按 Mission 的失败处理策略推进
+		var mission airforcev1alpha1.Mission
+		if err := r.Get(ctx, client.ObjectKey{Namespace: stage.Namespace, Name: stage.Spec.MissionRef.Name}, &mission); err != nil && !apierrors.IsNotFound(err) {
+			return ctrl.Result{}, err
+		}
+		recordWarning(r.Recorder, &stage, eventReasonStageTimedOut, "Stage exceeded timeout %s, failure action %s",
+			stage.Spec.Config.Timeout.Duration, stageFailureAction(&mission))
+	}
+
+	// 超时或 Mission 预算耗尽的阶段停止调度，终止仍在执行的任务
+	if stageStopped(&stage) != "" {
+		if err := r.stopStageTasks(ctx, &stage, tasks); err != nil {
+			return ctrl.Result{}, err
+		}
 	}
 
 	return ctrl.Result{RequeueAfter: 5 * time.Second}, nil
@@ -138,25 +194,56 @@ func (r *MissionStageReconciler) listFlightTasks(ctx context.Context, stage *air
 	return taskList.Items, nil
 }
 
-func (r *MissionStageReconciler) reconcileFlightTasks(ctx context.Context, stage *airforcev1alpha1.MissionStage) error {
-	desired := make(map[string]airforcev1alpha1.MissionStageFlightTaskTemplate, len(stage.Spec.FlightTasks))
-	for _, tmpl := range stage.Spec.FlightTasks {
+// reconcileFlightTasks creates and updates the stage's FlightTasks and returns the
+// tasks that were skipped, keyed by task name, with the reason.
+func (r *MissionStageReconciler) reconcileFlightTasks(ctx context.Context, stage *airforcev1alpha1.MissionStage) (map[string]string, error) {
+	skipped := map[string]string{}
+	waiting := map[string]bool{}
+	outputs := newOutputResolver(r.Client, stage.Namespace, stage.Spec.MissionRef.Name)
+	templates := stageFlightTaskTemplates(stage)
+	desired := make(map[string]airforcev1alpha1.MissionStageFlightTaskTemplate, len(templates))
+	for _, tmpl := range templates {
 		if tmpl.Name == "" {
 			continue
 		}
+		if stage.Status.Phase == airforcev1alpha1.MissionStagePhaseSkipped {
+			skipped[tmpl.Name] = "stage skipped"
+			continue
+		}
+		run, ready, err := outputs.evaluateWhen(ctx, "flightTask "+tmpl.Name, tmpl.When)
+		if err != nil {
+			return nil, err
+		}
+		if ready && !run {
+			skipped[tmpl.Name] = fmt.Sprintf("when %q evaluated to false", tmpl.When)
+			continue
+		}
+		waiting[tmpl.Name] = !ready
 		desired[tmpl.Name] = tmpl
 	}
 
-	for index, tmpl := range stage.Spec.FlightTasks {
+	for index, tmpl := range templates {
 		if tmpl.Name == "" {
 			continue
 		}
 		taskObjName := fmt.Sprintf("%s-%s", stage.Name, tmpl.Name)
+		if _, ok := skipped[tmpl.Name]; ok || waiting[tmpl.Name] {
+			continue
+		}
+
+		// 引用前序阶段输出的任务，等被引用阶段完成后再创建
+		tmpl, ready, err := outputs.resolveTemplate(ctx, tmpl)
+		if err != nil {
+			return nil, err
+		}
+		if !ready {
+			continue
+		}
 
 		var task airforcev1alpha1.FlightTask
-		err := r.Get(ctx, client.ObjectKey{Namespace: stage.Namespace, Name: taskObjName}, &task)
+		err = r.Get(ctx, client.ObjectKey{Namespace: stage.Namespace, Name: taskObjName}, &task)
 		if err != nil && !apierrors.IsNotFound(err) {
-			return err
+			return nil, err
 		}
 
 		desiredLabels := map[string]string{
@@ -164,20 +251,19 @@ func (r *MissionStageReconciler) reconcileFlightTasks(ctx context.Context, stage
 			"stage":       stage.Name,
 			"task-name":   tmpl.Name,
 			"task-index":  strconv.Itoa(index + 1),
-			"aircraft":    tmpl.Aircraft,
+			"aircraft":    templateAircraftType(tmpl),
 			"task-role":   tmpl.Role,
 			"stage-index": strconv.Itoa(int(stage.Spec.StageIndex)),
 		}
 
 		desiredSpec := airforcev1alpha1.FlightTaskSpec{
-			StageRef: airforcev1alpha1.MissionStageRef{Name: stage.Name},
-			AircraftRequirement: airforcev1alpha1.AircraftRequirement{
-				Type: tmpl.Aircraft,
-			},
-			Role: tmpl.Role,
-		}
-		if len(tmpl.TaskParams) > 0 {
-			desiredSpec.TaskParams = &airforcev1alpha1.FlightTaskParams{Extra: tmpl.TaskParams}
+			StageRef:            airforcev1alpha1.MissionStageRef{Name: stage.Name},
+			AircraftRequirement: templateAircraftRequirement(tmpl),
+			Role:                tmpl.Role,
+			TargetRef:           tmpl.TargetRef,
+			TaskParams:          templateTaskParams(tmpl),
+			UnschedulablePolicy: tmpl.UnschedulablePolicy.DeepCopy(),
+			ImagePullTimeout:    tmpl.ImagePullTimeout.DeepCopy(),
 		}
 		if len(tmpl.WeaponLoadout) > 0 {
 			desiredSpec.WeaponLoadout = make([]airforcev1alpha1.FlightTaskWeaponLoadoutItem, 0, len(tmpl.WeaponLoadout))
@@ -202,6 +288,9 @@ func (r *MissionStageReconciler) reconcileFlightTasks(ctx context.Context, stage
 		}
 
 		if apierrors.IsNotFound(err) {
+			if stageStopped(stage) != "" {
+				continue
+			}
 			task = airforcev1alpha1.FlightTask{
 				ObjectMeta: metav1.ObjectMeta{
 					Namespace: stage.Namespace,
@@ -211,11 +300,13 @@ func (r *MissionStageReconciler) reconcileFlightTasks(ctx context.Context, stage
 				Spec: desiredSpec,
 			}
 			if err := controllerutil.SetControllerReference(stage, &task, r.Scheme); err != nil {
-				return err
+				return nil, err
 			}
+			tracing.Inject(ctx, &task)
 			if err := r.Create(ctx, &task); err != nil {
-				return err
+				return nil, err
 			}
+			recordNormal(r.Recorder, stage, eventReasonTaskCreated, "Created FlightTask %s", task.Name)
 			continue
 		}
 
@@ -236,23 +327,29 @@ func (r *MissionStageReconciler) reconcileFlightTasks(ctx context.Context, stage
 			task.Spec.StageRef = desiredSpec.StageRef
 			changed = true
 		}
-		if task.Spec.AircraftRequirement.Type != desiredSpec.AircraftRequirement.Type {
-			task.Spec.AircraftRequirement.Type = desiredSpec.AircraftRequirement.Type
+		if !equality.Semantic.DeepEqual(task.Spec.AircraftRequirement, desiredSpec.AircraftRequirement) {
+			task.Spec.AircraftRequirement = desiredSpec.AircraftRequirement
 			changed = true
 		}
 		if task.Spec.Role != desiredSpec.Role {
 			task.Spec.Role = desiredSpec.Role
 			changed = true
 		}
-		if desiredSpec.TaskParams == nil && task.Spec.TaskParams != nil {
-			task.Spec.TaskParams = nil
+		if task.Spec.TargetRef != desiredSpec.TargetRef {
+			task.Spec.TargetRef = desiredSpec.TargetRef
 			changed = true
 		}
-		if desiredSpec.TaskParams != nil {
-			if task.Spec.TaskParams == nil || !mapsEqual(task.Spec.TaskParams.Extra, desiredSpec.TaskParams.Extra) {
-				task.Spec.TaskParams = desiredSpec.TaskParams
-				changed = true
-			}
+		if !equality.Semantic.DeepEqual(task.Spec.TaskParams, desiredSpec.TaskParams) {
+			task.Spec.TaskParams = desiredSpec.TaskParams
+			changed = true
+		}
+		if !equality.Semantic.DeepEqual(task.Spec.UnschedulablePolicy, desiredSpec.UnschedulablePolicy) {
+			task.Spec.UnschedulablePolicy = desiredSpec.UnschedulablePolicy
+			changed = true
+		}
+		if !equality.Semantic.DeepEqual(task.Spec.ImagePullTimeout, desiredSpec.ImagePullTimeout) {
+			task.Spec.ImagePullTimeout = desiredSpec.ImagePullTimeout
+			changed = true
 		}
 		if len(desiredSpec.WeaponLoadout) == 0 && len(task.Spec.WeaponLoadout) != 0 {
 			task.Spec.WeaponLoadout = nil
@@ -269,7 +366,7 @@ func (r *MissionStageReconciler) reconcileFlightTasks(ctx context.Context, stage
 
 		if changed {
 			if err := r.Patch(ctx, &task, patch); err != nil {
-				return err
+				return nil, err
 			}
 		}
 	}
@@ -277,7 +374,7 @@ func (r *MissionStageReconciler) reconcileFlightTasks(ctx context.Context, stage
 	// Delete tasks that are no longer referenced in stage.spec.flightTasks.
 	tasks, err := r.listFlightTasks(ctx, stage)
 	if err != nil {
-		return err
+		return nil, err
 	}
 	for _, task := range tasks {
 		taskName := task.Labels["task-name"]
@@ -287,10 +384,12 @@ func (r *MissionStageReconciler) reconcileFlightTasks(ctx context.Context, stage
 		if _, ok := desired[taskName]; ok {
 			continue
 		}
-		_ = r.Delete(ctx, &task)
+		if err := r.Delete(ctx, &task); err == nil {
+			recordNormal(r.Recorder, stage, eventReasonTaskDeleted, "Deleted FlightTask %s no longer in stage spec", task.Name)
+		}
 	}
 
-	return nil
+	return skipped, nil
 }
 
 func (r *MissionStageReconciler) progressTasks(ctx context.Context, stage *airforcev1alpha1.MissionStage, tasks []airforcev1alpha1.FlightTask) error {
@@ -368,18 +467,37 @@ func (r *MissionStageReconciler) progressTasks(ctx context.Context, stage *airfo
 	}
 }
 
-func (r *MissionStageReconciler) updateStageStatus(ctx context.Context, stage *airforcev1alpha1.MissionStage, tasks []airforcev1alpha1.FlightTask) error {
+func (r *MissionStageReconciler) updateStageStatus(ctx context.Context, stage *airforcev1alpha1.MissionStage, tasks []airforcev1alpha1.FlightTask, skipped map[string]string) error {
 	taskByName := make(map[string]airforcev1alpha1.FlightTask, len(tasks))
 	for _, task := range tasks {
 		taskByName[task.Labels["task-name"]] = task
 	}
 
-	statuses := make([]airforcev1alpha1.MissionStageFlightTaskStatus, 0, len(stage.Spec.FlightTasks))
-	var pending, scheduled, running, succeeded, failed int
-	for _, tmpl := range stage.Spec.FlightTasks {
+	templates := stageFlightTaskTemplates(stage)
+	statuses := make([]airforcev1alpha1.MissionStageFlightTaskStatus, 0, len(templates))
+	previousStatuses := make(map[string]airforcev1alpha1.FlightTaskPhase, len(stage.Status.FlightTasksStatus))
+	for _, status := range stage.Status.FlightTasksStatus {
+		previousStatuses[status.Name] = status.Phase
+	}
+	var pending, scheduled, running, succeeded, failed, skippedCount int
+	for _, tmpl := range templates {
 		if tmpl.Name == "" {
 			continue
 		}
+		if reason, ok := skipped[tmpl.Name]; ok {
+			skippedCount++
+			statuses = append(statuses, airforcev1alpha1.MissionStageFlightTaskStatus{
+				Name:    tmpl.Name,
+				Phase:   airforcev1alpha1.FlightTaskPhaseSkipped,
+				Target:  tmpl.TargetRef,
+				Message: reason,
+			})
+			if previousStatuses[tmpl.Name] != airforcev1alpha1.FlightTaskPhaseSkipped &&
+				stage.Status.Phase != airforcev1alpha1.MissionStagePhaseSkipped {
+				recordNormal(r.Recorder, stage, eventReasonTaskSkipped, "FlightTask %s skipped: %s", tmpl.Name, reason)
+			}
+			continue
+		}
 		task, ok := taskByName[tmpl.Name]
 		phase := airforcev1alpha1.FlightTaskPhasePending
 		if ok && task.Status.Phase != "" {
@@ -401,7 +519,11 @@ func (r *MissionStageReconciler) updateStageStatus(ctx context.Context, stage *a
 
 		aircraftNode := ""
 		podName := ""
+		message := ""
 		if ok {
+			if cond := apimeta.FindStatusCondition(task.Status.Conditions, conditionAborted); cond != nil && cond.Status == metav1.ConditionTrue {
+				message = cond.Reason + ": " + cond.Message
+			}
 			if task.Status.SchedulingInfo != nil {
 				aircraftNode = task.Status.SchedulingInfo.AssignedNode
 			}
@@ -415,19 +537,28 @@ func (r *MissionStageReconciler) updateStageStatus(ctx context.Context, stage *a
 			Phase:        phase,
 			AircraftNode: aircraftNode,
 			PodName:      podName,
+			Target:       tmpl.TargetRef,
+			Message:      message,
 		})
 	}
 
+	previousPhase := stage.Status.Phase
+	stopped := stageStopped(stage)
 	patch := client.MergeFrom(stage.DeepCopy())
 	stage.Status.FlightTasksStatus = statuses
-	stage.Status.Message = fmt.Sprintf("tasks: pending=%d scheduled=%d running=%d succeeded=%d failed=%d", pending, scheduled, running, succeeded, failed)
+	if stage.Status.Phase != airforcev1alpha1.MissionStagePhaseSkipped {
+		stage.Status.Message = fmt.Sprintf("tasks: pending=%d scheduled=%d running=%d succeeded=%d failed=%d skipped=%d", pending, scheduled, running, succeeded, failed, skippedCount)
+		if stopped != "" {
+			stage.Status.Message = stageStoppedMessage(stopped) + "; " + stage.Status.Message
+		}
+	}
 
 	if stage.Status.Phase == airforcev1alpha1.MissionStagePhaseRunning {
 		if failed > 0 {
 			stage.Status.Phase = airforcev1alpha1.MissionStagePhaseFailed
 			now := metav1.Now()
 			stage.Status.CompletionTime = &now
-		} else if len(statuses) == 0 || succeeded == len(statuses) {
+		} else if len(statuses) == 0 || succeeded+skippedCount == len(statuses) {
 			stage.Status.Phase = airforcev1alpha1.MissionStagePhaseSucceeded
 			now := metav1.Now()
 			stage.Status.CompletionTime = &now
@@ -446,7 +577,23 @@ func (r *MissionStageReconciler) updateStageStatus(ctx context.Context, stage *a
 		stage.Status.StartTime = &now
 	}
 
-	return r.Status().Patch(ctx, stage, patch)
+	if err := r.Status().Patch(ctx, stage, patch); err != nil {
+		return err
+	}
+	if previousPhase == airforcev1alpha1.MissionStagePhaseRunning && stage.Status.Phase != previousPhase {
+		return observeStageFinished(ctx, r.Client, stage)
+	}
+	return nil
+}
+
+// isStageFinished reports whether the stage reached a terminal phase.
+func isStageFinished(phase airforcev1alpha1.MissionStagePhase) bool {
+	switch phase {
+	case airforcev1alpha1.MissionStagePhaseSucceeded, airforcev1alpha1.MissionStagePhaseFailed, airforcev1alpha1.MissionStagePhaseSkipped:
+		return true
+	default:
+		return false
+	}
 }
 
 func (r *MissionStageReconciler) isStageTimeout(stage *airforcev1alpha1.MissionStage) bool {
@@ -460,6 +607,47 @@ func (r *MissionStageReconciler) isStageTimeout(stage *airforcev1alpha1.MissionS
 	return time.Since(stage.Status.StartTime.Time) > timeout
 }
 
+// templateAircraftType is the aircraft type of a task template: aircraftRequirement.type,
+// else the aircraft shorthand.
+func templateAircraftType(tmpl airforcev1alpha1.MissionStageFlightTaskTemplate) string {
+	if tmpl.AircraftRequirement != nil && tmpl.AircraftRequirement.Type != "" {
+		return tmpl.AircraftRequirement.Type
+	}
+	return tmpl.Aircraft
+}
+
+// templateAircraftRequirement is the aircraft requirement a template gives its FlightTask.
+func templateAircraftRequirement(tmpl airforcev1alpha1.MissionStageFlightTaskTemplate) airforcev1alpha1.AircraftRequirement {
+	var req airforcev1alpha1.AircraftRequirement
+	if tmpl.AircraftRequirement != nil {
+		tmpl.AircraftRequirement.DeepCopyInto(&req)
+	}
+	req.Type = templateAircraftType(tmpl)
+	return req
+}
+
+// templateTaskParams is the typed params of a template with the free-form taskParams
+// added to extra, or nil if the template has neither.
+func templateTaskParams(tmpl airforcev1alpha1.MissionStageFlightTaskTemplate) *airforcev1alpha1.FlightTaskParams {
+	if tmpl.Params == nil && len(tmpl.TaskParams) == 0 {
+		return nil
+	}
+	params := tmpl.Params.DeepCopy()
+	if params == nil {
+		params = &airforcev1alpha1.FlightTaskParams{}
+	}
+	for k, v := range tmpl.TaskParams {
+		if _, ok := params.Extra[k]; ok {
+			continue
+		}
+		if params.Extra == nil {
+			params.Extra = map[string]string{}
+		}
+		params.Extra[k] = v
+	}
+	return params
+}
+
 func mapsEqual(a, b map[string]string) bool {
 	if len(a) != len(b) {
 		return false
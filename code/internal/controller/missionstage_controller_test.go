@@ -18,11 +18,17 @@ package controller
 
 import (
 	"context"
+	goerrors "errors"
+	"time"
 
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
+	apimeta "k8s.io/apimachinery/pkg/api/meta"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -80,5 +86,402 @@ var _ = Describe("MissionStage Controller", func() {
 			// TODO(user): Add more specific assertions depending on your controller's reconciliation logic.
 			// Example: If you expect a certain status condition after reconciliation, verify it here.
 		})
+
+		It("should emit an event when creating FlightTasks", func() {
+			resource := &airforcev1alpha1.MissionStage{}
+			Expect(k8sClient.Get(ctx, typeNamespacedName, resource)).To(Succeed())
+			patch := client.MergeFrom(resource.DeepCopy())
+			resource.Spec.MissionRef.Name = "m1"
+			resource.Spec.FlightTasks = []airforcev1alpha1.MissionStageFlightTaskTemplate{
+				{Name: "j20-01", Aircraft: "j20", Role: "reconnaissance"},
+			}
+			Expect(k8sClient.Patch(ctx, resource, patch)).To(Succeed())
+
+			recorder := record.NewFakeRecorder(10)
+			controllerReconciler := &MissionStageReconciler{
+				Client:   k8sClient,
+				Scheme:   k8sClient.Scheme(),
+				Recorder: recorder,
+			}
+			_, err := controllerReconciler.Reconcile(ctx, reconcile.Request{
+				NamespacedName: typeNamespacedName,
+			})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(recorder.Events).To(Receive(ContainSubstring(eventReasonTaskCreated)))
+
+			task := &airforcev1alpha1.FlightTask{}
+			Expect(k8sClient.Get(ctx, types.NamespacedName{Name: resourceName + "-j20-01", Namespace: "default"}, task)).To(Succeed())
+			Expect(k8sClient.Delete(ctx, task)).To(Succeed())
+		})
+	})
+
+	Context("When a stage references outputs of an earlier stage", func() {
+		ctx := context.Background()
+		strikeKey := types.NamespacedName{Name: "m2-strike", Namespace: "default"}
+
+		BeforeEach(func() {
+			isr := &airforcev1alpha1.MissionStage{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "m2-isr",
+					Namespace: "default",
+					Labels:    map[string]string{"mission": "m2", "stage-name": "isr"},
+				},
+				Spec: airforcev1alpha1.MissionStageSpec{MissionRef: airforcev1alpha1.MissionRef{Name: "m2"}},
+			}
+			Expect(k8sClient.Create(ctx, isr)).To(Succeed())
+			patch := client.MergeFrom(isr.DeepCopy())
+			isr.Status.Phase = airforcev1alpha1.MissionStagePhaseSucceeded
+			Expect(k8sClient.Status().Patch(ctx, isr, patch)).To(Succeed())
+
+			isrTask := &airforcev1alpha1.FlightTask{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "m2-isr-j20-01",
+					Namespace: "default",
+					Labels:    map[string]string{"mission": "m2", "stage": "m2-isr", "task-name": "j20-01"},
+				},
+			}
+			Expect(k8sClient.Create(ctx, isrTask)).To(Succeed())
+			taskPatch := client.MergeFrom(isrTask.DeepCopy())
+			isrTask.Status.Outputs = map[string]string{"targetLat": "31.2304"}
+			Expect(k8sClient.Status().Patch(ctx, isrTask, taskPatch)).To(Succeed())
+		})
+
+		AfterEach(func() {
+			for _, name := range []string{"m2-isr-j20-01", "m2-strike-j20-02", "m2-strike-j20-03"} {
+				task := &airforcev1alpha1.FlightTask{}
+				if err := k8sClient.Get(ctx, types.NamespacedName{Name: name, Namespace: "default"}, task); err == nil {
+					Expect(k8sClient.Delete(ctx, task)).To(Succeed())
+				}
+			}
+			for _, name := range []string{"m2-isr", "m2-strike"} {
+				stage := &airforcev1alpha1.MissionStage{}
+				if err := k8sClient.Get(ctx, types.NamespacedName{Name: name, Namespace: "default"}, stage); err == nil {
+					Expect(k8sClient.Delete(ctx, stage)).To(Succeed())
+				}
+			}
+		})
+
+		createStrikeStage := func(param string) {
+			strike := &airforcev1alpha1.MissionStage{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      strikeKey.Name,
+					Namespace: "default",
+					Labels:    map[string]string{"mission": "m2", "stage-name": "strike"},
+				},
+				Spec: airforcev1alpha1.MissionStageSpec{
+					MissionRef: airforcev1alpha1.MissionRef{Name: "m2"},
+					DependsOn:  []string{"isr"},
+					FlightTasks: []airforcev1alpha1.MissionStageFlightTaskTemplate{{
+						Name:       "j20-02",
+						Aircraft:   "j20",
+						Role:       "strike",
+						TaskParams: map[string]string{"targetLat": param},
+					}},
+				},
+			}
+			Expect(k8sClient.Create(ctx, strike)).To(Succeed())
+		}
+
+		It("should substitute the referenced output into taskParams", func() {
+			createStrikeStage("{{ stages.isr.tasks.j20-01.outputs.targetLat }}")
+
+			controllerReconciler := &MissionStageReconciler{Client: k8sClient, Scheme: k8sClient.Scheme()}
+			_, err := controllerReconciler.Reconcile(ctx, reconcile.Request{NamespacedName: strikeKey})
+			Expect(err).NotTo(HaveOccurred())
+
+			task := &airforcev1alpha1.FlightTask{}
+			Expect(k8sClient.Get(ctx, types.NamespacedName{Name: "m2-strike-j20-02", Namespace: "default"}, task)).To(Succeed())
+			Expect(task.Spec.TaskParams).NotTo(BeNil())
+			Expect(task.Spec.TaskParams.Extra).To(HaveKeyWithValue("targetLat", "31.2304"))
+		})
+
+		It("should fail the stage when the referenced output is missing", func() {
+			createStrikeStage("{{ stages.isr.tasks.j20-01.outputs.targetLon }}")
+
+			recorder := record.NewFakeRecorder(10)
+			controllerReconciler := &MissionStageReconciler{Client: k8sClient, Scheme: k8sClient.Scheme(), Recorder: recorder}
+			_, err := controllerReconciler.Reconcile(ctx, reconcile.Request{NamespacedName: strikeKey})
+			Expect(err).NotTo(HaveOccurred())
+
+			stage := &airforcev1alpha1.MissionStage{}
+			Expect(k8sClient.Get(ctx, strikeKey, stage)).To(Succeed())
+			Expect(stage.Status.Phase).To(Equal(airforcev1alpha1.MissionStagePhaseFailed))
+			Expect(stage.Status.Message).To(ContainSubstring(`did not report output "targetLon"`))
+			Expect(recorder.Events).To(Receive(ContainSubstring(eventReasonOutputMissing)))
+
+			task := &airforcev1alpha1.FlightTask{}
+			err = k8sClient.Get(ctx, types.NamespacedName{Name: "m2-strike-j20-02", Namespace: "default"}, task)
+			Expect(errors.IsNotFound(err)).To(BeTrue())
+		})
+
+		It("should skip tasks whose when expression is false and report them in status", func() {
+			strike := &airforcev1alpha1.MissionStage{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      strikeKey.Name,
+					Namespace: "default",
+					Labels:    map[string]string{"mission": "m2", "stage-name": "strike"},
+				},
+				Spec: airforcev1alpha1.MissionStageSpec{
+					MissionRef: airforcev1alpha1.MissionRef{Name: "m2"},
+					StageType:  airforcev1alpha1.StageExecutionTypeParallel,
+					DependsOn:  []string{"isr"},
+					FlightTasks: []airforcev1alpha1.MissionStageFlightTaskTemplate{
+						{Name: "j20-02", Aircraft: "j20", Role: "strike", When: `stages.isr.outputs.targetConfirmed == "true"`},
+						{Name: "j20-03", Aircraft: "j20", Role: "bda", When: `stages.isr.phase == "已完成" && stages.isr.tasks.j20-01.outputs.targetLat > 30`},
+					},
+				},
+			}
+			Expect(k8sClient.Create(ctx, strike)).To(Succeed())
+			patch := client.MergeFrom(strike.DeepCopy())
+			strike.Status.Phase = airforcev1alpha1.MissionStagePhaseRunning
+			Expect(k8sClient.Status().Patch(ctx, strike, patch)).To(Succeed())
+
+			recorder := record.NewFakeRecorder(10)
+			controllerReconciler := &MissionStageReconciler{Client: k8sClient, Scheme: k8sClient.Scheme(), Recorder: recorder}
+			_, err := controllerReconciler.Reconcile(ctx, reconcile.Request{NamespacedName: strikeKey})
+			Expect(err).NotTo(HaveOccurred())
+
+			task := &airforcev1alpha1.FlightTask{}
+			err = k8sClient.Get(ctx, types.NamespacedName{Name: "m2-strike-j20-02", Namespace: "default"}, task)
+			Expect(errors.IsNotFound(err)).To(BeTrue())
+			Expect(k8sClient.Get(ctx, types.NamespacedName{Name: "m2-strike-j20-03", Namespace: "default"}, task)).To(Succeed())
+
+			stage := &airforcev1alpha1.MissionStage{}
+			Expect(k8sClient.Get(ctx, strikeKey, stage)).To(Succeed())
+			Expect(stage.Status.FlightTasksStatus).To(HaveLen(2))
+			Expect(stage.Status.FlightTasksStatus[0].Phase).To(Equal(airforcev1alpha1.FlightTaskPhaseSkipped))
+			Expect(stage.Status.FlightTasksStatus[0].Message).To(ContainSubstring("evaluated to false"))
+			Expect(recorder.Events).To(Receive(ContainSubstring(eventReasonTaskSkipped)))
+		})
+
+		It("should evaluate when expressions", func() {
+			values := map[string]string{
+				"stages.isr.outputs.targetConfirmed": "true",
+				"stages.isr.outputs.targets":         "3",
+				"stages.isr.phase":                   string(airforcev1alpha1.MissionStagePhaseSucceeded),
+			}
+			lookup := func(ref string) (string, bool, error) {
+				if ref == "stages.ew.phase" {
+					return "", false, nil
+				}
+				return values[ref], true, nil
+			}
+			cases := map[string]bool{
+				`stages.isr.outputs.targetConfirmed == "true"`:                      true,
+				`stages.isr.outputs.targetConfirmed`:                                true,
+				`!stages.isr.outputs.targetConfirmed`:                               false,
+				`stages.isr.outputs.targets >= 2 && stages.isr.outputs.targets < 4`: true,
+				`stages.isr.outputs.targets == 3.0`:                                 true,
+				`stages.isr.outputs.missing == "" || false`:                         true,
+				`stages.isr.phase != '已完成'`:                                         false,
+				`(false || true) && !(stages.isr.outputs.targets > 5)`:              true,
+				`false && stages.ew.phase == "已完成"`:                                 false,
+				`!stages.isr.outputs.missing == stages.isr.outputs.targetConfirmed`: true,
+				`!(stages.isr.outputs.targets == 3)`:                                false,
+			}
+			for expr, want := range cases {
+				got, ready, err := evaluateWhen("test", expr, lookup)
+				Expect(err).NotTo(HaveOccurred(), expr)
+				Expect(ready).To(BeTrue(), expr)
+				Expect(got).To(Equal(want), expr)
+			}
+
+			_, ready, err := evaluateWhen("test", `stages.ew.phase == "已完成"`, lookup)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(ready).To(BeFalse())
+
+			for _, expr := range []string{`stages.isr.outputs.targetConfirmed ==`, `isr == "true"`, `"a" < "b"`, `(true`, `stages.isr.phase`, `!stages.isr.outputs.targets == 3`} {
+				_, _, err := evaluateWhen("test", expr, lookup)
+				var whenErr *whenError
+				Expect(goerrors.As(err, &whenErr)).To(BeTrue(), expr)
+			}
+		})
+	})
+
+	Context("When a task template carries typed params and an aircraft requirement", func() {
+		ctx := context.Background()
+
+		AfterEach(func() {
+			_ = k8sClient.Delete(ctx, &airforcev1alpha1.FlightTask{ObjectMeta: metav1.ObjectMeta{Name: "typed-stage-strike-1", Namespace: "default"}})
+			_ = k8sClient.Delete(ctx, &airforcev1alpha1.MissionStage{ObjectMeta: metav1.ObjectMeta{Name: "typed-stage", Namespace: "default"}})
+		})
+
+		It("should propagate them to the FlightTask unchanged", func() {
+			stage := &airforcev1alpha1.MissionStage{
+				ObjectMeta: metav1.ObjectMeta{Name: "typed-stage", Namespace: "default"},
+				Spec: airforcev1alpha1.MissionStageSpec{
+					MissionRef: airforcev1alpha1.MissionRef{Name: "typed"},
+					FlightTasks: normalizeStageFlightTasks([]airforcev1alpha1.MissionStageFlightTaskTemplate{{
+						Name:     "strike",
+						Aircraft: "j16",
+						AircraftRequirement: &airforcev1alpha1.AircraftRequirement{
+							Type:               "j20",
+							MinFuelLevel:       60,
+							Capabilities:       []string{"stealth"},
+							RequiredHardpoints: 4,
+							PreferredLocation:  "east",
+						},
+						Params: &airforcev1alpha1.FlightTaskParams{
+							Altitude:        "{{ item.altitude }}",
+							Speed:           "900km/h",
+							MissionDuration: &metav1.Duration{Duration: 2 * time.Hour},
+							Phases:          []airforcev1alpha1.TaskPhase{{Name: "ingress", Duration: &metav1.Duration{Duration: 20 * time.Minute}}},
+							Extra:           map[string]string{"callsign": "eagle-{{ index }}"},
+						},
+						TaskParams: map[string]string{"callsign": "ignored", "iff": "mode-5"},
+						WithItems:  []map[string]string{{"altitude": "9000m"}},
+					}}),
+				},
+			}
+			Expect(k8sClient.Create(ctx, stage)).To(Succeed())
+
+			controllerReconciler := &MissionStageReconciler{
+				Client:   k8sClient,
+				Scheme:   k8sClient.Scheme(),
+				Recorder: record.NewFakeRecorder(10),
+			}
+			_, err := controllerReconciler.reconcileFlightTasks(ctx, stage)
+			Expect(err).NotTo(HaveOccurred())
+
+			task := &airforcev1alpha1.FlightTask{}
+			key := types.NamespacedName{Name: "typed-stage-strike-1", Namespace: "default"}
+			Expect(k8sClient.Get(ctx, key, task)).To(Succeed())
+			Expect(task.Labels["aircraft"]).To(Equal("j20"))
+			Expect(task.Spec.AircraftRequirement).To(Equal(*stage.Spec.FlightTasks[0].AircraftRequirement))
+			params := task.Spec.TaskParams
+			Expect(params.Altitude).To(Equal("9000m"))
+			Expect(params.MissionDuration.Duration).To(Equal(2 * time.Hour))
+			Expect(params.Phases).To(HaveLen(1))
+			Expect(params.Extra).To(Equal(map[string]string{"callsign": "eagle-1", "iff": "mode-5"}))
+
+			stage.Spec.FlightTasks[0].Params.Speed = "1100km/h"
+			stage.Spec.FlightTasks[0].AircraftRequirement.MinFuelLevel = 80
+			_, err = controllerReconciler.reconcileFlightTasks(ctx, stage)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(k8sClient.Get(ctx, key, task)).To(Succeed())
+			Expect(task.Spec.TaskParams.Speed).To(Equal("1100km/h"))
+			Expect(task.Spec.AircraftRequirement.MinFuelLevel).To(BeEquivalentTo(80))
+		})
+	})
+
+	Context("When a standalone stage fans a template out", func() {
+		ctx := context.Background()
+
+		AfterEach(func() {
+			for _, name := range []string{"fanout-stage-escort-1", "fanout-stage-escort-2"} {
+				_ = k8sClient.Delete(ctx, &airforcev1alpha1.FlightTask{ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default"}})
+			}
+			_ = k8sClient.Delete(ctx, &airforcev1alpha1.MissionStage{ObjectMeta: metav1.ObjectMeta{Name: "fanout-stage", Namespace: "default"}})
+		})
+
+		It("should expand count and substitute the index into when", func() {
+			stage := &airforcev1alpha1.MissionStage{
+				ObjectMeta: metav1.ObjectMeta{Name: "fanout-stage", Namespace: "default"},
+				Spec: airforcev1alpha1.MissionStageSpec{
+					MissionRef: airforcev1alpha1.MissionRef{Name: "fanout"},
+					FlightTasks: []airforcev1alpha1.MissionStageFlightTaskTemplate{{
+						Name: "escort", Aircraft: "j16", Count: 2, When: `"{{ index }}" == "1"`,
+					}},
+				},
+			}
+			Expect(k8sClient.Create(ctx, stage)).To(Succeed())
+
+			controllerReconciler := &MissionStageReconciler{
+				Client:   k8sClient,
+				Scheme:   k8sClient.Scheme(),
+				Recorder: record.NewFakeRecorder(10),
+			}
+			skipped, err := controllerReconciler.reconcileFlightTasks(ctx, stage)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(skipped).To(HaveKey("escort-2"))
+
+			task := &airforcev1alpha1.FlightTask{}
+			Expect(k8sClient.Get(ctx, types.NamespacedName{Name: "fanout-stage-escort-1", Namespace: "default"}, task)).To(Succeed())
+			Expect(task.Labels["task-name"]).To(Equal("escort-1"))
+			err = k8sClient.Get(ctx, types.NamespacedName{Name: "fanout-stage-escort-2", Namespace: "default"}, task)
+			Expect(errors.IsNotFound(err)).To(BeTrue())
+		})
+	})
+
+	Context("When a running stage exceeds its timeout", func() {
+		ctx := context.Background()
+
+		AfterEach(func() {
+			_ = k8sClient.Delete(ctx, &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "slow-stage-strike-pod", Namespace: "default"}})
+			_ = k8sClient.Delete(ctx, &airforcev1alpha1.FlightTask{ObjectMeta: metav1.ObjectMeta{Name: "slow-stage-strike", Namespace: "default"}})
+			_ = k8sClient.Delete(ctx, &airforcev1alpha1.MissionStage{ObjectMeta: metav1.ObjectMeta{Name: "slow-stage", Namespace: "default"}})
+		})
+
+		It("should stop its tasks with the StageTimedOut reason", func() {
+			stage := &airforcev1alpha1.MissionStage{
+				ObjectMeta: metav1.ObjectMeta{Name: "slow-stage", Namespace: "default"},
+				Spec: airforcev1alpha1.MissionStageSpec{
+					MissionRef:  airforcev1alpha1.MissionRef{Name: "slow"},
+					StageType:   airforcev1alpha1.StageExecutionTypeParallel,
+					FlightTasks: []airforcev1alpha1.MissionStageFlightTaskTemplate{{Name: "strike", Aircraft: "j20"}},
+					Config:      &airforcev1alpha1.MissionStageConfig{Timeout: &metav1.Duration{Duration: time.Minute}},
+				},
+			}
+			Expect(k8sClient.Create(ctx, stage)).To(Succeed())
+			stage.Status.Phase = airforcev1alpha1.MissionStagePhaseRunning
+			stage.Status.StartTime = &metav1.Time{Time: time.Now().Add(-2 * time.Minute)}
+			Expect(k8sClient.Status().Update(ctx, stage)).To(Succeed())
+
+			pod := &corev1.Pod{
+				ObjectMeta: metav1.ObjectMeta{Name: "slow-stage-strike-pod", Namespace: "default"},
+				Spec:       corev1.PodSpec{Containers: []corev1.Container{{Name: "task", Image: "busybox:1.36"}}},
+			}
+			Expect(k8sClient.Create(ctx, pod)).To(Succeed())
+			task := &airforcev1alpha1.FlightTask{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "slow-stage-strike",
+					Namespace: "default",
+					Labels:    map[string]string{"mission": "slow", "stage": "slow-stage", "task-name": "strike", "aircraft": "j20"},
+				},
+				Spec: airforcev1alpha1.FlightTaskSpec{
+					StageRef:            airforcev1alpha1.MissionStageRef{Name: "slow-stage"},
+					AircraftRequirement: airforcev1alpha1.AircraftRequirement{Type: "j20"},
+				},
+			}
+			Expect(k8sClient.Create(ctx, task)).To(Succeed())
+			task.Status.Phase = airforcev1alpha1.FlightTaskPhaseRunning
+			task.Status.PodRef = &corev1.ObjectReference{Name: pod.Name, Namespace: pod.Namespace}
+			Expect(k8sClient.Status().Update(ctx, task)).To(Succeed())
+
+			recorder := record.NewFakeRecorder(20)
+			controllerReconciler := &MissionStageReconciler{
+				Client:   k8sClient,
+				Scheme:   k8sClient.Scheme(),
+				Recorder: recorder,
+			}
+			key := types.NamespacedName{Name: "slow-stage", Namespace: "default"}
+			_, err := controllerReconciler.Reconcile(ctx, reconcile.Request{NamespacedName: key})
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(k8sClient.Get(ctx, key, stage)).To(Succeed())
+			Expect(stage.Status.Phase).To(Equal(airforcev1alpha1.MissionStagePhaseFailed))
+			Expect(stageTimedOut(stage)).To(BeTrue())
+			Expect(stage.Status.Reason).To(Equal(reasonStageTimedOut))
+
+			Expect(k8sClient.Get(ctx, types.NamespacedName{Name: "slow-stage-strike", Namespace: "default"}, task)).To(Succeed())
+			Expect(task.Status.Phase).To(Equal(airforcev1alpha1.FlightTaskPhaseFailed))
+			Expect(taskAborted(task)).To(BeTrue())
+			Expect(apimeta.FindStatusCondition(task.Status.Conditions, conditionAborted).Reason).To(Equal(reasonStageTimedOut))
+
+			err = k8sClient.Get(ctx, types.NamespacedName{Name: pod.Name, Namespace: "default"}, &corev1.Pod{})
+			if err == nil {
+				// envtest 没有 kubelet，Pod 停留在终止中
+				Expect(k8sClient.Get(ctx, types.NamespacedName{Name: pod.Name, Namespace: "default"}, pod)).To(Succeed())
+				Expect(pod.DeletionTimestamp).NotTo(BeNil())
+			} else {
+				Expect(errors.IsNotFound(err)).To(BeTrue())
+			}
+
+			_, err = controllerReconciler.Reconcile(ctx, reconcile.Request{NamespacedName: key})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(k8sClient.Get(ctx, key, stage)).To(Succeed())
+			Expect(stage.Status.Message).To(HavePrefix("Stage timed out; tasks:"))
+			Expect(stage.Status.FlightTasksStatus[0].Message).To(HavePrefix(reasonStageTimedOut))
+		})
 	})
 })
@@ -0,0 +1,302 @@
+/*
+Copyright 2026 yydashuai.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	airforcev1alpha1 "github.com/yydashuai/mission-system/api/v1alpha1"
+)
+
+// outputRefPattern matches {{ stages.<stage>.tasks.<task>.outputs.<key> }} where <stage> and
+// <task> are the names used in Mission.spec.stages and the stage's flightTasks.
+var outputRefPattern = regexp.MustCompile(`\{\{\s*stages\.([A-Za-z0-9_-]+)\.tasks\.([A-Za-z0-9_-]+)\.outputs\.([A-Za-z0-9_.-]+)\s*\}\}`)
+
+// whenRefPattern matches the references allowed in when expressions, see when.go.
+var whenRefPattern = regexp.MustCompile(`^stages\.([A-Za-z0-9_-]+)\.(?:tasks\.([A-Za-z0-9_-]+)\.)?(?:phase|outputs\.([A-Za-z0-9_.-]+))$`)
+
+// outputReferenceError reports a template reference that can never be resolved,
+// e.g. the referenced stage failed or the task did not report the output.
+type outputReferenceError struct {
+	task string
+	msg  string
+}
+
+func (e *outputReferenceError) Error() string {
+	return fmt.Sprintf("flightTask %q: %s", e.task, e.msg)
+}
+
+// outputResolver looks up outputs of FlightTasks in earlier stages of the same Mission.
+type outputResolver struct {
+	reader    client.Reader
+	namespace string
+	mission   string
+	stages    map[string]*airforcev1alpha1.MissionStage
+	tasks     map[string][]airforcev1alpha1.FlightTask
+}
+
+func newOutputResolver(reader client.Reader, namespace, mission string) *outputResolver {
+	return &outputResolver{
+		reader:    reader,
+		namespace: namespace,
+		mission:   mission,
+		stages:    map[string]*airforcev1alpha1.MissionStage{},
+		tasks:     map[string][]airforcev1alpha1.FlightTask{},
+	}
+}
+
+// resolveTemplate substitutes output references in params, taskParams values and the pod template.
+// ready is false while a referenced stage has not finished yet; the task must not be
+// created until then.
+func (o *outputResolver) resolveTemplate(ctx context.Context, tmpl airforcev1alpha1.MissionStageFlightTaskTemplate) (airforcev1alpha1.MissionStageFlightTaskTemplate, bool, error) {
+	hasRefs := false
+	for _, v := range tmpl.TaskParams {
+		if outputRefPattern.MatchString(v) {
+			hasRefs = true
+			break
+		}
+	}
+	if tmpl.PodTemplate != nil && outputRefPattern.Match(tmpl.PodTemplate.Raw) {
+		hasRefs = true
+	}
+	if params, err := json.Marshal(tmpl.Params); err == nil && tmpl.Params != nil && outputRefPattern.Match(params) {
+		hasRefs = true
+	}
+	if !hasRefs {
+		return tmpl, true, nil
+	}
+
+	resolved := *tmpl.DeepCopy()
+	for k, v := range resolved.TaskParams {
+		out, ready, err := o.substitute(ctx, tmpl.Name, v, false)
+		if err != nil || !ready {
+			return tmpl, ready, err
+		}
+		resolved.TaskParams[k] = out
+	}
+	if resolved.PodTemplate != nil && len(resolved.PodTemplate.Raw) != 0 {
+		// References in the pod template always sit inside JSON strings, so the
+		// replacement is escaped as JSON string content.
+		out, ready, err := o.substitute(ctx, tmpl.Name, string(resolved.PodTemplate.Raw), true)
+		if err != nil || !ready {
+			return tmpl, ready, err
+		}
+		resolved.PodTemplate = &runtime.RawExtension{Raw: []byte(out)}
+	}
+	ready := true
+	params, err := rewriteParams(resolved.Params, func(s string) (string, error) {
+		out, ok, err := o.substitute(ctx, tmpl.Name, s, true)
+		ready = ready && ok
+		return out, err
+	})
+	if err != nil || !ready {
+		return tmpl, ready, err
+	}
+	resolved.Params = params
+	return resolved, true, nil
+}
+
+func (o *outputResolver) substitute(ctx context.Context, taskName, s string, jsonEscape bool) (string, bool, error) {
+	matches := outputRefPattern.FindAllStringSubmatchIndex(s, -1)
+	if len(matches) == 0 {
+		return s, true, nil
+	}
+	var out []byte
+	last := 0
+	for _, m := range matches {
+		stageName, refTask, key := s[m[2]:m[3]], s[m[4]:m[5]], s[m[6]:m[7]]
+		value, ready, err := o.lookup(ctx, taskName, stageName, refTask, key)
+		if err != nil || !ready {
+			return "", ready, err
+		}
+		if jsonEscape {
+			quoted, _ := json.Marshal(value)
+			value = string(quoted[1 : len(quoted)-1])
+		}
+		out = append(out, s[last:m[0]]...)
+		out = append(out, value...)
+		last = m[1]
+	}
+	out = append(out, s[last:]...)
+	return string(out), true, nil
+}
+
+func (o *outputResolver) lookup(ctx context.Context, taskName, stageName, refTask, key string) (string, bool, error) {
+	ref := fmt.Sprintf("stages.%s.tasks.%s.outputs.%s", stageName, refTask, key)
+	stage, err := o.stage(ctx, stageName)
+	if err != nil {
+		return "", false, err
+	}
+	if stage == nil {
+		return "", false, &outputReferenceError{task: taskName, msg: fmt.Sprintf("%s: stage %q not found in mission %q", ref, stageName, o.mission)}
+	}
+	switch stage.Status.Phase {
+	case airforcev1alpha1.MissionStagePhaseSucceeded:
+	case airforcev1alpha1.MissionStagePhaseFailed:
+		return "", false, &outputReferenceError{task: taskName, msg: fmt.Sprintf("%s: stage %q failed", ref, stageName)}
+	case airforcev1alpha1.MissionStagePhaseSkipped:
+		return "", false, &outputReferenceError{task: taskName, msg: fmt.Sprintf("%s: stage %q was skipped", ref, stageName)}
+	default:
+		return "", false, nil
+	}
+
+	var task airforcev1alpha1.FlightTask
+	err = o.reader.Get(ctx, client.ObjectKey{Namespace: o.namespace, Name: fmt.Sprintf("%s-%s", stage.Name, refTask)}, &task)
+	if apierrors.IsNotFound(err) {
+		return "", false, &outputReferenceError{task: taskName, msg: fmt.Sprintf("%s: task %q not found in stage %q", ref, refTask, stageName)}
+	}
+	if err != nil {
+		return "", false, err
+	}
+	value, ok := task.Status.Outputs[key]
+	if !ok {
+		return "", false, &outputReferenceError{task: taskName, msg: fmt.Sprintf("%s: task %q did not report output %q", ref, refTask, key)}
+	}
+	return value, true, nil
+}
+
+// evaluateWhen evaluates a when expression against the stages of the Mission.
+// Unlike template references, an output that was not reported (including outputs
+// of failed or skipped stages) evaluates to "", so a condition such as
+// stages.isr.outputs.targetConfirmed == "true" is simply false.
+func (o *outputResolver) evaluateWhen(ctx context.Context, subject, expr string) (bool, bool, error) {
+	return evaluateWhen(subject, expr, func(ref string) (string, bool, error) {
+		return o.whenValue(ctx, ref)
+	})
+}
+
+func (o *outputResolver) whenValue(ctx context.Context, ref string) (string, bool, error) {
+	m := whenRefPattern.FindStringSubmatch(ref)
+	if m == nil {
+		return "", false, whenEvalError(fmt.Sprintf("unknown reference %q", ref))
+	}
+	stageName, taskName, key := m[1], m[2], m[3]
+	stage, err := o.stage(ctx, stageName)
+	if err != nil {
+		return "", false, err
+	}
+	if stage == nil {
+		return "", false, whenEvalError(fmt.Sprintf("%s: stage %q not found in mission %q", ref, stageName, o.mission))
+	}
+	if !isStageFinished(stage.Status.Phase) {
+		return "", false, nil
+	}
+	isPhase := key == ""
+
+	if taskName == "" {
+		if isPhase {
+			return string(stage.Status.Phase), true, nil
+		}
+		tasks, err := o.stageTasks(ctx, stage)
+		if err != nil {
+			return "", false, err
+		}
+		for _, task := range tasks {
+			if v, ok := task.Status.Outputs[key]; ok {
+				return v, true, nil
+			}
+		}
+		return "", true, nil
+	}
+
+	known := false
+	for _, tmpl := range stageFlightTaskTemplates(stage) {
+		if tmpl.Name == taskName {
+			known = true
+			break
+		}
+	}
+	if !known {
+		return "", false, whenEvalError(fmt.Sprintf("%s: task %q not found in stage %q", ref, taskName, stageName))
+	}
+	if isPhase {
+		// Skipped tasks only exist in the stage status.
+		for _, status := range stage.Status.FlightTasksStatus {
+			if status.Name == taskName {
+				return string(status.Phase), true, nil
+			}
+		}
+		return "", true, nil
+	}
+	tasks, err := o.stageTasks(ctx, stage)
+	if err != nil {
+		return "", false, err
+	}
+	for _, task := range tasks {
+		if task.Labels["task-name"] == taskName {
+			return task.Status.Outputs[key], true, nil
+		}
+	}
+	return "", true, nil
+}
+
+// stageTasks lists the FlightTasks of a stage in task order.
+func (o *outputResolver) stageTasks(ctx context.Context, stage *airforcev1alpha1.MissionStage) ([]airforcev1alpha1.FlightTask, error) {
+	if tasks, ok := o.tasks[stage.Name]; ok {
+		return tasks, nil
+	}
+	var taskList airforcev1alpha1.FlightTaskList
+	if err := o.reader.List(ctx, &taskList, &client.ListOptions{
+		Namespace: o.namespace,
+		LabelSelector: labels.SelectorFromSet(labels.Set{
+			"mission": o.mission,
+			"stage":   stage.Name,
+		}),
+	}); err != nil {
+		return nil, err
+	}
+	tasks := taskList.Items
+	sort.SliceStable(tasks, func(i, j int) bool {
+		li, _ := strconv.Atoi(tasks[i].Labels["task-index"])
+		lj, _ := strconv.Atoi(tasks[j].Labels["task-index"])
+		return li < lj
+	})
+	o.tasks[stage.Name] = tasks
+	return tasks, nil
+}
+
+func (o *outputResolver) stage(ctx context.Context, stageName string) (*airforcev1alpha1.MissionStage, error) {
+	if stage, ok := o.stages[stageName]; ok {
+		return stage, nil
+	}
+	var stageList airforcev1alpha1.MissionStageList
+	if err := o.reader.List(ctx, &stageList, &client.ListOptions{
+		Namespace: o.namespace,
+		LabelSelector: labels.SelectorFromSet(labels.Set{
+			"mission":    o.mission,
+			"stage-name": stageName,
+		}),
+	}); err != nil {
+		return nil, err
+	}
+	var stage *airforcev1alpha1.MissionStage
+	if len(stageList.Items) != 0 {
+		stage = &stageList.Items[0]
+	}
+	o.stages[stageName] = stage
+	return stage, nil
+}
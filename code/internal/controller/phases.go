@@ -0,0 +1,163 @@
+/*
+Copyright 2026 yydashuai.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"k8s.io/apimachinery/pkg/api/equality"
+	apimeta "k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	airforcev1alpha1 "github.com/yydashuai/mission-system/api/v1alpha1"
+)
+
+// conditionPhaseOverrun is True once a task phase has lasted longer than its declared duration.
+const conditionPhaseOverrun = "PhaseOverrun"
+
+type declaredPhase struct {
+	name string
+	// duration is 0 for phases without one; they only end when telemetry reports a later phase.
+	duration time.Duration
+}
+
+// declaredPhases returns taskParams.phases, naming unnamed phases like the simulation does.
+func declaredPhases(task *airforcev1alpha1.FlightTask) []declaredPhase {
+	if task.Spec.TaskParams == nil {
+		return nil
+	}
+	phases := make([]declaredPhase, 0, len(task.Spec.TaskParams.Phases))
+	for i, p := range task.Spec.TaskParams.Phases {
+		name := p.Name
+		if name == "" {
+			name = "phase-" + strconv.Itoa(i+1)
+		}
+		var d time.Duration
+		if p.Duration != nil && p.Duration.Duration > 0 {
+			d = p.Duration.Duration
+		}
+		phases = append(phases, declaredPhase{name: name, duration: d})
+	}
+	return phases
+}
+
+// syncPhaseProgress advances ExecutionStatus.CurrentPhase through the declared phases of a
+// task whose pod started at started: to the phase reported by telemetry if there is one,
+// else to the phase the elapsed time falls in. It records phase start and end times, ends
+// the current phase when the task is finished, and flags phases that overrun their
+// duration. It returns whether the status changed and the phases newly flagged as overrun.
+func syncPhaseProgress(task *airforcev1alpha1.FlightTask, started, now time.Time, finished bool) (bool, []string) {
+	phases := declaredPhases(task)
+	if len(phases) == 0 {
+		return false, nil
+	}
+	before := task.Status.ExecutionStatus.DeepCopy()
+	exec := task.Status.ExecutionStatus
+	if exec == nil {
+		exec = &airforcev1alpha1.ExecutionStatus{}
+		task.Status.ExecutionStatus = exec
+	}
+
+	// 遥测上报的阶段优先，否则按各阶段时长从 Pod 启动时间推算
+	current, reported := -1, false
+	for i, p := range phases {
+		if exec.ReportedPhase != "" && p.name == exec.ReportedPhase {
+			current, reported = i, true
+			break
+		}
+	}
+	starts := make([]time.Time, len(phases))
+	offset := started
+	for i, p := range phases {
+		starts[i] = offset
+		if !reported && current < 0 && (p.duration == 0 || now.Before(offset.Add(p.duration)) || i == len(phases)-1) {
+			current = i
+		}
+		offset = offset.Add(p.duration)
+	}
+	// 已记录的阶段不会回退
+	if last := len(exec.Phases) - 1; last >= 0 {
+		for i, p := range phases {
+			if p.name == exec.Phases[last].Name && i > current {
+				current = i
+			}
+		}
+	}
+
+	recorded := map[string]bool{}
+	for _, rec := range exec.Phases {
+		recorded[rec.Name] = true
+	}
+	for i := 0; i <= current; i++ {
+		if recorded[phases[i].name] {
+			continue
+		}
+		start := now
+		if !reported && (i == 0 || phases[i-1].duration > 0) && starts[i].Before(now) {
+			start = starts[i]
+		}
+		exec.Phases = append(exec.Phases, airforcev1alpha1.PhaseStatus{Name: phases[i].name, StartTime: &metav1.Time{Time: start}})
+	}
+	records := map[string]*airforcev1alpha1.PhaseStatus{}
+	for i := range exec.Phases {
+		records[exec.Phases[i].Name] = &exec.Phases[i]
+	}
+	for i := 0; i < current; i++ {
+		if rec := records[phases[i].name]; rec.EndTime == nil {
+			rec.EndTime = records[phases[i+1].name].StartTime.DeepCopy()
+		}
+	}
+	if rec := records[phases[current].name]; finished && rec.EndTime == nil {
+		rec.EndTime = &metav1.Time{Time: now}
+	}
+	exec.CurrentPhase = phases[current].name
+
+	var newlyOverrun, overrun []string
+	for i := 0; i <= current; i++ {
+		rec := records[phases[i].name]
+		if d := phases[i].duration; d > 0 && !rec.Overrun && rec.StartTime != nil {
+			end := now
+			if rec.EndTime != nil {
+				end = rec.EndTime.Time
+			}
+			if end.Sub(rec.StartTime.Time) > d {
+				rec.Overrun = true
+				newlyOverrun = append(newlyOverrun, phases[i].name)
+			}
+		}
+		if rec.Overrun {
+			overrun = append(overrun, fmt.Sprintf("%s (%s)", phases[i].name, phases[i].duration))
+		}
+	}
+
+	cond := metav1.Condition{
+		Type:               conditionPhaseOverrun,
+		Status:             metav1.ConditionFalse,
+		Reason:             "WithinDuration",
+		Message:            "all phases are within their declared duration",
+		ObservedGeneration: task.Generation,
+	}
+	if len(overrun) != 0 {
+		cond.Status, cond.Reason = metav1.ConditionTrue, "DurationExceeded"
+		cond.Message = "phases exceeded their declared duration: " + strings.Join(overrun, ", ")
+	}
+	condChanged := apimeta.SetStatusCondition(&task.Status.Conditions, cond)
+	return condChanged || !equality.Semantic.DeepEqual(before, exec), newlyOverrun
+}
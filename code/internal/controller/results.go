@@ -0,0 +1,273 @@
+/*
+Copyright 2026 yydashuai.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	apimeta "k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	airforcev1alpha1 "github.com/yydashuai/mission-system/api/v1alpha1"
+)
+
+const (
+	// interfaceVolumeName is the emptyDir shared by the task container and weapon sidecars.
+	interfaceVolumeName = "weapon-interface"
+	interfaceMountPath  = "/interface"
+	// taskResultPath is where containers write their result. It is also set as the
+	// termination message path, so the kubelet copies the file into the container status.
+	taskResultPath = interfaceMountPath + "/result.json"
+
+	conditionResultCaptured = "ResultCaptured"
+)
+
+// taskResult is the JSON document the task container and weapon sidecars write to
+// their termination message path before exiting, e.g.
+//
+//	{"fuelRemaining": 35, "weaponsRemaining": {"pl-15": 1}, "outputs": {"bda": "destroyed"}}
+//
+// A weapon sidecar may report its own rounds left with "remaining". Weapon sidecars run as
+// native sidecars and should keep running until the kubelet stops them after the task
+// container exits, writing their result on SIGTERM. A weapon that exits earlier is
+// restarted by the kubelet like any native sidecar; the result of its last run that
+// reported one is kept.
+type taskResult struct {
+	FuelRemaining    *int32                     `json:"fuelRemaining,omitempty"`
+	WeaponsRemaining map[string]int32           `json:"weaponsRemaining,omitempty"`
+	Remaining        *int32                     `json:"remaining,omitempty"`
+	Outputs          map[string]json.RawMessage `json:"outputs,omitempty"`
+	Extra            map[string]json.RawMessage `json:"extra,omitempty"`
+}
+
+// ensureResultProtocol mounts the interface volume in every container and points the
+// termination message path of the containers and weapon sidecars that keep the default
+// at taskResultPath.
+func ensureResultProtocol(pod *corev1.Pod) {
+	ensureEmptyDirVolume(&pod.Spec, interfaceVolumeName)
+	ensureVolumeMountAllContainers(&pod.Spec, corev1.VolumeMount{Name: interfaceVolumeName, MountPath: interfaceMountPath})
+	var containers []*corev1.Container
+	for i := range pod.Spec.Containers {
+		containers = append(containers, &pod.Spec.Containers[i])
+	}
+	for i := range pod.Spec.InitContainers {
+		if weaponSidecarName(&pod.Spec.InitContainers[i]) != "" {
+			containers = append(containers, &pod.Spec.InitContainers[i])
+		}
+	}
+	for _, c := range containers {
+		if c.TerminationMessagePath == "" || c.TerminationMessagePath == corev1.TerminationMessagePathDefault {
+			c.TerminationMessagePath = taskResultPath
+		}
+	}
+}
+
+// taskContainerName returns the container whose result feeds ExecutionStatus:
+// the container named "task", or else the first container that is not a weapon sidecar.
+func taskContainerName(pod *corev1.Pod) string {
+	for i := range pod.Spec.Containers {
+		if pod.Spec.Containers[i].Name == "task" {
+			return "task"
+		}
+	}
+	for i := range pod.Spec.Containers {
+		if weaponSidecarName(&pod.Spec.Containers[i]) == "" {
+			return pod.Spec.Containers[i].Name
+		}
+	}
+	return ""
+}
+
+// weaponSidecarName returns the weapon a sidecar was injected for, or "" for other containers.
+func weaponSidecarName(c *corev1.Container) string {
+	if !strings.HasPrefix(c.Name, "weapon-") {
+		return ""
+	}
+	for _, env := range c.Env {
+		if env.Name == "WEAPON_NAME" {
+			return env.Value
+		}
+	}
+	return ""
+}
+
+// weaponSidecars returns the weapon sidecars of a pod: its restartable init containers,
+// and the regular containers of pods created before weapons ran as native sidecars.
+func weaponSidecars(pod *corev1.Pod) []*corev1.Container {
+	var sidecars []*corev1.Container
+	for i := range pod.Spec.InitContainers {
+		if c := &pod.Spec.InitContainers[i]; weaponSidecarName(c) != "" {
+			sidecars = append(sidecars, c)
+		}
+	}
+	for i := range pod.Spec.Containers {
+		if c := &pod.Spec.Containers[i]; weaponSidecarName(c) != "" {
+			sidecars = append(sidecars, c)
+		}
+	}
+	return sidecars
+}
+
+// captureTaskResult parses the termination messages of a finished pod into the
+// task's ExecutionStatus and Outputs. It runs once per pod; the ResultCaptured
+// condition records the outcome. Returns true if the task status changed.
+func captureTaskResult(task *airforcev1alpha1.FlightTask, pod *corev1.Pod) bool {
+	if pod.Status.Phase != corev1.PodSucceeded && pod.Status.Phase != corev1.PodFailed {
+		return false
+	}
+	if cond := apimeta.FindStatusCondition(task.Status.Conditions, conditionResultCaptured); cond != nil &&
+		strings.HasPrefix(cond.Message, resultMessagePrefix(pod)) {
+		return false
+	}
+
+	taskContainer := taskContainerName(pod)
+	weapons := map[string]string{}
+	for _, c := range weaponSidecars(pod) {
+		weapons[c.Name] = weaponSidecarName(c)
+	}
+
+	exec := task.Status.ExecutionStatus.DeepCopy()
+	if exec == nil {
+		exec = &airforcev1alpha1.ExecutionStatus{}
+	}
+	outputs := map[string]string{}
+	for k, v := range task.Status.Outputs {
+		outputs[k] = v
+	}
+
+	var captured, invalid []string
+	// Apply the task container first so weapon sidecars, which know their own
+	// inventory, win on conflicting weaponsRemaining entries.
+	statuses := append(append([]corev1.ContainerStatus{}, pod.Status.ContainerStatuses...), pod.Status.InitContainerStatuses...)
+	sort.SliceStable(statuses, func(i, j int) bool {
+		return statuses[i].Name == taskContainer && statuses[j].Name != taskContainer
+	})
+	for _, cs := range statuses {
+		weapon, isWeapon := weapons[cs.Name]
+		if cs.Name != taskContainer && !isWeapon {
+			continue
+		}
+		msg := terminationMessage(&cs)
+		if msg == "" {
+			continue
+		}
+		var result taskResult
+		if err := json.Unmarshal([]byte(msg), &result); err != nil {
+			invalid = append(invalid, fmt.Sprintf("%s: %v", cs.Name, err))
+			continue
+		}
+		prefix := ""
+		if isWeapon {
+			prefix = weapon + "."
+		}
+		applyTaskResult(exec, outputs, &result, prefix, weapon)
+		captured = append(captured, cs.Name)
+	}
+
+	cond := metav1.Condition{
+		Type:               conditionResultCaptured,
+		ObservedGeneration: task.Generation,
+	}
+	switch {
+	case len(invalid) != 0:
+		cond.Status = metav1.ConditionFalse
+		cond.Reason = "InvalidResult"
+		cond.Message = resultMessagePrefix(pod) + "invalid result from " + strings.Join(invalid, "; ")
+	case len(captured) == 0:
+		cond.Status = metav1.ConditionFalse
+		cond.Reason = "NoResult"
+		cond.Message = resultMessagePrefix(pod) + "no container reported a result"
+	default:
+		cond.Status = metav1.ConditionTrue
+		cond.Reason = "Captured"
+		cond.Message = resultMessagePrefix(pod) + "result captured from " + strings.Join(captured, ", ")
+	}
+	apimeta.SetStatusCondition(&task.Status.Conditions, cond)
+
+	if len(captured) != 0 {
+		task.Status.ExecutionStatus = exec
+		if len(outputs) != 0 {
+			task.Status.Outputs = outputs
+		}
+	}
+	return true
+}
+
+// terminationMessage returns the termination message of the current run of a container,
+// else that of its previous run: a weapon sidecar that exited before the task container
+// was restarted, and the run stopped with the pod may not have reported anything.
+func terminationMessage(cs *corev1.ContainerStatus) string {
+	if t := cs.State.Terminated; t != nil {
+		if msg := strings.TrimSpace(t.Message); msg != "" {
+			return msg
+		}
+	}
+	if t := cs.LastTerminationState.Terminated; t != nil {
+		return strings.TrimSpace(t.Message)
+	}
+	return ""
+}
+
+// resultMessagePrefix ties the ResultCaptured condition to one pod so a replacement pod is parsed again.
+func resultMessagePrefix(pod *corev1.Pod) string {
+	return fmt.Sprintf("pod %s: ", pod.UID)
+}
+
+func applyTaskResult(exec *airforcev1alpha1.ExecutionStatus, outputs map[string]string, result *taskResult, prefix, weapon string) {
+	if result.FuelRemaining != nil && prefix == "" {
+		exec.FuelRemaining = *result.FuelRemaining
+	}
+	if len(result.WeaponsRemaining) != 0 && exec.WeaponsRemaining == nil {
+		exec.WeaponsRemaining = map[string]int32{}
+	}
+	for k, v := range result.WeaponsRemaining {
+		exec.WeaponsRemaining[k] = v
+	}
+	if result.Remaining != nil && weapon != "" {
+		if exec.WeaponsRemaining == nil {
+			exec.WeaponsRemaining = map[string]int32{}
+		}
+		exec.WeaponsRemaining[weapon] = *result.Remaining
+	}
+	for k, v := range result.Outputs {
+		outputs[prefix+k] = resultValueString(v)
+	}
+	if len(result.Extra) != 0 && exec.Extra == nil {
+		exec.Extra = map[string]string{}
+	}
+	for k, v := range result.Extra {
+		exec.Extra[prefix+k] = resultValueString(v)
+	}
+}
+
+// resultValueString keeps JSON strings as-is and renders any other JSON value compactly.
+func resultValueString(raw json.RawMessage) string {
+	var s string
+	if err := json.Unmarshal(raw, &s); err == nil {
+		return s
+	}
+	var buf bytes.Buffer
+	if err := json.Compact(&buf, raw); err != nil {
+		return string(raw)
+	}
+	return buf.String()
+}
@@ -0,0 +1,272 @@
+/*
+Copyright 2026 yydashuai.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	apimeta "k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	airforcev1alpha1 "github.com/yydashuai/mission-system/api/v1alpha1"
+	"github.com/yydashuai/mission-system/internal/geo"
+	"github.com/yydashuai/mission-system/internal/units"
+)
+
+// conditionRouteInRange reports whether every leg of the task route, including the leg from
+// the aircraft node to the first waypoint, is within the range of the aircraft.
+const conditionRouteInRange = "RouteInRange"
+
+// aircraftRangeLabel holds the range of an aircraft node, e.g. "1800km".
+const aircraftRangeLabel = "aircraft.mil/range"
+
+// waypointSetMissingError reports a waypointSetRef that names no WaypointSet. The task waits
+// for the WaypointSet to be created instead of failing.
+type waypointSetMissingError struct {
+	name string
+}
+
+func (e *waypointSetMissingError) Error() string {
+	return fmt.Sprintf("waypointSet %q not found", e.name)
+}
+
+// resolveTaskRoute looks up the waypoints named by the task phases, checks that they lie
+// inside the operation area and computes the leg distances and per-phase ETAs. Tasks whose
+// phases name waypoints without any waypoint definitions keep the names as opaque labels
+// and get no route. A missing WaypointSet is reported as *waypointSetMissingError and
+// apiserver errors as *transientError; every other error is an invalid task spec.
+func (r *FlightTaskReconciler) resolveTaskRoute(ctx context.Context, task *airforcev1alpha1.FlightTask, area geo.Area) (*airforcev1alpha1.TaskRoute, error) {
+	params := task.Spec.TaskParams
+	if params == nil {
+		return nil, nil
+	}
+	named := false
+	for _, phase := range params.Phases {
+		if len(phase.Waypoints) != 0 {
+			named = true
+			break
+		}
+	}
+	if !named || (len(params.Waypoints) == 0 && params.WaypointSetRef == "") {
+		return nil, nil
+	}
+
+	waypoints := map[string]airforcev1alpha1.GeoCoordinates{}
+	if params.WaypointSetRef != "" {
+		var set airforcev1alpha1.WaypointSet
+		if err := r.Get(ctx, client.ObjectKey{Namespace: task.Namespace, Name: params.WaypointSetRef}, &set); err != nil {
+			if apierrors.IsNotFound(err) {
+				return nil, &waypointSetMissingError{name: params.WaypointSetRef}
+			}
+			return nil, &transientError{err: fmt.Errorf("failed to get waypointSet %q: %w", params.WaypointSetRef, err)}
+		}
+		for _, wp := range set.Spec.Waypoints {
+			waypoints[wp.Name] = wp.Coordinates
+		}
+	}
+	for _, wp := range params.Waypoints {
+		waypoints[wp.Name] = wp.Coordinates
+	}
+
+	speedKmh := 0.0
+	if strings.TrimSpace(params.Speed) != "" {
+		v, err := units.ParseSpeed(params.Speed)
+		if err != nil {
+			return nil, fmt.Errorf("invalid taskParams.speed: %w", err)
+		}
+		speedKmh = v
+	}
+
+	route := &airforcev1alpha1.TaskRoute{}
+	var prev *geo.Point
+	total := 0.0
+	for _, phase := range params.Phases {
+		if len(phase.Waypoints) == 0 {
+			continue
+		}
+		phaseKm := 0.0
+		for _, name := range phase.Waypoints {
+			coords, ok := waypoints[name]
+			if !ok {
+				return nil, fmt.Errorf("phase %q: unknown waypoint %q", phase.Name, name)
+			}
+			p, err := geoPoint(&coords)
+			if err != nil {
+				return nil, fmt.Errorf("waypoint %q: %w", name, err)
+			}
+			if area != nil && !area.Contains(p) {
+				return nil, fmt.Errorf("waypoint %q (%s) is outside the operation area", name, p)
+			}
+			leg := 0.0
+			if prev != nil {
+				leg = geo.Distance(*prev, p)
+			}
+			prev = &p
+			phaseKm += leg
+			route.Waypoints = append(route.Waypoints, airforcev1alpha1.RouteWaypoint{
+				Name:  name,
+				Phase: phase.Name,
+				Coordinates: airforcev1alpha1.GeoCoordinates{
+					Latitude:  decimalDegrees(coords.Latitude, p.Lat),
+					Longitude: decimalDegrees(coords.Longitude, p.Lon),
+				},
+				LegKm: int32(math.Round(leg)),
+			})
+		}
+		total += phaseKm
+		pr := airforcev1alpha1.PhaseRoute{Name: phase.Name, DistanceKm: int32(math.Round(phaseKm))}
+		if speedKmh > 0 {
+			pr.FlightTime = &metav1.Duration{Duration: flightTime(phaseKm, speedKmh)}
+			pr.ETA = &metav1.Duration{Duration: flightTime(total, speedKmh)}
+		}
+		route.Phases = append(route.Phases, pr)
+	}
+	route.DistanceKm = int32(math.Round(total))
+	return route, nil
+}
+
+// flightTasksForWaypointSet maps a WaypointSet to the unfinished FlightTasks that reference
+// it, so that tasks waiting for the set resume when it is created or changed.
+func (r *FlightTaskReconciler) flightTasksForWaypointSet(ctx context.Context, obj client.Object) []reconcile.Request {
+	var tasks airforcev1alpha1.FlightTaskList
+	if err := r.List(ctx, &tasks, client.InNamespace(obj.GetNamespace())); err != nil {
+		return nil
+	}
+	var requests []reconcile.Request
+	for i := range tasks.Items {
+		task := &tasks.Items[i]
+		if isFlightTaskFinished(task.Status.Phase) || task.Spec.TaskParams == nil || task.Spec.TaskParams.WaypointSetRef != obj.GetName() {
+			continue
+		}
+		requests = append(requests, reconcile.Request{NamespacedName: types.NamespacedName{
+			Namespace: task.Namespace,
+			Name:      task.Name,
+		}})
+	}
+	return requests
+}
+
+// flightTime is the time to fly a distance at a speed, rounded to the second.
+func flightTime(distanceKm, speedKmh float64) time.Duration {
+	return time.Duration(distanceKm / speedKmh * float64(time.Hour)).Round(time.Second)
+}
+
+// injectRouteEnv gives the task container its resolved route as JSON in TASK_ROUTE.
+func injectRouteEnv(pod *corev1.Pod, route *airforcev1alpha1.TaskRoute) error {
+	if route == nil {
+		return nil
+	}
+	data, err := json.Marshal(route)
+	if err != nil {
+		return err
+	}
+	injectTaskEnv(pod, []corev1.EnvVar{{Name: "TASK_ROUTE", Value: string(data)}})
+	return nil
+}
+
+// routeOutOfRange reports a task that was failed by the route range check.
+func routeOutOfRange(task *airforcev1alpha1.FlightTask) bool {
+	cond := apimeta.FindStatusCondition(task.Status.Conditions, conditionRouteInRange)
+	return task.Status.Phase == airforcev1alpha1.FlightTaskPhaseFailed &&
+		cond != nil && cond.Status == metav1.ConditionFalse
+}
+
+// checkRouteRange compares every leg of the task route with the range of the aircraft node
+// the task was scheduled on. The Mission's range check policy applies as for weapons.
+func (r *FlightTaskReconciler) checkRouteRange(ctx context.Context, task *airforcev1alpha1.FlightTask, nodeName string) (metav1.Condition, airforcev1alpha1.RangeCheckPolicy, error) {
+	cond := metav1.Condition{
+		Type:               conditionRouteInRange,
+		Status:             metav1.ConditionUnknown,
+		ObservedGeneration: task.Generation,
+	}
+
+	mission, err := r.taskMission(ctx, task)
+	if err != nil {
+		return cond, "", err
+	}
+	policy := rangeCheckPolicy(mission)
+	if policy == airforcev1alpha1.RangeCheckIgnore {
+		cond.Reason, cond.Message = "CheckDisabled", "range check is disabled for this mission"
+		return cond, policy, nil
+	}
+	route := task.Status.Route
+	if route == nil || len(route.Waypoints) == 0 {
+		cond.Reason, cond.Message = "NoRoute", "task has no resolved route"
+		return cond, policy, nil
+	}
+
+	var node corev1.Node
+	if err := r.Get(ctx, client.ObjectKey{Name: nodeName}, &node); err != nil {
+		if !apierrors.IsNotFound(err) {
+			return cond, policy, err
+		}
+	}
+	meters, err := units.ParseDistance(node.Labels[aircraftRangeLabel])
+	if err != nil {
+		cond.Reason, cond.Message = "NoAircraftRange", fmt.Sprintf("aircraft node %s has no valid %s label", nodeName, aircraftRangeLabel)
+		return cond, policy, nil
+	}
+	rangeKm := meters / 1000
+
+	from := nodeName
+	prev, ok := nodeLocation(&node)
+	if !ok {
+		from = ""
+	}
+	longest, longestLeg := 0.0, ""
+	var tooLong []string
+	for _, wp := range route.Waypoints {
+		p, err := geoPoint(&wp.Coordinates)
+		if err != nil {
+			continue
+		}
+		if from != "" {
+			leg := geo.Distance(prev, p)
+			name := fmt.Sprintf("%s -> %s", from, wp.Name)
+			if leg > rangeKm {
+				tooLong = append(tooLong, fmt.Sprintf("%s (%.0f km)", name, leg))
+			}
+			if leg > longest {
+				longest, longestLeg = leg, name
+			}
+		}
+		from, prev = wp.Name, p
+	}
+
+	if len(tooLong) != 0 {
+		cond.Status, cond.Reason = metav1.ConditionFalse, "LegTooLong"
+		cond.Message = fmt.Sprintf("route legs %s are beyond the %.0f km range of aircraft node %s",
+			strings.Join(tooLong, ", "), rangeKm, nodeName)
+		return cond, policy, nil
+	}
+	cond.Status, cond.Reason = metav1.ConditionTrue, "InRange"
+	cond.Message = fmt.Sprintf("all route legs are within the %.0f km range of aircraft node %s", rangeKm, nodeName)
+	if longestLeg != "" {
+		cond.Message += fmt.Sprintf("; longest leg %s is %.0f km", longestLeg, longest)
+	}
+	return cond, policy, nil
+}
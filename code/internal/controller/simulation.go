@@ -0,0 +1,440 @@
+/*
+Copyright 2026 yydashuai.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"sort"
+	"strconv"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	apimeta "k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	airforcev1alpha1 "github.com/yydashuai/mission-system/api/v1alpha1"
+)
+
+const (
+	// defaultSimulatedPhaseDuration is used for phases (or whole tasks) that do not declare a duration.
+	defaultSimulatedPhaseDuration = 5 * time.Minute
+
+	simulatedExecutionPhase = "execution"
+)
+
+type simulatedPhase struct {
+	name     string
+	duration time.Duration
+}
+
+// simulationConfig returns the simulation settings of the task's Mission, or nil when the task runs for real.
+func (r *FlightTaskReconciler) simulationConfig(ctx context.Context, task *airforcev1alpha1.FlightTask) (*airforcev1alpha1.SimulationConfig, error) {
+	return missionSimulation(ctx, r.Client, task.Namespace, task.Labels["mission"])
+}
+
+// missionSimulation returns the simulation settings of the named Mission, or nil when it runs for real
+// or does not exist. Other errors reading the Mission are returned: a rehearsal must not fall back to
+// a real run because the apiserver could not be reached.
+func missionSimulation(ctx context.Context, reader client.Reader, namespace, missionName string) (*airforcev1alpha1.SimulationConfig, error) {
+	if missionName == "" {
+		return nil, nil
+	}
+	var mission airforcev1alpha1.Mission
+	if err := reader.Get(ctx, client.ObjectKey{Namespace: namespace, Name: missionName}, &mission); err != nil {
+		if apierrors.IsNotFound(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get mission %q for simulation check: %w", missionName, err)
+	}
+	if mission.Spec.Config == nil || mission.Spec.Config.Simulation == nil || !mission.Spec.Config.Simulation.Enabled {
+		return nil, nil
+	}
+	return mission.Spec.Config.Simulation, nil
+}
+
+// simulatedTask reports a task that was assigned a simulated aircraft.
+func simulatedTask(task *airforcev1alpha1.FlightTask) bool {
+	return apimeta.FindStatusCondition(task.Status.Conditions, "Simulated") != nil
+}
+
+func (r *FlightTaskReconciler) reconcileSimulatedTask(ctx context.Context, task *airforcev1alpha1.FlightTask, sim *airforcev1alpha1.SimulationConfig) (ctrl.Result, error) {
+	switch task.Status.Phase {
+	case airforcev1alpha1.FlightTaskPhaseScheduled:
+		return r.assignSimulatedAircraft(ctx, task)
+	case airforcev1alpha1.FlightTaskPhaseRunning:
+		return r.advanceSimulatedTask(ctx, task, sim)
+	default:
+		return ctrl.Result{}, nil
+	}
+}
+
+func (r *FlightTaskReconciler) assignSimulatedAircraft(ctx context.Context, task *airforcev1alpha1.FlightTask) (ctrl.Result, error) {
+	logger := log.FromContext(ctx)
+
+	// Build the pod exactly like a real run so that spec validation, weapon compatibility
+	// and scheduling constraints behave the same, but never create it.
+	// buildPodForTask 会写入 status.route，补丁以构建前的状态为基准
+	base := task.DeepCopy()
+	pod, err := r.buildPodForTask(ctx, task, fmt.Sprintf("%s-pod", task.Name))
+	var transient *transientError
+	if errors.As(err, &transient) {
+		return ctrl.Result{}, err
+	}
+	var missingSet *waypointSetMissingError
+	if errors.As(err, &missingSet) {
+		// 与真实运行一样等待航路点集创建
+		return ctrl.Result{}, r.waitForWaypointSet(ctx, task, base, missingSet)
+	}
+	if err != nil {
+		patch := client.MergeFrom(base)
+		task.Status.Phase = airforcev1alpha1.FlightTaskPhaseFailed
+		apimeta.SetStatusCondition(&task.Status.Conditions, metav1.Condition{
+			Type:               "Simulated",
+			Status:             metav1.ConditionFalse,
+			Reason:             "InvalidSpec",
+			Message:            err.Error(),
+			ObservedGeneration: task.Generation,
+		})
+		return ctrl.Result{}, r.Status().Patch(ctx, task, patch)
+	}
+
+	var nodeList corev1.NodeList
+	if err := r.List(ctx, &nodeList); err != nil {
+		return ctrl.Result{}, err
+	}
+	node := selectSimulatedAircraft(nodeList.Items, pod)
+
+	patch := client.MergeFrom(base)
+	if task.Status.SchedulingInfo == nil {
+		task.Status.SchedulingInfo = &airforcev1alpha1.SchedulingInfo{}
+	}
+	if node == nil {
+		task.Status.SchedulingInfo.SchedulingAttempts++
+		setConditionWithTime(&task.Status.Conditions, metav1.Condition{
+			Type:               "NoFailedScheduling",
+			Status:             metav1.ConditionFalse,
+			Reason:             "SimulatedUnschedulable",
+			Message:            "no aircraft node matches the task requirements",
+			ObservedGeneration: task.Generation,
+		}, metav1.Now())
+		if err := r.Status().Patch(ctx, task, patch); err != nil {
+			return ctrl.Result{}, err
+		}
+		return ctrl.Result{RequeueAfter: 10 * time.Second}, nil
+	}
+
+	now := metav1.Now()
+	phases := simulatedPhases(task)
+	task.Status.Phase = airforcev1alpha1.FlightTaskPhaseRunning
+	task.Status.SchedulingInfo.AssignedNode = node.Name
+	task.Status.SchedulingInfo.AssignedTime = &now
+	if task.Status.SchedulingInfo.SchedulingAttempts == 0 {
+		task.Status.SchedulingInfo.SchedulingAttempts = 1
+	}
+	if task.Status.ExecutionStatus == nil {
+		task.Status.ExecutionStatus = &airforcev1alpha1.ExecutionStatus{}
+	}
+	task.Status.ExecutionStatus.CurrentPhase = phases[0].name
+	setConditionWithTime(&task.Status.Conditions, metav1.Condition{
+		Type:               "NoFailedScheduling",
+		Status:             metav1.ConditionTrue,
+		Reason:             "NoFailedSchedulingEvents",
+		Message:            "No FailedScheduling events observed",
+		ObservedGeneration: task.Generation,
+	}, now)
+	setConditionWithTime(&task.Status.Conditions, metav1.Condition{
+		Type:               "Simulated",
+		Status:             metav1.ConditionTrue,
+		Reason:             "Running",
+		Message:            fmt.Sprintf("simulated aircraft %s assigned", node.Name),
+		ObservedGeneration: task.Generation,
+	}, now)
+	if err := r.Status().Patch(ctx, task, patch); err != nil {
+		return ctrl.Result{}, err
+	}
+	logger.Info("assigned simulated aircraft", "flightTask", task.Name, "node", node.Name)
+	r.observeFlightTaskScheduled(ctx, task, time.Time{}, now.Time)
+	recordNormal(r.Recorder, task, eventReasonSimulatedAircraftAssigned, "Assigned simulated aircraft %s", node.Name)
+	return ctrl.Result{Requeue: true}, nil
+}
+
+func (r *FlightTaskReconciler) advanceSimulatedTask(ctx context.Context, task *airforcev1alpha1.FlightTask, sim *airforcev1alpha1.SimulationConfig) (ctrl.Result, error) {
+	if task.Status.SchedulingInfo == nil || task.Status.SchedulingInfo.AssignedTime == nil {
+		return ctrl.Result{}, nil
+	}
+
+	scale := time.Duration(sim.TimeScale)
+	if scale < 1 {
+		scale = 1
+	}
+	elapsed := time.Since(task.Status.SchedulingInfo.AssignedTime.Time) * scale
+
+	phases := simulatedPhases(task)
+	var total time.Duration
+	for _, p := range phases {
+		total += p.duration
+	}
+	end := total
+	failAt, fails := simulatedFailurePoint(sim, task, total)
+	if fails {
+		end = failAt
+	}
+
+	current := elapsed
+	if current > end {
+		current = end
+	}
+	phaseName, nextBoundary := simulatedPhaseAt(phases, current)
+
+	patch := client.MergeFrom(task.DeepCopy())
+	if task.Status.ExecutionStatus == nil {
+		task.Status.ExecutionStatus = &airforcev1alpha1.ExecutionStatus{}
+	}
+	changed := task.Status.ExecutionStatus.CurrentPhase != phaseName
+	task.Status.ExecutionStatus.CurrentPhase = phaseName
+
+	if elapsed >= end {
+		cond := metav1.Condition{
+			Type:               "Simulated",
+			Status:             metav1.ConditionTrue,
+			Reason:             "Completed",
+			Message:            "simulated execution completed",
+			ObservedGeneration: task.Generation,
+		}
+		task.Status.Phase = airforcev1alpha1.FlightTaskPhaseSucceeded
+		if fails {
+			task.Status.Phase = airforcev1alpha1.FlightTaskPhaseFailed
+			cond.Status = metav1.ConditionFalse
+			cond.Reason = "SimulatedFailure"
+			cond.Message = fmt.Sprintf("simulated failure during phase %q", phaseName)
+		}
+		setConditionWithTime(&task.Status.Conditions, cond, metav1.Now())
+		if err := r.Status().Patch(ctx, task, patch); err != nil {
+			return ctrl.Result{}, err
+		}
+		observeFlightTaskFinished(task, task.Status.Phase)
+		return ctrl.Result{}, nil
+	}
+
+	if changed {
+		if err := r.Status().Patch(ctx, task, patch); err != nil {
+			return ctrl.Result{}, err
+		}
+	}
+
+	if nextBoundary > end {
+		nextBoundary = end
+	}
+	requeue := (nextBoundary - elapsed) / scale
+	if requeue > 5*time.Second {
+		requeue = 5 * time.Second
+	}
+	if requeue < time.Second {
+		requeue = time.Second
+	}
+	return ctrl.Result{RequeueAfter: requeue}, nil
+}
+
+// simulatedPhases returns the declared TaskPhases with defaults applied; a task without phases
+// is simulated as a single phase lasting MissionDuration.
+func simulatedPhases(task *airforcev1alpha1.FlightTask) []simulatedPhase {
+	params := task.Spec.TaskParams
+	if params == nil || len(params.Phases) == 0 {
+		d := defaultSimulatedPhaseDuration
+		if params != nil && params.MissionDuration != nil && params.MissionDuration.Duration > 0 {
+			d = params.MissionDuration.Duration
+		}
+		return []simulatedPhase{{name: simulatedExecutionPhase, duration: d}}
+	}
+
+	phases := make([]simulatedPhase, 0, len(params.Phases))
+	for i, p := range params.Phases {
+		name := p.Name
+		if name == "" {
+			name = "phase-" + strconv.Itoa(i+1)
+		}
+		d := defaultSimulatedPhaseDuration
+		if p.Duration != nil && p.Duration.Duration > 0 {
+			d = p.Duration.Duration
+		}
+		phases = append(phases, simulatedPhase{name: name, duration: d})
+	}
+	return phases
+}
+
+// simulatedPhaseAt returns the phase active at the given simulated offset and the offset at which it ends.
+func simulatedPhaseAt(phases []simulatedPhase, offset time.Duration) (string, time.Duration) {
+	var boundary time.Duration
+	for _, p := range phases {
+		boundary += p.duration
+		if offset < boundary {
+			return p.name, boundary
+		}
+	}
+	return phases[len(phases)-1].name, boundary
+}
+
+// simulatedFailurePoint decides deterministically (per Mission seed and task UID) whether the task
+// fails and, if so, at which simulated offset.
+func simulatedFailurePoint(sim *airforcev1alpha1.SimulationConfig, task *airforcev1alpha1.FlightTask, total time.Duration) (time.Duration, bool) {
+	if sim.FailureRate <= 0 {
+		return 0, false
+	}
+	h := fnv.New64a()
+	var seed [8]byte
+	binary.LittleEndian.PutUint64(seed[:], uint64(sim.Seed))
+	_, _ = h.Write(seed[:])
+	_, _ = h.Write([]byte(task.UID))
+	_, _ = h.Write([]byte(task.Name))
+	sum := h.Sum64()
+
+	if int32(sum%100) >= sim.FailureRate {
+		return 0, false
+	}
+	fraction := float64((sum>>32)%1000) / 1000
+	return time.Duration(float64(total) * fraction), true
+}
+
+// selectSimulatedAircraft picks the node the scheduler would most likely bind the pod to:
+// nodes must satisfy the nodeSelector and required node affinity, and are ranked by the
+// sum of matching preferred term weights (which includes distance-based preferences).
+func selectSimulatedAircraft(nodes []corev1.Node, pod *corev1.Pod) *corev1.Node {
+	type candidate struct {
+		node  *corev1.Node
+		score int32
+	}
+	var candidates []candidate
+	for i := range nodes {
+		node := &nodes[i]
+		if node.Spec.Unschedulable || !nodeMatchesPod(node, pod) {
+			continue
+		}
+		candidates = append(candidates, candidate{node: node, score: preferredSchedulingScore(node, pod)})
+	}
+	if len(candidates) == 0 {
+		return nil
+	}
+	sort.Slice(candidates, func(i, j int) bool {
+		if candidates[i].score != candidates[j].score {
+			return candidates[i].score > candidates[j].score
+		}
+		return candidates[i].node.Name < candidates[j].node.Name
+	})
+	return candidates[0].node
+}
+
+func nodeMatchesPod(node *corev1.Node, pod *corev1.Pod) bool {
+	for k, v := range pod.Spec.NodeSelector {
+		if node.Labels[k] != v {
+			return false
+		}
+	}
+	if pod.Spec.Affinity == nil || pod.Spec.Affinity.NodeAffinity == nil ||
+		pod.Spec.Affinity.NodeAffinity.RequiredDuringSchedulingIgnoredDuringExecution == nil {
+		return true
+	}
+	terms := pod.Spec.Affinity.NodeAffinity.RequiredDuringSchedulingIgnoredDuringExecution.NodeSelectorTerms
+	if len(terms) == 0 {
+		return true
+	}
+	// Terms are ORed, expressions within a term are ANDed.
+	for _, term := range terms {
+		if nodeSelectorTermMatches(term, node) {
+			return true
+		}
+	}
+	return false
+}
+
+func preferredSchedulingScore(node *corev1.Node, pod *corev1.Pod) int32 {
+	if pod.Spec.Affinity == nil || pod.Spec.Affinity.NodeAffinity == nil {
+		return 0
+	}
+	var score int32
+	for _, term := range pod.Spec.Affinity.NodeAffinity.PreferredDuringSchedulingIgnoredDuringExecution {
+		if nodeSelectorTermMatches(term.Preference, node) {
+			score += term.Weight
+		}
+	}
+	return score
+}
+
+func nodeSelectorTermMatches(term corev1.NodeSelectorTerm, node *corev1.Node) bool {
+	if len(term.MatchExpressions) == 0 && len(term.MatchFields) == 0 {
+		return false
+	}
+	for _, req := range term.MatchExpressions {
+		if !nodeSelectorRequirementMatches(req, node.Labels) {
+			return false
+		}
+	}
+	for _, req := range term.MatchFields {
+		if req.Key != "metadata.name" || !nodeSelectorRequirementMatches(req, map[string]string{req.Key: node.Name}) {
+			return false
+		}
+	}
+	return true
+}
+
+func nodeSelectorRequirementMatches(req corev1.NodeSelectorRequirement, nodeLabels map[string]string) bool {
+	value, ok := nodeLabels[req.Key]
+	switch req.Operator {
+	case corev1.NodeSelectorOpIn:
+		return ok && containsExact(req.Values, value)
+	case corev1.NodeSelectorOpNotIn:
+		return !ok || !containsExact(req.Values, value)
+	case corev1.NodeSelectorOpExists:
+		return ok
+	case corev1.NodeSelectorOpDoesNotExist:
+		return !ok
+	case corev1.NodeSelectorOpGt, corev1.NodeSelectorOpLt:
+		if !ok || len(req.Values) != 1 {
+			return false
+		}
+		actual, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			return false
+		}
+		bound, err := strconv.ParseInt(req.Values[0], 10, 64)
+		if err != nil {
+			return false
+		}
+		if req.Operator == corev1.NodeSelectorOpGt {
+			return actual > bound
+		}
+		return actual < bound
+	default:
+		return false
+	}
+}
+
+func containsExact(values []string, needle string) bool {
+	for _, v := range values {
+		if v == needle {
+			return true
+		}
+	}
+	return false
+}
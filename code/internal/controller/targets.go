@@ -0,0 +1,176 @@
+/*
+Copyright 2026 yydashuai.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	airforcev1alpha1 "github.com/yydashuai/mission-system/api/v1alpha1"
+)
+
+// unknownTargetError reports a targetRef that does not name a target in the Mission objective.
+type unknownTargetError struct {
+	mission   string
+	targetRef string
+}
+
+func (e *unknownTargetError) Error() string {
+	return fmt.Sprintf("target %q not found in objective of mission %q", e.targetRef, e.mission)
+}
+
+// resolveTaskTarget returns the objective target a task is flown against: the target
+// named by targetRef, else the legacy objective.targetCoordinates, else the
+// highest-priority entry of objective.targets. It returns nil if the Mission has no target.
+func resolveTaskTarget(mission *airforcev1alpha1.Mission, targetRef string) (*airforcev1alpha1.MissionTarget, error) {
+	objective := mission.Spec.Objective
+	if targetRef != "" {
+		if objective != nil {
+			for i := range objective.Targets {
+				if objective.Targets[i].Name == targetRef {
+					return &objective.Targets[i], nil
+				}
+			}
+		}
+		return nil, &unknownTargetError{mission: mission.Name, targetRef: targetRef}
+	}
+	if objective == nil {
+		return nil, nil
+	}
+	if objective.TargetCoordinates != nil {
+		return &airforcev1alpha1.MissionTarget{
+			Name:        objective.TargetArea,
+			Coordinates: objective.TargetCoordinates,
+			Priority:    mission.Spec.Priority,
+			Description: objective.TargetDescription,
+		}, nil
+	}
+	var primary *airforcev1alpha1.MissionTarget
+	for i := range objective.Targets {
+		t := &objective.Targets[i]
+		if primary == nil || priorityRank(t.Priority) > priorityRank(primary.Priority) {
+			primary = t
+		}
+	}
+	return primary, nil
+}
+
+func priorityRank(p airforcev1alpha1.MissionPriority) int {
+	switch p {
+	case airforcev1alpha1.MissionPriorityCritical:
+		return 4
+	case airforcev1alpha1.MissionPriorityHigh:
+		return 3
+	case airforcev1alpha1.MissionPriorityMedium:
+		return 2
+	case airforcev1alpha1.MissionPriorityLow:
+		return 1
+	default:
+		return 0
+	}
+}
+
+// taskTarget looks up the Mission of a task and resolves the task's target.
+func (r *FlightTaskReconciler) taskTarget(ctx context.Context, task *airforcev1alpha1.FlightTask) (*airforcev1alpha1.MissionTarget, error) {
+	missionName := task.Labels["mission"]
+	if missionName == "" {
+		return nil, nil
+	}
+	var mission airforcev1alpha1.Mission
+	if err := r.Get(ctx, client.ObjectKey{Namespace: task.Namespace, Name: missionName}, &mission); err != nil {
+		return nil, fmt.Errorf("failed to get mission %s: %w", missionName, err)
+	}
+	return resolveTaskTarget(&mission, task.Spec.TargetRef)
+}
+
+// taskMission returns the Mission a task belongs to, or nil for standalone tasks and
+// tasks whose Mission is gone.
+func (r *FlightTaskReconciler) taskMission(ctx context.Context, task *airforcev1alpha1.FlightTask) (*airforcev1alpha1.Mission, error) {
+	name := task.Labels["mission"]
+	if name == "" {
+		return nil, nil
+	}
+	var mission airforcev1alpha1.Mission
+	if err := r.Get(ctx, client.ObjectKey{Namespace: task.Namespace, Name: name}, &mission); err != nil {
+		if apierrors.IsNotFound(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &mission, nil
+}
+
+// injectTargetEnv gives the task container its target as TARGET_* variables,
+// keeping any value the pod template already sets.
+func injectTargetEnv(pod *corev1.Pod, target *airforcev1alpha1.MissionTarget) {
+	if target == nil {
+		return
+	}
+	env := []corev1.EnvVar{
+		{Name: "TARGET_NAME", Value: target.Name},
+		{Name: "TARGET_DESCRIPTION", Value: target.Description},
+	}
+	if p, err := geoPoint(target.Coordinates); err == nil {
+		// DMS 坐标统一转换为十进制度下发
+		env = append(env,
+			corev1.EnvVar{Name: "TARGET_LATITUDE", Value: decimalDegrees(target.Coordinates.Latitude, p.Lat)},
+			corev1.EnvVar{Name: "TARGET_LONGITUDE", Value: decimalDegrees(target.Coordinates.Longitude, p.Lon)},
+		)
+	}
+	injectTaskEnv(pod, env)
+}
+
+// injectTaskEnv adds the non-empty variables to the task container, keeping any value the
+// pod template already sets.
+func injectTaskEnv(pod *corev1.Pod, env []corev1.EnvVar) {
+	name := taskContainerName(pod)
+	for i := range pod.Spec.Containers {
+		c := &pod.Spec.Containers[i]
+		if c.Name != name {
+			continue
+		}
+		for _, e := range env {
+			if e.Value == "" || hasEnv(c.Env, e.Name) {
+				continue
+			}
+			c.Env = append(c.Env, e)
+		}
+	}
+}
+
+// decimalDegrees keeps a coordinate that is already decimal as written and formats DMS input.
+func decimalDegrees(raw string, value float64) string {
+	if _, err := strconv.ParseFloat(strings.TrimSpace(raw), 64); err == nil {
+		return strings.TrimSpace(raw)
+	}
+	return strconv.FormatFloat(value, 'f', 6, 64)
+}
+
+func hasEnv(env []corev1.EnvVar, name string) bool {
+	for _, e := range env {
+		if e.Name == name {
+			return true
+		}
+	}
+	return false
+}
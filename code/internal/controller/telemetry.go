@@ -0,0 +1,56 @@
+/*
+Copyright 2026 yydashuai.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	corev1 "k8s.io/api/core/v1"
+
+	airforcev1alpha1 "github.com/yydashuai/mission-system/api/v1alpha1"
+)
+
+// TelemetryCredentials issues the endpoint and token a task reports telemetry with
+// (see internal/telemetry).
+type TelemetryCredentials interface {
+	Endpoint(namespace, name string) string
+	Token(namespace, name string) string
+}
+
+// injectTelemetryEnv gives the task container and weapon sidecars TELEMETRY_ENDPOINT and
+// TELEMETRY_TOKEN, keeping any value the pod template already sets.
+func injectTelemetryEnv(pod *corev1.Pod, task *airforcev1alpha1.FlightTask, creds TelemetryCredentials) {
+	if creds == nil {
+		return
+	}
+	env := []corev1.EnvVar{
+		{Name: "TELEMETRY_ENDPOINT", Value: creds.Endpoint(task.Namespace, task.Name)},
+		{Name: "TELEMETRY_TOKEN", Value: creds.Token(task.Namespace, task.Name)},
+	}
+	name := taskContainerName(pod)
+	containers := weaponSidecars(pod)
+	for i := range pod.Spec.Containers {
+		if pod.Spec.Containers[i].Name == name {
+			containers = append(containers, &pod.Spec.Containers[i])
+		}
+	}
+	for _, c := range containers {
+		for _, e := range env {
+			if !hasEnv(c.Env, e.Name) {
+				c.Env = append(c.Env, e)
+			}
+		}
+	}
+}
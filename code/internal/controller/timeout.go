@@ -0,0 +1,135 @@
+/*
+Copyright 2026 yydashuai.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	apimeta "k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	airforcev1alpha1 "github.com/yydashuai/mission-system/api/v1alpha1"
+)
+
+const (
+	// conditionAborted is True on a FlightTask its stage stopped before it finished.
+	conditionAborted = "Aborted"
+	// reasonStageTimedOut is the Aborted reason for tasks of a stage that exceeded its timeout.
+	reasonStageTimedOut = "StageTimedOut"
+	// reasonMissionBudgetExceeded is the Aborted reason for tasks of a Mission that ran out
+	// of its time budget.
+	reasonMissionBudgetExceeded = "MissionBudgetExceeded"
+
+	// stageTimedOutMessage leads the message of a stage that failed by timing out.
+	stageTimedOutMessage = "Stage timed out"
+	// missionBudgetExceededMessage leads the message of a stage the Mission controller
+	// failed because the Mission ran out of its time budget.
+	missionBudgetExceededMessage = "Mission time budget exceeded"
+)
+
+// stageTimedOut reports a stage that was failed for exceeding its timeout.
+func stageTimedOut(stage *airforcev1alpha1.MissionStage) bool {
+	return stage.Status.Phase == airforcev1alpha1.MissionStagePhaseFailed &&
+		stage.Status.Reason == reasonStageTimedOut
+}
+
+// stageStopped returns the reason of a stage that was failed before its tasks finished,
+// reasonStageTimedOut or reasonMissionBudgetExceeded, or "" for any other stage.
+func stageStopped(stage *airforcev1alpha1.MissionStage) string {
+	if stage.Status.Phase != airforcev1alpha1.MissionStagePhaseFailed {
+		return ""
+	}
+	switch stage.Status.Reason {
+	case reasonStageTimedOut, reasonMissionBudgetExceeded:
+		return stage.Status.Reason
+	}
+	return ""
+}
+
+// stageStoppedMessage is the message that leads the status message of a stopped stage.
+func stageStoppedMessage(reason string) string {
+	if reason == reasonStageTimedOut {
+		return stageTimedOutMessage
+	}
+	return missionBudgetExceededMessage
+}
+
+// taskAborted reports a task that its stage stopped; its pod must not be recreated.
+func taskAborted(task *airforcev1alpha1.FlightTask) bool {
+	cond := apimeta.FindStatusCondition(task.Status.Conditions, conditionAborted)
+	return task.Status.Phase == airforcev1alpha1.FlightTaskPhaseFailed &&
+		cond != nil && cond.Status == metav1.ConditionTrue
+}
+
+// stopStageTasks fails the unfinished tasks of a stopped stage, with the StageTimedOut or
+// MissionBudgetExceeded reason, and terminates their pods within the Mission's cancellation
+// grace period if it sets one. It is idempotent and runs on every reconcile of the stage.
+func (r *MissionStageReconciler) stopStageTasks(ctx context.Context, stage *airforcev1alpha1.MissionStage, tasks []airforcev1alpha1.FlightTask) error {
+	var deleteOpts []client.DeleteOption
+	var mission airforcev1alpha1.Mission
+	if err := r.Get(ctx, client.ObjectKey{Namespace: stage.Namespace, Name: stage.Spec.MissionRef.Name}, &mission); err != nil {
+		if !apierrors.IsNotFound(err) {
+			return err
+		}
+	} else if cfg := mission.Spec.Config; cfg != nil && cfg.CancellationPolicy != nil && cfg.CancellationPolicy.GracePeriod != nil {
+		deleteOpts = append(deleteOpts, client.GracePeriodSeconds(int64(cfg.CancellationPolicy.GracePeriod.Seconds())))
+	}
+
+	reason, eventReason := reasonMissionBudgetExceeded, eventReasonBudgetExceeded
+	message := fmt.Sprintf("mission %s ran out of its time budget", stage.Spec.MissionRef.Name)
+	if stageTimedOut(stage) {
+		reason, eventReason = reasonStageTimedOut, eventReasonStageTimedOut
+		message = fmt.Sprintf("stage %s exceeded its timeout", stage.Name)
+		if stage.Spec.Config != nil && stage.Spec.Config.Timeout != nil {
+			message += " of " + stage.Spec.Config.Timeout.Duration.String()
+		}
+	}
+
+	for i := range tasks {
+		task := &tasks[i]
+		if isFlightTaskFinished(task.Status.Phase) {
+			continue
+		}
+		patch := client.MergeFrom(task.DeepCopy())
+		task.Status.Phase = airforcev1alpha1.FlightTaskPhaseFailed
+		apimeta.SetStatusCondition(&task.Status.Conditions, metav1.Condition{
+			Type:               conditionAborted,
+			Status:             metav1.ConditionTrue,
+			Reason:             reason,
+			Message:            message,
+			ObservedGeneration: task.Generation,
+		})
+		if err := r.Status().Patch(ctx, task, patch); err != nil {
+			return err
+		}
+		observeFlightTaskFinished(task, task.Status.Phase)
+		recordWarning(r.Recorder, task, eventReason, "Stopped: %s", message)
+
+		if task.Status.PodRef == nil || task.Status.PodRef.Name == "" {
+			continue
+		}
+		pod := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Namespace: task.Namespace, Name: task.Status.PodRef.Name}}
+		if err := r.Delete(ctx, pod, deleteOpts...); err != nil && !apierrors.IsNotFound(err) {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,132 @@
+/*
+Copyright 2026 yydashuai.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"fmt"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	apimeta "k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	airforcev1alpha1 "github.com/yydashuai/mission-system/api/v1alpha1"
+)
+
+// conditionUnschedulable is True on a task that was failed because its pod could not be
+// scheduled within the limits of its UnschedulablePolicy.
+const conditionUnschedulable = "Unschedulable"
+
+// unschedulablePolicy returns the policy for a task: the manager default with the fields
+// the task sets laid over it. It returns nil when neither sets a limit, in which case the
+// task waits for an aircraft for as long as it takes.
+func (r *FlightTaskReconciler) unschedulablePolicy(task *airforcev1alpha1.FlightTask) *airforcev1alpha1.UnschedulablePolicy {
+	policy := r.UnschedulablePolicy.DeepCopy()
+	if override := task.Spec.UnschedulablePolicy; override != nil {
+		if override.MaxAttempts > 0 {
+			policy.MaxAttempts = override.MaxAttempts
+		}
+		if override.Timeout != nil {
+			policy.Timeout = override.Timeout.DeepCopy()
+		}
+		if override.Action != "" {
+			policy.Action = override.Action
+		}
+		if override.FallbackAircraftType != "" {
+			policy.FallbackAircraftType = override.FallbackAircraftType
+		}
+	}
+	if policy.MaxAttempts <= 0 && (policy.Timeout == nil || policy.Timeout.Duration <= 0) {
+		return nil
+	}
+	return policy
+}
+
+// unschedulableLimit reports whether an unscheduled pod reached the limits of the policy,
+// with the condition reason and message saying which.
+func unschedulableLimit(policy *airforcev1alpha1.UnschedulablePolicy, attempts int32, pod *corev1.Pod, now time.Time) (string, string, bool) {
+	if policy.MaxAttempts > 0 && attempts >= policy.MaxAttempts {
+		return "SchedulingAttemptsExceeded", fmt.Sprintf("pod %s failed to schedule %d time(s)", pod.Name, attempts), true
+	}
+	if policy.Timeout != nil && policy.Timeout.Duration > 0 && now.Sub(pod.CreationTimestamp.Time) >= policy.Timeout.Duration {
+		return "SchedulingTimeout", fmt.Sprintf("pod %s was not scheduled within %s", pod.Name, policy.Timeout.Duration), true
+	}
+	return "", "", false
+}
+
+// nextUnschedulableAction returns what to do with a task whose pod reached the limits.
+// Relaxing and falling back are tried once each; a task that already took that step fails,
+// as does one that already accepts the fallback aircraft type.
+func nextUnschedulableAction(policy *airforcev1alpha1.UnschedulablePolicy, task *airforcev1alpha1.FlightTask) airforcev1alpha1.UnschedulableAction {
+	info := task.Status.SchedulingInfo
+	switch policy.Action {
+	case airforcev1alpha1.UnschedulableActionRelax:
+		if info == nil || !info.PreferencesRelaxed {
+			return airforcev1alpha1.UnschedulableActionRelax
+		}
+	case airforcev1alpha1.UnschedulableActionFallback:
+		if policy.FallbackAircraftType != "" && !containsString(acceptableAircraftTypes(task.Spec.AircraftRequirement), policy.FallbackAircraftType) &&
+			(info == nil || info.FallbackAircraftType == "") {
+			return airforcev1alpha1.UnschedulableActionFallback
+		}
+	}
+	return airforcev1alpha1.UnschedulableActionFail
+}
+
+// setUnschedulableCondition records why the task failed to be scheduled, with the last
+// message of the scheduler.
+func setUnschedulableCondition(task *airforcev1alpha1.FlightTask, reason, message string) bool {
+	if cond := apimeta.FindStatusCondition(task.Status.Conditions, "NoFailedScheduling"); cond != nil &&
+		cond.Status == metav1.ConditionFalse && cond.Message != "" {
+		message += ": " + cond.Message
+	}
+	return apimeta.SetStatusCondition(&task.Status.Conditions, metav1.Condition{
+		Type:               conditionUnschedulable,
+		Status:             metav1.ConditionTrue,
+		Reason:             reason,
+		Message:            message,
+		ObservedGeneration: task.Generation,
+	})
+}
+
+// unschedulable reports a task that was failed because its pod could not be scheduled.
+func unschedulable(task *airforcev1alpha1.FlightTask) bool {
+	cond := apimeta.FindStatusCondition(task.Status.Conditions, conditionUnschedulable)
+	return task.Status.Phase == airforcev1alpha1.FlightTaskPhaseFailed &&
+		cond != nil && cond.Status == metav1.ConditionTrue
+}
+
+// effectiveAircraftRequirement is the requirement a task's pod is built for, after the
+// steps its UnschedulablePolicy took: a fallback aircraft type, or no preferred location.
+// Either step drops the node pin.
+func effectiveAircraftRequirement(task *airforcev1alpha1.FlightTask) airforcev1alpha1.AircraftRequirement {
+	req := task.Spec.AircraftRequirement
+	if info := task.Status.SchedulingInfo; info != nil {
+		if info.FallbackAircraftType != "" {
+			req.Type, req.AlternateTypes, req.Node = info.FallbackAircraftType, nil, ""
+		}
+		if info.PreferencesRelaxed {
+			req.PreferredLocation, req.Node = "", ""
+		}
+	}
+	return req
+}
+
+// preferencesRelaxed reports a task whose pod is built without scheduling preferences.
+func preferencesRelaxed(task *airforcev1alpha1.FlightTask) bool {
+	return task.Status.SchedulingInfo != nil && task.Status.SchedulingInfo.PreferencesRelaxed
+}
@@ -18,8 +18,13 @@ package controller
 
 import (
 	"context"
+	"strings"
 
+	"k8s.io/apimachinery/pkg/api/equality"
+	apimeta "k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/record"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/log"
@@ -30,12 +35,14 @@ import (
 // WeaponReconciler reconciles a Weapon object
 type WeaponReconciler struct {
 	client.Client
-	Scheme *runtime.Scheme
+	Scheme   *runtime.Scheme
+	Recorder record.EventRecorder
 }
 
 //+kubebuilder:rbac:groups=airforce.airforce.mil,resources=weapons,verbs=get;list;watch;create;update;patch;delete
 //+kubebuilder:rbac:groups=airforce.airforce.mil,resources=weapons/status,verbs=get;update;patch
 //+kubebuilder:rbac:groups=airforce.airforce.mil,resources=weapons/finalizers,verbs=update
+//+kubebuilder:rbac:groups="",resources=events,verbs=create;patch
 
 // Reconcile is part of the main kubernetes reconciliation loop which aims to
 // move the current state of the cluster closer to the desired state.
@@ -60,6 +67,44 @@ func (r *WeaponReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctr
 		if err := r.Status().Patch(ctx, &weapon, patch); err != nil {
 			return ctrl.Result{}, err
 		}
+		recordNormal(r.Recorder, &weapon, eventReasonWeaponAvailable, "Weapon %s is available", weapon.Name)
+	}
+
+	// 解析射程/重量/速度等规格参数
+	parsed := parseWeaponSpecifications(&weapon)
+	if !equality.Semantic.DeepEqual(weapon.Status.Parsed, parsed) {
+		patch := client.MergeFrom(weapon.DeepCopy())
+		weapon.Status.Parsed = parsed
+		if err := r.Status().Patch(ctx, &weapon, patch); err != nil {
+			return ctrl.Result{}, err
+		}
+		if len(parsed.Errors) != 0 {
+			recordWarning(r.Recorder, &weapon, eventReasonInvalidWeapon, "invalid spec.specifications: %s", strings.Join(parsed.Errors, "; "))
+		}
+	}
+
+	// 镜像仓库为空时记录条件，仅在条件变为 False 时告警
+	imageCond := metav1.Condition{
+		Type:               "ImageConfigured",
+		Status:             metav1.ConditionTrue,
+		Reason:             "Configured",
+		Message:            "spec.image.repository is set",
+		ObservedGeneration: weapon.Generation,
+	}
+	if weapon.Spec.Image == nil || strings.TrimSpace(weapon.Spec.Image.Repository) == "" {
+		imageCond.Status = metav1.ConditionFalse
+		imageCond.Reason = "RepositoryEmpty"
+		imageCond.Message = "spec.image.repository is empty; FlightTasks loading this weapon will fail"
+	}
+	wasMissing := apimeta.IsStatusConditionFalse(weapon.Status.Conditions, imageCond.Type)
+	patch := client.MergeFrom(weapon.DeepCopy())
+	if apimeta.SetStatusCondition(&weapon.Status.Conditions, imageCond) {
+		if err := r.Status().Patch(ctx, &weapon, patch); err != nil {
+			return ctrl.Result{}, err
+		}
+		if imageCond.Status == metav1.ConditionFalse && !wasMissing {
+			recordWarning(r.Recorder, &weapon, eventReasonInvalidWeapon, "%s", imageCond.Message)
+		}
 	}
 
 	return ctrl.Result{}, nil
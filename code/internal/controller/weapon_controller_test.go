@@ -22,7 +22,9 @@ import (
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
 	"k8s.io/apimachinery/pkg/api/errors"
+	apimeta "k8s.io/apimachinery/pkg/api/meta"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -83,5 +85,68 @@ var _ = Describe("Weapon Controller", func() {
 			Expect(k8sClient.Get(ctx, typeNamespacedName, fetched)).To(Succeed())
 			Expect(fetched.Status.Phase).To(Equal(airforcev1alpha1.WeaponPhaseAvailable))
 		})
+
+		It("should warn about an empty image repository only when it becomes empty", func() {
+			recorder := record.NewFakeRecorder(10)
+			controllerReconciler := &WeaponReconciler{
+				Client:   k8sClient,
+				Scheme:   k8sClient.Scheme(),
+				Recorder: recorder,
+			}
+
+			for i := 0; i < 2; i++ {
+				_, err := controllerReconciler.Reconcile(ctx, reconcile.Request{NamespacedName: typeNamespacedName})
+				Expect(err).NotTo(HaveOccurred())
+			}
+			fetched := &airforcev1alpha1.Weapon{}
+			Expect(k8sClient.Get(ctx, typeNamespacedName, fetched)).To(Succeed())
+			Expect(apimeta.IsStatusConditionFalse(fetched.Status.Conditions, "ImageConfigured")).To(BeTrue())
+			Expect(recorder.Events).To(HaveLen(2))
+			events := []string{<-recorder.Events, <-recorder.Events}
+			Expect(events).To(ContainElement(ContainSubstring(eventReasonWeaponAvailable)))
+			Expect(events).To(ContainElement(ContainSubstring(eventReasonInvalidWeapon)))
+
+			fetched.Spec.Image = &airforcev1alpha1.WeaponSpecImage{Repository: "registry.local/pl15"}
+			Expect(k8sClient.Update(ctx, fetched)).To(Succeed())
+			_, err := controllerReconciler.Reconcile(ctx, reconcile.Request{NamespacedName: typeNamespacedName})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(k8sClient.Get(ctx, typeNamespacedName, fetched)).To(Succeed())
+			Expect(apimeta.IsStatusConditionTrue(fetched.Status.Conditions, "ImageConfigured")).To(BeTrue())
+			Expect(recorder.Events).To(BeEmpty())
+		})
+
+		It("should parse the specifications into fixed units", func() {
+			resource := &airforcev1alpha1.Weapon{}
+			Expect(k8sClient.Get(ctx, typeNamespacedName, resource)).To(Succeed())
+			resource.Spec.Specifications = &airforcev1alpha1.WeaponSpecifications{
+				Range:  "120 nm",
+				Weight: "450 lb",
+				Speed:  "Mach 4",
+				Length: "4.2m",
+			}
+			Expect(k8sClient.Update(ctx, resource)).To(Succeed())
+
+			controllerReconciler := &WeaponReconciler{
+				Client: k8sClient,
+				Scheme: k8sClient.Scheme(),
+			}
+			_, err := controllerReconciler.Reconcile(ctx, reconcile.Request{NamespacedName: typeNamespacedName})
+			Expect(err).NotTo(HaveOccurred())
+
+			fetched := &airforcev1alpha1.Weapon{}
+			Expect(k8sClient.Get(ctx, typeNamespacedName, fetched)).To(Succeed())
+			Expect(fetched.Status.Parsed).NotTo(BeNil())
+			Expect(fetched.Status.Parsed.RangeMeters).To(Equal(int64(222240)))
+			Expect(fetched.Status.Parsed.WeightKg).To(Equal(int32(204)))
+			Expect(fetched.Status.Parsed.SpeedKmh).To(Equal(int32(4900)))
+			Expect(fetched.Status.Parsed.Errors).To(BeEmpty())
+
+			fetched.Spec.Specifications.Speed = "4.0"
+			Expect(k8sClient.Update(ctx, fetched)).To(Succeed())
+			_, err = controllerReconciler.Reconcile(ctx, reconcile.Request{NamespacedName: typeNamespacedName})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(k8sClient.Get(ctx, typeNamespacedName, fetched)).To(Succeed())
+			Expect(fetched.Status.Parsed.Errors).To(ConsistOf(ContainSubstring("speed")))
+		})
 	})
 })
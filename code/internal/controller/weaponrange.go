@@ -0,0 +1,182 @@
+/*
+Copyright 2026 yydashuai.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	apimeta "k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	airforcev1alpha1 "github.com/yydashuai/mission-system/api/v1alpha1"
+	"github.com/yydashuai/mission-system/internal/geo"
+	"github.com/yydashuai/mission-system/internal/units"
+)
+
+// conditionWeaponInRange reports whether the task's target is within the effective range of
+// its loaded weapons, measured from the aircraft node the task was scheduled on.
+const conditionWeaponInRange = "WeaponInRange"
+
+// parseWeaponSpecifications converts the free-form range, weight and speed of a Weapon
+// to fixed units. Specifications that are set but cannot be parsed are listed in Errors.
+func parseWeaponSpecifications(weapon *airforcev1alpha1.Weapon) *airforcev1alpha1.WeaponParsedSpecifications {
+	parsed := &airforcev1alpha1.WeaponParsedSpecifications{ObservedGeneration: weapon.Generation}
+	spec := weapon.Spec.Specifications
+	if spec == nil {
+		return parsed
+	}
+	if strings.TrimSpace(spec.Range) != "" {
+		if v, err := units.ParseDistance(spec.Range); err != nil {
+			parsed.Errors = append(parsed.Errors, "range: "+err.Error())
+		} else {
+			parsed.RangeMeters = int64(math.Round(v))
+		}
+	}
+	if strings.TrimSpace(spec.Weight) != "" {
+		if v, err := units.ParseMass(spec.Weight); err != nil {
+			parsed.Errors = append(parsed.Errors, "weight: "+err.Error())
+		} else {
+			parsed.WeightKg = int32(math.Round(v))
+		}
+	}
+	if strings.TrimSpace(spec.Speed) != "" {
+		if v, err := units.ParseSpeed(spec.Speed); err != nil {
+			parsed.Errors = append(parsed.Errors, "speed: "+err.Error())
+		} else {
+			parsed.SpeedKmh = int32(math.Round(v))
+		}
+	}
+	return parsed
+}
+
+// weaponRangeKm returns the effective range of a Weapon, or 0 if it is not set or invalid.
+func weaponRangeKm(weapon *airforcev1alpha1.Weapon) float64 {
+	if weapon.Spec.Specifications == nil {
+		return 0
+	}
+	meters, err := units.ParseDistance(weapon.Spec.Specifications.Range)
+	if err != nil {
+		return 0
+	}
+	return meters / 1000
+}
+
+// weaponOutOfRange reports a task that was failed by the range check.
+func weaponOutOfRange(task *airforcev1alpha1.FlightTask) bool {
+	cond := apimeta.FindStatusCondition(task.Status.Conditions, conditionWeaponInRange)
+	return task.Status.Phase == airforcev1alpha1.FlightTaskPhaseFailed &&
+		cond != nil && cond.Status == metav1.ConditionFalse
+}
+
+// rangeCheckPolicy returns the Mission's range check policy; standalone tasks only warn.
+func rangeCheckPolicy(mission *airforcev1alpha1.Mission) airforcev1alpha1.RangeCheckPolicy {
+	if mission == nil || mission.Spec.Config == nil || mission.Spec.Config.RangeCheck == "" {
+		return airforcev1alpha1.RangeCheckWarn
+	}
+	return mission.Spec.Config.RangeCheck
+}
+
+// checkWeaponRange compares the distance from the aircraft node to the task's target with
+// the effective range of every loaded weapon. Checks that cannot be made for lack of data
+// yield an Unknown condition.
+func (r *FlightTaskReconciler) checkWeaponRange(ctx context.Context, task *airforcev1alpha1.FlightTask, nodeName string) (metav1.Condition, airforcev1alpha1.RangeCheckPolicy, error) {
+	cond := metav1.Condition{
+		Type:               conditionWeaponInRange,
+		Status:             metav1.ConditionUnknown,
+		ObservedGeneration: task.Generation,
+	}
+
+	mission, err := r.taskMission(ctx, task)
+	if err != nil {
+		return cond, "", err
+	}
+	policy := rangeCheckPolicy(mission)
+	if policy == airforcev1alpha1.RangeCheckIgnore {
+		cond.Reason, cond.Message = "CheckDisabled", "range check is disabled for this mission"
+		return cond, policy, nil
+	}
+	if len(task.Spec.WeaponLoadout) == 0 {
+		cond.Reason, cond.Message = "NoWeapons", "task carries no weapons"
+		return cond, policy, nil
+	}
+
+	var target *airforcev1alpha1.MissionTarget
+	if mission != nil {
+		target, _ = resolveTaskTarget(mission, task.Spec.TargetRef)
+	}
+	if target == nil {
+		cond.Reason, cond.Message = "NoTarget", "task has no target with coordinates"
+		return cond, policy, nil
+	}
+	targetPoint, err := geoPoint(target.Coordinates)
+	if err != nil {
+		cond.Reason, cond.Message = "NoTarget", fmt.Sprintf("target %s has no usable coordinates: %v", target.Name, err)
+		return cond, policy, nil
+	}
+
+	var node corev1.Node
+	if err := r.Get(ctx, client.ObjectKey{Name: nodeName}, &node); err != nil {
+		if !apierrors.IsNotFound(err) {
+			return cond, policy, err
+		}
+	}
+	nodePoint, ok := nodeLocation(&node)
+	if !ok {
+		cond.Reason, cond.Message = "NoAircraftLocation", fmt.Sprintf("aircraft node %s has no location labels", nodeName)
+		return cond, policy, nil
+	}
+	distance := geo.Distance(nodePoint, targetPoint)
+
+	checked := 0
+	var outOfRange []string
+	for _, item := range task.Spec.WeaponLoadout {
+		var weapon airforcev1alpha1.Weapon
+		if err := r.Get(ctx, client.ObjectKey{Namespace: task.Namespace, Name: item.WeaponRef.Name}, &weapon); err != nil {
+			if apierrors.IsNotFound(err) {
+				continue
+			}
+			return cond, policy, err
+		}
+		rangeKm := weaponRangeKm(&weapon)
+		if rangeKm <= 0 {
+			continue
+		}
+		checked++
+		if distance > rangeKm {
+			outOfRange = append(outOfRange, fmt.Sprintf("%s (%.0f km)", weapon.Name, rangeKm))
+		}
+	}
+
+	switch {
+	case len(outOfRange) != 0:
+		cond.Status, cond.Reason = metav1.ConditionFalse, "OutOfRange"
+		cond.Message = fmt.Sprintf("target %s is %.0f km from aircraft node %s, beyond the effective range of %s",
+			target.Name, distance, nodeName, strings.Join(outOfRange, ", "))
+	case checked == 0:
+		cond.Reason, cond.Message = "NoWeaponRange", "no loaded weapon has a valid specifications.range"
+	default:
+		cond.Status, cond.Reason = metav1.ConditionTrue, "InRange"
+		cond.Message = fmt.Sprintf("target %s is %.0f km from aircraft node %s", target.Name, distance, nodeName)
+	}
+	return cond, policy, nil
+}
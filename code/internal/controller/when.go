@@ -0,0 +1,393 @@
+/*
+Copyright 2026 yydashuai.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// `when` expressions gate stages and tasks on the results of earlier stages:
+//
+//	stages.isr.outputs.targetConfirmed == "true" && stages.isr.phase != "失败"
+//
+// Operands are string or number literals, true/false, and references:
+//
+//	stages.<stage>.phase
+//	stages.<stage>.outputs.<key>
+//	stages.<stage>.tasks.<task>.phase
+//	stages.<stage>.tasks.<task>.outputs.<key>
+//
+// Operators are ==, !=, <, <=, >, >=, !, && and || with the usual precedence, plus
+// parentheses: ! applies to a single operand, so !a == b is (!a) == b; write !(a == b)
+// to negate a comparison. Ordering operators require numbers; == and != compare
+// numerically when both sides are numbers and as strings otherwise. All values are
+// strings; "true" is true and "false" or "" is false.
+
+// whenError reports a when expression that can never be evaluated, e.g. a syntax
+// error or a reference to a stage that is not part of the Mission.
+type whenError struct {
+	subject string
+	expr    string
+	msg     string
+}
+
+func (e *whenError) Error() string {
+	return fmt.Sprintf("%s: when %q: %s", e.subject, e.expr, e.msg)
+}
+
+// whenLookup resolves a reference. ready is false while the referenced stage has not finished.
+type whenLookup func(ref string) (value string, ready bool, err error)
+
+// evaluateWhen parses and evaluates expr. An empty expression is always true.
+func evaluateWhen(subject, expr string, lookup whenLookup) (bool, bool, error) {
+	if strings.TrimSpace(expr) == "" {
+		return true, true, nil
+	}
+	node, err := parseWhen(expr)
+	if err != nil {
+		return false, false, &whenError{subject: subject, expr: expr, msg: err.Error()}
+	}
+	value, ready, err := node.eval(lookup)
+	if err != nil || !ready {
+		return false, ready, wrapWhenError(subject, expr, err)
+	}
+	result, err := whenBool(value)
+	if err != nil {
+		return false, false, &whenError{subject: subject, expr: expr, msg: err.Error()}
+	}
+	return result, true, nil
+}
+
+// wrapWhenError marks evaluation errors as permanent, leaving API errors from lookups alone.
+func wrapWhenError(subject, expr string, err error) error {
+	if evalErr, ok := err.(whenEvalError); ok {
+		return &whenError{subject: subject, expr: expr, msg: string(evalErr)}
+	}
+	return err
+}
+
+// whenEvalError is a type or reference error found while evaluating.
+type whenEvalError string
+
+func (e whenEvalError) Error() string { return string(e) }
+
+type whenNode struct {
+	op          string // "" for operands
+	value       string
+	ref         string
+	left, right *whenNode
+}
+
+func (n *whenNode) eval(lookup whenLookup) (string, bool, error) {
+	switch n.op {
+	case "":
+		if n.ref != "" {
+			return lookup(n.ref)
+		}
+		return n.value, true, nil
+	case "!":
+		v, ready, err := n.left.eval(lookup)
+		if err != nil || !ready {
+			return "", ready, err
+		}
+		b, err := whenBool(v)
+		if err != nil {
+			return "", false, err
+		}
+		return strconv.FormatBool(!b), true, nil
+	case "&&", "||":
+		l, ready, err := n.left.eval(lookup)
+		if err != nil || !ready {
+			return "", ready, err
+		}
+		lb, err := whenBool(l)
+		if err != nil {
+			return "", false, err
+		}
+		if (n.op == "&&" && !lb) || (n.op == "||" && lb) {
+			return strconv.FormatBool(lb), true, nil
+		}
+		r, ready, err := n.right.eval(lookup)
+		if err != nil || !ready {
+			return "", ready, err
+		}
+		rb, err := whenBool(r)
+		if err != nil {
+			return "", false, err
+		}
+		return strconv.FormatBool(rb), true, nil
+	default:
+		l, ready, err := n.left.eval(lookup)
+		if err != nil || !ready {
+			return "", ready, err
+		}
+		r, ready, err := n.right.eval(lookup)
+		if err != nil || !ready {
+			return "", ready, err
+		}
+		result, err := whenCompare(n.op, l, r)
+		if err != nil {
+			return "", false, err
+		}
+		return strconv.FormatBool(result), true, nil
+	}
+}
+
+func whenBool(v string) (bool, error) {
+	switch v {
+	case "true":
+		return true, nil
+	case "false", "":
+		return false, nil
+	default:
+		return false, whenEvalError(fmt.Sprintf("%q is not a boolean", v))
+	}
+}
+
+func whenCompare(op, l, r string) (bool, error) {
+	lf, lerr := strconv.ParseFloat(l, 64)
+	rf, rerr := strconv.ParseFloat(r, 64)
+	numeric := lerr == nil && rerr == nil
+	switch op {
+	case "==":
+		if numeric {
+			return lf == rf, nil
+		}
+		return l == r, nil
+	case "!=":
+		if numeric {
+			return lf != rf, nil
+		}
+		return l != r, nil
+	}
+	if !numeric {
+		return false, whenEvalError(fmt.Sprintf("%q %s %q: operands must be numbers", l, op, r))
+	}
+	switch op {
+	case "<":
+		return lf < rf, nil
+	case "<=":
+		return lf <= rf, nil
+	case ">":
+		return lf > rf, nil
+	default:
+		return lf >= rf, nil
+	}
+}
+
+const (
+	whenTokEOF = iota
+	whenTokOp
+	whenTokString
+	whenTokWord
+)
+
+type whenToken struct {
+	kind int
+	text string
+}
+
+func tokenizeWhen(s string) ([]whenToken, error) {
+	var tokens []whenToken
+	for i := 0; i < len(s); {
+		c := s[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			i++
+		case c == '(' || c == ')':
+			tokens = append(tokens, whenToken{kind: whenTokOp, text: string(c)})
+			i++
+		case c == '"' || c == '\'':
+			var sb strings.Builder
+			j := i + 1
+			for ; j < len(s) && s[j] != c; j++ {
+				if s[j] == '\\' && j+1 < len(s) {
+					j++
+				}
+				sb.WriteByte(s[j])
+			}
+			if j >= len(s) {
+				return nil, fmt.Errorf("unterminated string at offset %d", i)
+			}
+			tokens = append(tokens, whenToken{kind: whenTokString, text: sb.String()})
+			i = j + 1
+		case strings.IndexByte("=!<>&|", c) >= 0:
+			if i+1 < len(s) {
+				switch two := s[i : i+2]; two {
+				case "==", "!=", "<=", ">=", "&&", "||":
+					tokens = append(tokens, whenToken{kind: whenTokOp, text: two})
+					i += 2
+					continue
+				}
+			}
+			if c != '!' && c != '<' && c != '>' {
+				return nil, fmt.Errorf("unexpected %q at offset %d", c, i)
+			}
+			tokens = append(tokens, whenToken{kind: whenTokOp, text: string(c)})
+			i++
+		case isWhenWordByte(c):
+			j := i
+			for j < len(s) && isWhenWordByte(s[j]) {
+				j++
+			}
+			tokens = append(tokens, whenToken{kind: whenTokWord, text: s[i:j]})
+			i = j
+		default:
+			return nil, fmt.Errorf("unexpected %q at offset %d", c, i)
+		}
+	}
+	return tokens, nil
+}
+
+func isWhenWordByte(c byte) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' ||
+		c == '_' || c == '-' || c == '.' || c == '+'
+}
+
+func parseWhen(expr string) (*whenNode, error) {
+	tokens, err := tokenizeWhen(expr)
+	if err != nil {
+		return nil, err
+	}
+	p := &whenParser{tokens: tokens}
+	node, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if tok := p.peek(); tok.kind != whenTokEOF {
+		return nil, fmt.Errorf("unexpected %q", tok.text)
+	}
+	return node, nil
+}
+
+type whenParser struct {
+	tokens []whenToken
+	pos    int
+}
+
+func (p *whenParser) peek() whenToken {
+	if p.pos >= len(p.tokens) {
+		return whenToken{kind: whenTokEOF}
+	}
+	return p.tokens[p.pos]
+}
+
+func (p *whenParser) next() whenToken {
+	tok := p.peek()
+	p.pos++
+	return tok
+}
+
+func (p *whenParser) parseOr() (*whenNode, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for tok := p.peek(); tok.kind == whenTokOp && tok.text == "||"; tok = p.peek() {
+		p.next()
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		left = &whenNode{op: "||", left: left, right: right}
+	}
+	return left, nil
+}
+
+func (p *whenParser) parseAnd() (*whenNode, error) {
+	left, err := p.parseComparison()
+	if err != nil {
+		return nil, err
+	}
+	for tok := p.peek(); tok.kind == whenTokOp && tok.text == "&&"; tok = p.peek() {
+		p.next()
+		right, err := p.parseComparison()
+		if err != nil {
+			return nil, err
+		}
+		left = &whenNode{op: "&&", left: left, right: right}
+	}
+	return left, nil
+}
+
+func (p *whenParser) parseComparison() (*whenNode, error) {
+	left, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+	tok := p.peek()
+	if tok.kind != whenTokOp {
+		return left, nil
+	}
+	switch tok.text {
+	case "==", "!=", "<", "<=", ">", ">=":
+	default:
+		return left, nil
+	}
+	p.next()
+	right, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+	return &whenNode{op: tok.text, left: left, right: right}, nil
+}
+
+func (p *whenParser) parseUnary() (*whenNode, error) {
+	if tok := p.peek(); tok.kind == whenTokOp && tok.text == "!" {
+		p.next()
+		operand, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return &whenNode{op: "!", left: operand}, nil
+	}
+	return p.parseOperand()
+}
+
+func (p *whenParser) parseOperand() (*whenNode, error) {
+	tok := p.next()
+	switch tok.kind {
+	case whenTokEOF:
+		return nil, fmt.Errorf("unexpected end of expression")
+	case whenTokString:
+		return &whenNode{value: tok.text}, nil
+	case whenTokOp:
+		if tok.text != "(" {
+			return nil, fmt.Errorf("unexpected %q", tok.text)
+		}
+		node, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if closing := p.next(); closing.kind != whenTokOp || closing.text != ")" {
+			return nil, fmt.Errorf("missing )")
+		}
+		return node, nil
+	}
+	if tok.text == "true" || tok.text == "false" {
+		return &whenNode{value: tok.text}, nil
+	}
+	if _, err := strconv.ParseFloat(tok.text, 64); err == nil {
+		return &whenNode{value: tok.text}, nil
+	}
+	if !whenRefPattern.MatchString(tok.text) {
+		return nil, fmt.Errorf("unknown reference %q", tok.text)
+	}
+	return &whenNode{ref: tok.text}, nil
+}
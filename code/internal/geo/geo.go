@@ -0,0 +1,236 @@
+/*
+Copyright 2026 yydashuai.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package geo parses coordinates and does the spherical geometry used for targets,
+// operation areas and routes. Distances are in kilometres and angles in degrees.
+package geo
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// EarthRadiusKm is the mean Earth radius.
+const EarthRadiusKm = 6371.0
+
+// Point is a WGS84 position in decimal degrees.
+type Point struct {
+	Lat, Lon float64
+}
+
+func (p Point) String() string {
+	return fmt.Sprintf("%.6f,%.6f", p.Lat, p.Lon)
+}
+
+// Validate checks that the latitude and longitude are within range.
+func (p Point) Validate() error {
+	if math.IsNaN(p.Lat) || p.Lat < -90 || p.Lat > 90 {
+		return fmt.Errorf("latitude %v out of range [-90, 90]", p.Lat)
+	}
+	if math.IsNaN(p.Lon) || p.Lon < -180 || p.Lon > 180 {
+		return fmt.Errorf("longitude %v out of range [-180, 180]", p.Lon)
+	}
+	return nil
+}
+
+// ParsePoint parses a latitude and longitude given in decimal degrees or DMS.
+func ParsePoint(lat, lon string) (Point, error) {
+	if strings.TrimSpace(lat) == "" || strings.TrimSpace(lon) == "" {
+		return Point{}, fmt.Errorf("latitude or longitude is empty")
+	}
+	la, err := ParseLatitude(lat)
+	if err != nil {
+		return Point{}, err
+	}
+	lo, err := ParseLongitude(lon)
+	if err != nil {
+		return Point{}, err
+	}
+	return Point{Lat: la, Lon: lo}, nil
+}
+
+// ParseLatitude parses "39.9042", "-33.86", `39°54'15"N`, "N 39 54 15" or "北纬39°54′15″".
+func ParseLatitude(s string) (float64, error) {
+	v, err := parseAngle(s, "N", "S", "北纬", "南纬")
+	if err != nil {
+		return 0, fmt.Errorf("invalid latitude %q: %w", s, err)
+	}
+	if v < -90 || v > 90 {
+		return 0, fmt.Errorf("invalid latitude %q: out of range [-90, 90]", s)
+	}
+	return v, nil
+}
+
+// ParseLongitude parses "116.4074", "-0.12", `116°24'27"E`, "W 0 7 39" or "东经116°24′".
+func ParseLongitude(s string) (float64, error) {
+	v, err := parseAngle(s, "E", "W", "东经", "西经")
+	if err != nil {
+		return 0, fmt.Errorf("invalid longitude %q: %w", s, err)
+	}
+	if v < -180 || v > 180 {
+		return 0, fmt.Errorf("invalid longitude %q: out of range [-180, 180]", s)
+	}
+	return v, nil
+}
+
+// parseAngle reads decimal degrees or degrees/minutes/seconds with an optional
+// hemisphere, given as an upper-case letter or its Chinese name, before or after the value.
+// Lower-case d, m and s are unit separators, so "39d54m15sN" is accepted.
+func parseAngle(s, pos, neg, posZh, negZh string) (float64, error) {
+	s = strings.TrimSpace(s)
+	sign := 1.0
+	hemisphere := false
+	for _, h := range []struct {
+		text string
+		sign float64
+	}{{posZh, 1}, {negZh, -1}, {pos, 1}, {neg, -1}} {
+		if rest, ok := strings.CutPrefix(s, h.text); ok {
+			s, sign, hemisphere = strings.TrimSpace(rest), h.sign, true
+			break
+		}
+		if rest, ok := strings.CutSuffix(s, h.text); ok {
+			s, sign, hemisphere = strings.TrimSpace(rest), h.sign, true
+			break
+		}
+	}
+	if s == "" {
+		return 0, fmt.Errorf("no value")
+	}
+
+	if v, err := strconv.ParseFloat(s, 64); err == nil {
+		if hemisphere && math.Signbit(v) {
+			return 0, fmt.Errorf("negative value with hemisphere")
+		}
+		return sign * v, nil
+	}
+
+	fields := strings.FieldsFunc(s, func(r rune) bool {
+		return strings.ContainsRune(" °º'′\"″:dms", r)
+	})
+	if len(fields) == 0 || len(fields) > 3 {
+		return 0, fmt.Errorf("expected decimal degrees or degrees, minutes and seconds")
+	}
+	var parts [3]float64
+	for i, f := range fields {
+		v, err := strconv.ParseFloat(f, 64)
+		if err != nil {
+			return 0, fmt.Errorf("%q is not a number", f)
+		}
+		if i > 0 && (v < 0 || v >= 60) {
+			return 0, fmt.Errorf("minutes and seconds must be in [0, 60)")
+		}
+		if i < len(fields)-1 && v != math.Trunc(v) {
+			return 0, fmt.Errorf("only the last component may have a fraction")
+		}
+		parts[i] = v
+	}
+	// "-0 30" 的度数为 -0，按符号位判断西经/南纬
+	if math.Signbit(parts[0]) {
+		if hemisphere {
+			return 0, fmt.Errorf("negative value with hemisphere")
+		}
+		sign, parts[0] = -1, -parts[0]
+	}
+	return sign * (parts[0] + parts[1]/60 + parts[2]/3600), nil
+}
+
+// Distance is the great-circle distance between two points.
+func Distance(a, b Point) float64 {
+	lat1, lat2 := radians(a.Lat), radians(b.Lat)
+	dLat := radians(b.Lat - a.Lat)
+	dLon := radians(b.Lon - a.Lon)
+	h := math.Sin(dLat/2)*math.Sin(dLat/2) + math.Cos(lat1)*math.Cos(lat2)*math.Sin(dLon/2)*math.Sin(dLon/2)
+	return EarthRadiusKm * 2 * math.Atan2(math.Sqrt(h), math.Sqrt(1-h))
+}
+
+// Bearing is the initial great-circle bearing from a to b, in [0, 360).
+func Bearing(a, b Point) float64 {
+	lat1, lat2 := radians(a.Lat), radians(b.Lat)
+	dLon := radians(b.Lon - a.Lon)
+	y := math.Sin(dLon) * math.Cos(lat2)
+	x := math.Cos(lat1)*math.Sin(lat2) - math.Sin(lat1)*math.Cos(lat2)*math.Cos(dLon)
+	return math.Mod(degrees(math.Atan2(y, x))+360, 360)
+}
+
+// Destination is the point reached from p after distanceKm along the given initial bearing.
+func Destination(p Point, bearing, distanceKm float64) Point {
+	lat1, lon1 := radians(p.Lat), radians(p.Lon)
+	brng := radians(bearing)
+	d := distanceKm / EarthRadiusKm
+	lat2 := math.Asin(math.Sin(lat1)*math.Cos(d) + math.Cos(lat1)*math.Sin(d)*math.Cos(brng))
+	lon2 := lon1 + math.Atan2(math.Sin(brng)*math.Sin(d)*math.Cos(lat1), math.Cos(d)-math.Sin(lat1)*math.Sin(lat2))
+	return Point{Lat: degrees(lat2), Lon: math.Mod(degrees(lon2)+540, 360) - 180}
+}
+
+// RouteLength is the total great-circle length of the legs between consecutive points.
+func RouteLength(points []Point) float64 {
+	total := 0.0
+	for i := 1; i < len(points); i++ {
+		total += Distance(points[i-1], points[i])
+	}
+	return total
+}
+
+// Area is a region on the Earth's surface.
+type Area interface {
+	Contains(p Point) bool
+}
+
+// Circle is the set of points within RadiusKm of Center.
+type Circle struct {
+	Center   Point
+	RadiusKm float64
+}
+
+func (c Circle) Contains(p Point) bool {
+	return Distance(c.Center, p) <= c.RadiusKm
+}
+
+// Polygon is a closed ring of vertices; the last vertex connects back to the first.
+// Edges are treated as straight lines in latitude/longitude, which is accurate enough
+// for operation areas that do not span the antimeridian or a pole.
+type Polygon []Point
+
+// Validate checks the polygon has at least three valid vertices.
+func (poly Polygon) Validate() error {
+	if len(poly) < 3 {
+		return fmt.Errorf("polygon needs at least 3 vertices, got %d", len(poly))
+	}
+	for i, p := range poly {
+		if err := p.Validate(); err != nil {
+			return fmt.Errorf("vertex %d: %w", i, err)
+		}
+	}
+	return nil
+}
+
+// Contains uses ray casting; points on an edge may fall on either side.
+func (poly Polygon) Contains(p Point) bool {
+	inside := false
+	for i, j := 0, len(poly)-1; i < len(poly); j, i = i, i+1 {
+		a, b := poly[i], poly[j]
+		if (a.Lat > p.Lat) != (b.Lat > p.Lat) &&
+			p.Lon < (b.Lon-a.Lon)*(p.Lat-a.Lat)/(b.Lat-a.Lat)+a.Lon {
+			inside = !inside
+		}
+	}
+	return inside
+}
+
+func radians(d float64) float64 { return d * math.Pi / 180 }
+func degrees(r float64) float64 { return r * 180 / math.Pi }
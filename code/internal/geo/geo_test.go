@@ -0,0 +1,122 @@
+/*
+Copyright 2026 yydashuai.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package geo
+
+import (
+	"math"
+	"testing"
+)
+
+func near(a, b, tolerance float64) bool { return math.Abs(a-b) <= tolerance }
+
+func TestParseCoordinates(t *testing.T) {
+	lat := map[string]float64{
+		"39.9042":         39.9042,
+		"-33.86":          -33.86,
+		`39°54'15"N`:      39.904167,
+		"N 39 54 15":      39.904167,
+		"39d54m15sN":      39.904167,
+		"33°52′S":         -33.866667,
+		"北纬39°54′15″":     39.904167,
+		"39:54:15.5":      39.904306,
+		"0":               0,
+		"S 0.5":           -0.5,
+		"-39 54 15":       -39.904167,
+		"-0 7 39":         -0.1275,
+		"-0°30'":          -0.5,
+		"-0:00:36":        -0.01,
+		"90":              90,
+		"45°30'":          45.5,
+		`41° 24' 12.2" N`: 41.403389,
+	}
+	for in, want := range lat {
+		got, err := ParseLatitude(in)
+		if err != nil || !near(got, want, 1e-6) {
+			t.Errorf("ParseLatitude(%q) = %v, %v; want %v", in, got, err, want)
+		}
+	}
+	if got, err := ParseLongitude(`116°24'27"W`); err != nil || !near(got, -116.4075, 1e-6) {
+		t.Errorf("ParseLongitude = %v, %v", got, err)
+	}
+	if got, err := ParseLongitude("东经116.4"); err != nil || got != 116.4 {
+		t.Errorf("ParseLongitude = %v, %v", got, err)
+	}
+	if got, err := ParseLongitude("-0 7 39"); err != nil || !near(got, -0.1275, 1e-6) {
+		t.Errorf("ParseLongitude(-0 7 39) = %v, %v", got, err)
+	}
+
+	for _, in := range []string{"", "91", "-90.5", "abc", "39°61'", "N -5", "N -0 30", "S -0", "39.5°30'", "1 2 3 4"} {
+		if v, err := ParseLatitude(in); err == nil {
+			t.Errorf("ParseLatitude(%q) = %v, want error", in, v)
+		}
+	}
+	if _, err := ParseLongitude("181"); err == nil {
+		t.Error("ParseLongitude(181) should fail")
+	}
+	if _, err := ParsePoint("39.9", ""); err == nil {
+		t.Error("ParsePoint with empty longitude should fail")
+	}
+}
+
+func TestGeometry(t *testing.T) {
+	beijing := Point{Lat: 39.9042, Lon: 116.4074}
+	shanghai := Point{Lat: 31.2304, Lon: 121.4737}
+	if d := Distance(beijing, shanghai); !near(d, 1067, 5) {
+		t.Errorf("Distance = %v", d)
+	}
+	if b := Bearing(Point{}, Point{Lat: 1}); !near(b, 0, 1e-9) {
+		t.Errorf("Bearing north = %v", b)
+	}
+	if b := Bearing(Point{}, Point{Lon: -1}); !near(b, 270, 1e-9) {
+		t.Errorf("Bearing west = %v", b)
+	}
+
+	b := Bearing(beijing, shanghai)
+	dest := Destination(beijing, b, Distance(beijing, shanghai))
+	if !near(dest.Lat, shanghai.Lat, 1e-6) || !near(dest.Lon, shanghai.Lon, 1e-6) {
+		t.Errorf("Destination = %v, want %v", dest, shanghai)
+	}
+
+	route := []Point{beijing, shanghai, beijing}
+	if l := RouteLength(route); !near(l, 2*Distance(beijing, shanghai), 1e-9) {
+		t.Errorf("RouteLength = %v", l)
+	}
+	if RouteLength(route[:1]) != 0 {
+		t.Error("single point route should have no length")
+	}
+}
+
+func TestAreas(t *testing.T) {
+	circle := Circle{Center: Point{Lat: 30, Lon: 120}, RadiusKm: 100}
+	if !circle.Contains(Destination(circle.Center, 45, 99)) {
+		t.Error("point 99 km from center should be inside")
+	}
+	if circle.Contains(Destination(circle.Center, 45, 101)) {
+		t.Error("point 101 km from center should be outside")
+	}
+
+	square := Polygon{{Lat: 0, Lon: 0}, {Lat: 0, Lon: 10}, {Lat: 10, Lon: 10}, {Lat: 10, Lon: 0}}
+	if err := square.Validate(); err != nil {
+		t.Fatal(err)
+	}
+	if !square.Contains(Point{Lat: 5, Lon: 5}) || square.Contains(Point{Lat: 5, Lon: 11}) || square.Contains(Point{Lat: -1, Lon: 5}) {
+		t.Error("square containment is wrong")
+	}
+	if err := (Polygon{{}, {Lat: 1}}).Validate(); err == nil {
+		t.Error("two-vertex polygon should be invalid")
+	}
+}
@@ -0,0 +1,473 @@
+/*
+Copyright 2026 yydashuai.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package planning assigns Mission targets to flight tasks and weapons to those tasks.
+//
+// The solver is a single greedy pass, not a search for the best overall plan:
+// tasks whose target is set keep it and take the nearest aircraft that can fly
+// it, then the open targets are taken in priority order (the most constrained
+// first within a priority) and each gets the feasible task/aircraft/weapon
+// combination with the nearest aircraft. An aircraft is planned for one task at
+// most. Every target it cannot cover is reported with the reason of the closest miss.
+package planning
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+
+	"github.com/yydashuai/mission-system/internal/geo"
+)
+
+type Target struct {
+	Name string
+	// Priority orders the targets; higher is engaged first.
+	Priority int
+	Location *geo.Point
+	// Guidance and Warheads restrict the weapons that may engage the target; empty accepts any.
+	Guidance []string
+	Warheads []string
+	// Rounds is the number of weapons needed; 0 means 1.
+	Rounds int32
+}
+
+type Aircraft struct {
+	Name     string
+	Type     string
+	Location *geo.Point
+	// Hardpoints available on the aircraft; 0 means unknown and is not enforced.
+	Hardpoints int32
+}
+
+type Weapon struct {
+	Name string
+	// RangeKm is the effective range; 0 means unknown and is not enforced.
+	RangeKm  float64
+	Guidance string
+	Warhead  string
+	// Hardpoints used per round; 0 means 1.
+	Hardpoints int32
+	// AircraftTypes the weapon can be carried by; empty means any.
+	AircraftTypes []string
+}
+
+type Loadout struct {
+	Weapon   string
+	Quantity int32
+}
+
+type Task struct {
+	Stage        string
+	Name         string
+	AircraftType string
+	// Target is set for tasks that are already assigned; they are kept as they are.
+	Target string
+	// Weapons is the task's own loadout. Tasks without one get a weapon from the planner.
+	Weapons []Loadout
+}
+
+type Problem struct {
+	Targets  []Target
+	Tasks    []Task
+	Aircraft []Aircraft
+	Weapons  []Weapon
+}
+
+type Assignment struct {
+	Stage    string
+	Task     string
+	Target   string
+	Aircraft string
+	// DistanceKm from the aircraft to the target; negative if unknown.
+	DistanceKm float64
+	Weapons    []Loadout
+	// Fixed marks tasks whose target was set in the spec rather than by the planner.
+	Fixed bool
+}
+
+type Unassigned struct {
+	Target string
+	Reason string
+}
+
+type Plan struct {
+	Assignments []Assignment
+	Unassigned  []Unassigned
+	// Covered is the number of targets with at least one assigned task.
+	Covered int
+	Total   int
+}
+
+// Solve computes an assignment for the problem. It is deterministic for a given input.
+func Solve(p Problem) Plan {
+	plan := Plan{Total: len(p.Targets)}
+	weapons := make(map[string]*Weapon, len(p.Weapons))
+	for i := range p.Weapons {
+		weapons[p.Weapons[i].Name] = &p.Weapons[i]
+	}
+	targets := make(map[string]*Target, len(p.Targets))
+	for i := range p.Targets {
+		targets[p.Targets[i].Name] = &p.Targets[i]
+	}
+
+	covered := map[string]bool{}
+	s := &solver{problem: p, weapons: weapons, usedTasks: map[int]bool{}, usedAircraft: map[string]bool{}}
+	for i, task := range p.Tasks {
+		if task.Target == "" {
+			continue
+		}
+		s.usedTasks[i] = true
+		covered[task.Target] = true
+		assignment := Assignment{
+			Stage:      task.Stage,
+			Task:       task.Name,
+			Target:     task.Target,
+			DistanceKm: -1,
+			Weapons:    task.Weapons,
+			Fixed:      true,
+		}
+		// 已指定目标的任务也占用一架飞机，避免同一架飞机被计划两次
+		if t, ok := targets[task.Target]; ok {
+			if a, distance, ok := s.fixedTaskAircraft(i, t); ok {
+				s.usedAircraft[a] = true
+				assignment.Aircraft, assignment.DistanceKm = a, distance
+			}
+		}
+		plan.Assignments = append(plan.Assignments, assignment)
+	}
+
+	var open []*Target
+	for i := range p.Targets {
+		if !covered[p.Targets[i].Name] {
+			open = append(open, &p.Targets[i])
+		}
+	}
+	options := make(map[string]int, len(open))
+	for _, t := range open {
+		options[t.Name] = len(s.candidates(t, nil))
+	}
+	sort.SliceStable(open, func(i, j int) bool {
+		if open[i].Priority != open[j].Priority {
+			return open[i].Priority > open[j].Priority
+		}
+		if options[open[i].Name] != options[open[j].Name] {
+			return options[open[i].Name] < options[open[j].Name]
+		}
+		return open[i].Name < open[j].Name
+	})
+
+	for _, t := range open {
+		var miss missReason
+		cands := s.candidates(t, &miss)
+		if len(cands) == 0 {
+			plan.Unassigned = append(plan.Unassigned, Unassigned{Target: t.Name, Reason: miss.String()})
+			continue
+		}
+		best := cands[0]
+		s.usedTasks[best.task] = true
+		if best.aircraft != "" {
+			s.usedAircraft[best.aircraft] = true
+		}
+		task := p.Tasks[best.task]
+		covered[t.Name] = true
+		plan.Assignments = append(plan.Assignments, Assignment{
+			Stage:      task.Stage,
+			Task:       task.Name,
+			Target:     t.Name,
+			Aircraft:   best.aircraft,
+			DistanceKm: best.distance,
+			Weapons:    best.weapons,
+		})
+	}
+
+	for name := range covered {
+		if _, ok := targets[name]; ok {
+			plan.Covered++
+		}
+	}
+	sort.SliceStable(plan.Assignments, func(i, j int) bool {
+		a, b := plan.Assignments[i], plan.Assignments[j]
+		if a.Stage != b.Stage {
+			return a.Stage < b.Stage
+		}
+		return a.Task < b.Task
+	})
+	return plan
+}
+
+type solver struct {
+	problem      Problem
+	weapons      map[string]*Weapon
+	usedTasks    map[int]bool
+	usedAircraft map[string]bool
+}
+
+type candidate struct {
+	task     int
+	aircraft string
+	distance float64
+	weapons  []Loadout
+	// hardpoints used by the loadout, to prefer lighter options.
+	hardpoints int32
+}
+
+// candidates lists the feasible options for a target, best first. When miss is
+// non-nil it records why options were rejected.
+func (s *solver) candidates(t *Target, miss *missReason) []candidate {
+	if miss == nil {
+		miss = &missReason{}
+	}
+	rounds := t.Rounds
+	if rounds <= 0 {
+		rounds = 1
+	}
+
+	var out []candidate
+	freeTasks := 0
+	for ti := range s.problem.Tasks {
+		if s.usedTasks[ti] {
+			continue
+		}
+		freeTasks++
+		out = append(out, s.taskCandidates(ti, t, rounds, miss)...)
+	}
+	if freeTasks == 0 {
+		miss.note(rankNoTask, "no unassigned task left")
+	}
+	sortCandidates(out)
+	return out
+}
+
+// taskCandidates lists the feasible aircraft/weapon options of one task against a target.
+func (s *solver) taskCandidates(ti int, t *Target, rounds int32, miss *missReason) []candidate {
+	task := s.problem.Tasks[ti]
+	aircraft := s.aircraftFor(task.AircraftType)
+	if len(aircraft) == 0 {
+		miss.note(rankNoAircraft, fmt.Sprintf("no available %s aircraft", typeOrAny(task.AircraftType)))
+		return nil
+	}
+	var out []candidate
+	for _, a := range aircraft {
+		distance := -1.0
+		if a.Location != nil && t.Location != nil {
+			distance = geo.Distance(*a.Location, *t.Location)
+		}
+		if len(task.Weapons) != 0 {
+			if need, ok := s.checkFixedLoadout(t, task, a, distance, rounds, miss); ok {
+				out = append(out, candidate{task: ti, aircraft: a.Name, distance: distance, weapons: task.Weapons, hardpoints: need})
+			}
+			continue
+		}
+		for _, w := range s.problem.Weapons {
+			if !s.weaponMatches(t, &w, a.Type, miss) {
+				continue
+			}
+			if w.RangeKm > 0 && distance >= 0 && distance > w.RangeKm {
+				miss.note(rankRange, fmt.Sprintf("nearest %s aircraft %s is %.0f km away, beyond the %.0f km range of %s",
+					a.Type, a.Name, distance, w.RangeKm, w.Name))
+				continue
+			}
+			need := weaponHardpoints(&w) * rounds
+			if a.Hardpoints > 0 && need > a.Hardpoints {
+				miss.note(rankHardpoints, fmt.Sprintf("%d x %s needs %d hardpoints, aircraft %s has %d",
+					rounds, w.Name, need, a.Name, a.Hardpoints))
+				continue
+			}
+			out = append(out, candidate{
+				task:       ti,
+				aircraft:   a.Name,
+				distance:   distance,
+				weapons:    []Loadout{{Weapon: w.Name, Quantity: rounds}},
+				hardpoints: need,
+			})
+		}
+	}
+	return out
+}
+
+// fixedTaskAircraft picks the aircraft for a task whose target is set: the nearest one
+// that can fly it with a suitable loadout, else the nearest one of the task's type.
+func (s *solver) fixedTaskAircraft(ti int, t *Target) (string, float64, bool) {
+	rounds := t.Rounds
+	if rounds <= 0 {
+		rounds = 1
+	}
+	if cands := s.taskCandidates(ti, t, rounds, &missReason{}); len(cands) != 0 {
+		sortCandidates(cands)
+		return cands[0].aircraft, cands[0].distance, true
+	}
+	var best *Aircraft
+	bestDistance := -1.0
+	for _, a := range s.aircraftFor(s.problem.Tasks[ti].AircraftType) {
+		a := a
+		distance := -1.0
+		if a.Location != nil && t.Location != nil {
+			distance = geo.Distance(*a.Location, *t.Location)
+		}
+		if best == nil || distance >= 0 && (bestDistance < 0 || distance < bestDistance) {
+			best, bestDistance = &a, distance
+		}
+	}
+	if best == nil {
+		return "", -1, false
+	}
+	return best.Name, bestDistance, true
+}
+
+// sortCandidates orders options nearest first, then lightest loadout, then by name.
+func sortCandidates(out []candidate) {
+	sort.SliceStable(out, func(i, j int) bool {
+		di, dj := out[i].distance, out[j].distance
+		if di < 0 {
+			di = math.MaxFloat64
+		}
+		if dj < 0 {
+			dj = math.MaxFloat64
+		}
+		if di != dj {
+			return di < dj
+		}
+		if out[i].hardpoints != out[j].hardpoints {
+			return out[i].hardpoints < out[j].hardpoints
+		}
+		if out[i].task != out[j].task {
+			return out[i].task < out[j].task
+		}
+		if out[i].aircraft != out[j].aircraft {
+			return out[i].aircraft < out[j].aircraft
+		}
+		return out[i].weapons[0].Weapon < out[j].weapons[0].Weapon
+	})
+}
+
+// checkFixedLoadout accepts a task that brings its own weapons on aircraft a if enough of
+// them suit the target and a has the hardpoints for the whole loadout. It returns the
+// hardpoints the loadout uses.
+func (s *solver) checkFixedLoadout(t *Target, task Task, a Aircraft, distance float64, rounds int32, miss *missReason) (int32, bool) {
+	var usable, need int32
+	for _, l := range task.Weapons {
+		w, ok := s.weapons[l.Weapon]
+		if !ok {
+			miss.note(rankNoWeapon, fmt.Sprintf("weapon %s of task %s not found", l.Weapon, task.Name))
+			continue
+		}
+		need += weaponHardpoints(w) * l.Quantity
+		if !s.weaponMatches(t, w, a.Type, miss) {
+			continue
+		}
+		if w.RangeKm > 0 && distance >= 0 && distance > w.RangeKm {
+			miss.note(rankRange, fmt.Sprintf("target is %.0f km away, beyond the %.0f km range of %s on task %s",
+				distance, w.RangeKm, w.Name, task.Name))
+			continue
+		}
+		usable += l.Quantity
+	}
+	if usable < rounds {
+		if usable > 0 {
+			miss.note(rankHardpoints, fmt.Sprintf("task %s carries %d suitable rounds, target needs %d", task.Name, usable, rounds))
+		}
+		return need, false
+	}
+	if a.Hardpoints > 0 && need > a.Hardpoints {
+		miss.note(rankHardpoints, fmt.Sprintf("loadout of task %s needs %d hardpoints, aircraft %s has %d",
+			task.Name, need, a.Name, a.Hardpoints))
+		return need, false
+	}
+	return need, true
+}
+
+// weaponHardpoints is the number of hardpoints one round of w uses.
+func weaponHardpoints(w *Weapon) int32 {
+	if w.Hardpoints <= 0 {
+		return 1
+	}
+	return w.Hardpoints
+}
+
+func (s *solver) weaponMatches(t *Target, w *Weapon, aircraftType string, miss *missReason) bool {
+	if len(w.AircraftTypes) != 0 && aircraftType != "" && !containsFold(w.AircraftTypes, aircraftType) {
+		miss.note(rankNoWeapon, fmt.Sprintf("no suitable weapon is compatible with %s", aircraftType))
+		return false
+	}
+	if len(t.Guidance) != 0 && !containsFold(t.Guidance, w.Guidance) {
+		miss.note(rankNoWeapon, fmt.Sprintf("no weapon with guidance %s", strings.Join(t.Guidance, "/")))
+		return false
+	}
+	if len(t.Warheads) != 0 && !containsFold(t.Warheads, w.Warhead) {
+		miss.note(rankNoWeapon, fmt.Sprintf("no weapon with warhead %s", strings.Join(t.Warheads, "/")))
+		return false
+	}
+	return true
+}
+
+// aircraftFor returns the unused aircraft a task of the given type can fly.
+func (s *solver) aircraftFor(aircraftType string) []Aircraft {
+	var out []Aircraft
+	for _, a := range s.problem.Aircraft {
+		if s.usedAircraft[a.Name] {
+			continue
+		}
+		if aircraftType != "" && !strings.EqualFold(a.Type, aircraftType) {
+			continue
+		}
+		out = append(out, a)
+	}
+	return out
+}
+
+// Miss ranks: a higher rank got closer to a feasible assignment and explains the target best.
+const (
+	rankNoTask = iota + 1
+	rankNoAircraft
+	rankNoWeapon
+	rankHardpoints
+	rankRange
+)
+
+type missReason struct {
+	rank int
+	msg  string
+}
+
+func (m *missReason) note(rank int, msg string) {
+	if rank > m.rank {
+		m.rank, m.msg = rank, msg
+	}
+}
+
+func (m *missReason) String() string {
+	if m.msg == "" {
+		return "no eligible task"
+	}
+	return m.msg
+}
+
+func typeOrAny(t string) string {
+	if t == "" {
+		return "ready"
+	}
+	return t
+}
+
+func containsFold(values []string, needle string) bool {
+	for _, v := range values {
+		if strings.EqualFold(strings.TrimSpace(v), strings.TrimSpace(needle)) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,155 @@
+/*
+Copyright 2026 yydashuai.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package planning
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/yydashuai/mission-system/internal/geo"
+)
+
+func TestSolve(t *testing.T) {
+	problem := Problem{
+		Targets: []Target{
+			{Name: "port", Priority: 2, Location: &geo.Point{Lat: 22.0, Lon: 114.0}},
+			{Name: "radar", Priority: 3, Location: &geo.Point{Lat: 40.0, Lon: 116.0}, Guidance: []string{"radar"}},
+			{Name: "bunker", Priority: 1, Location: &geo.Point{Lat: 30.0, Lon: 110.0}, Warheads: []string{"penetrator"}},
+			{Name: "far", Priority: 1, Location: &geo.Point{Lat: -30.0, Lon: 0}},
+		},
+		Tasks: []Task{
+			{Stage: "strike", Name: "s1", AircraftType: "j20"},
+			{Stage: "strike", Name: "s2", AircraftType: "j20"},
+			{Stage: "strike", Name: "s3", AircraftType: "j20"},
+			{Stage: "strike", Name: "s4", AircraftType: "j20"},
+		},
+		Aircraft: []Aircraft{
+			{Name: "north", Type: "j20", Location: &geo.Point{Lat: 40.1, Lon: 116.1}, Hardpoints: 4},
+			{Name: "south", Type: "j20", Location: &geo.Point{Lat: 22.1, Lon: 114.1}, Hardpoints: 1},
+			{Name: "west", Type: "j20", Location: &geo.Point{Lat: 30.1, Lon: 110.1}, Hardpoints: 4},
+			{Name: "spare", Type: "j20", Location: &geo.Point{Lat: 31.0, Lon: 111.0}, Hardpoints: 4},
+		},
+		Weapons: []Weapon{
+			{Name: "pl-15", RangeKm: 200, Guidance: "radar", Warhead: "he", Hardpoints: 1, AircraftTypes: []string{"j20"}},
+			{Name: "kd-88", RangeKm: 150, Guidance: "tv", Warhead: "he", Hardpoints: 2},
+		},
+	}
+
+	plan := Solve(problem)
+	got := map[string]Assignment{}
+	for _, a := range plan.Assignments {
+		got[a.Target] = a
+	}
+	if a := got["radar"]; a.Aircraft != "north" || len(a.Weapons) != 1 || a.Weapons[0].Weapon != "pl-15" {
+		t.Errorf("radar assignment = %+v", a)
+	}
+	// south has a single hardpoint, so only the one-hardpoint weapon fits
+	if a := got["port"]; a.Aircraft != "south" || a.Weapons[0].Weapon != "pl-15" {
+		t.Errorf("port assignment = %+v", a)
+	}
+	if plan.Covered != 2 || plan.Total != 4 {
+		t.Errorf("coverage = %d/%d, want 2/4", plan.Covered, plan.Total)
+	}
+
+	reasons := map[string]string{}
+	for _, u := range plan.Unassigned {
+		reasons[u.Target] = u.Reason
+	}
+	if !strings.Contains(reasons["bunker"], "warhead penetrator") {
+		t.Errorf("bunker reason = %q", reasons["bunker"])
+	}
+	if !strings.Contains(reasons["far"], "beyond") {
+		t.Errorf("far reason = %q", reasons["far"])
+	}
+}
+
+func TestSolveKeepsFixedTasks(t *testing.T) {
+	plan := Solve(Problem{
+		Targets: []Target{{Name: "a", Priority: 1}, {Name: "b", Priority: 2}},
+		Tasks: []Task{
+			{Stage: "s", Name: "fixed", Target: "a", Weapons: []Loadout{{Weapon: "w", Quantity: 2}}},
+			{Stage: "s", Name: "free"},
+		},
+		Aircraft: []Aircraft{{Name: "n1", Type: "j20", Hardpoints: 2}, {Name: "n2", Type: "j20", Hardpoints: 2}},
+		Weapons:  []Weapon{{Name: "w", Hardpoints: 1}},
+	})
+	if len(plan.Assignments) != 2 || plan.Covered != 2 || len(plan.Unassigned) != 0 {
+		t.Fatalf("plan = %+v", plan)
+	}
+	if !plan.Assignments[0].Fixed || plan.Assignments[0].Target != "a" || plan.Assignments[0].Aircraft != "n1" {
+		t.Errorf("fixed task = %+v", plan.Assignments[0])
+	}
+	if plan.Assignments[1].Target != "b" || plan.Assignments[1].Aircraft != "n2" {
+		t.Errorf("free task = %+v", plan.Assignments[1])
+	}
+}
+
+func TestSolveFixedTaskTakesItsAircraft(t *testing.T) {
+	plan := Solve(Problem{
+		Targets: []Target{{Name: "a"}, {Name: "b"}},
+		Tasks: []Task{
+			{Stage: "s", Name: "fixed", Target: "a"},
+			{Stage: "s", Name: "free"},
+		},
+		Aircraft: []Aircraft{{Name: "n1", Type: "j20"}},
+		Weapons:  []Weapon{{Name: "w"}},
+	})
+	if len(plan.Unassigned) != 1 || plan.Unassigned[0].Target != "b" {
+		t.Fatalf("expected b unassigned once the fixed task took the only aircraft, plan = %+v", plan)
+	}
+	if plan.Assignments[0].Aircraft != "n1" {
+		t.Errorf("fixed task = %+v", plan.Assignments[0])
+	}
+}
+
+func TestSolveFixedLoadoutChecksAircraft(t *testing.T) {
+	plan := Solve(Problem{
+		Targets: []Target{{Name: "a"}},
+		Tasks:   []Task{{Stage: "s", Name: "t", Weapons: []Loadout{{Weapon: "heavy", Quantity: 2}}}},
+		Aircraft: []Aircraft{
+			{Name: "small", Type: "j20", Hardpoints: 2},
+			{Name: "other", Type: "j10", Hardpoints: 8},
+			{Name: "big", Type: "j20", Hardpoints: 4},
+		},
+		Weapons: []Weapon{{Name: "heavy", Hardpoints: 2, AircraftTypes: []string{"j20"}}},
+	})
+	if len(plan.Assignments) != 1 || plan.Assignments[0].Aircraft != "big" {
+		t.Fatalf("expected the j20 with 4 hardpoints, plan = %+v", plan)
+	}
+
+	plan = Solve(Problem{
+		Targets:  []Target{{Name: "a"}},
+		Tasks:    []Task{{Stage: "s", Name: "t", Weapons: []Loadout{{Weapon: "heavy", Quantity: 2}}}},
+		Aircraft: []Aircraft{{Name: "small", Type: "j20", Hardpoints: 2}},
+		Weapons:  []Weapon{{Name: "heavy", Hardpoints: 2}},
+	})
+	if len(plan.Unassigned) != 1 || !strings.Contains(plan.Unassigned[0].Reason, "needs 4 hardpoints") {
+		t.Fatalf("plan = %+v", plan)
+	}
+}
+
+func TestSolveHardpointLimit(t *testing.T) {
+	plan := Solve(Problem{
+		Targets:  []Target{{Name: "a", Rounds: 3}},
+		Tasks:    []Task{{Stage: "s", Name: "t"}},
+		Aircraft: []Aircraft{{Name: "n1", Hardpoints: 4}},
+		Weapons:  []Weapon{{Name: "heavy", Hardpoints: 2}},
+	})
+	if len(plan.Unassigned) != 1 || !strings.Contains(plan.Unassigned[0].Reason, "needs 6 hardpoints") {
+		t.Fatalf("plan = %+v", plan)
+	}
+}
@@ -0,0 +1,413 @@
+/*
+Copyright 2026 yydashuai.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package telemetry receives in-flight reports from task and weapon containers and writes
+// them to FlightTask status.
+//
+// Containers POST a report, or a JSON array of reports, to
+// <URL>/v1/namespaces/<namespace>/flighttasks/<name>/telemetry with the task's token as a
+// bearer token. Tokens are an HMAC of the task's namespace and name, so every manager replica
+// sharing the key accepts them. Reports are rate-limited per task and buffered; every flush
+// interval each task with pending reports gets a single status patch carrying the latest
+// snapshot and the new track points.
+package telemetry
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"golang.org/x/time/rate"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/util/retry"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+
+	airforcev1alpha1 "github.com/yydashuai/mission-system/api/v1alpha1"
+	"github.com/yydashuai/mission-system/internal/geo"
+)
+
+const (
+	defaultFlushInterval = 2 * time.Second
+	defaultRate          = 2
+	defaultBurst         = 10
+	defaultTrackLength   = 20
+
+	maxBodyBytes = 1 << 20
+)
+
+var reportsTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+	Name: "airforce_telemetry_reports_total",
+	Help: "Telemetry requests received, by result.",
+}, []string{"result"})
+
+func init() {
+	metrics.Registry.MustRegister(reportsTotal)
+}
+
+// Options configures the telemetry server.
+type Options struct {
+	// BindAddress is the address the server listens on, e.g. ":8090".
+	BindAddress string
+	// URL is the base URL task containers reach the server at, e.g.
+	// http://airforce-mission-system-telemetry.airforce-mission-system-system.svc:8090.
+	URL string
+	// Key signs the per-task tokens. It must be the same on every manager replica.
+	Key []byte
+	// FlushInterval is how often buffered reports are written to FlightTask status.
+	FlushInterval time.Duration
+	// Rate and Burst limit the requests accepted per task.
+	Rate  float64
+	Burst int
+	// TrackLength is the number of positions kept in executionStatus.track.
+	TrackLength int
+}
+
+// Report is one telemetry sample. Unset fields leave the last reported value in place.
+type Report struct {
+	// Time of the sample; the time it was received if unset.
+	Time             *metav1.Time                     `json:"time,omitempty"`
+	Location         *airforcev1alpha1.GeoCoordinates `json:"location,omitempty"`
+	Altitude         string                           `json:"altitude,omitempty"`
+	Speed            string                           `json:"speed,omitempty"`
+	FuelRemaining    *int32                           `json:"fuelRemaining,omitempty"`
+	WeaponsRemaining map[string]int32                 `json:"weaponsRemaining,omitempty"`
+	// Phase is the task phase the aircraft is flying; see executionStatus.reportedPhase.
+	Phase string            `json:"phase,omitempty"`
+	Extra map[string]string `json:"extra,omitempty"`
+}
+
+// Server is a manager Runnable that serves the ingestion endpoint and flushes reports.
+type Server struct {
+	client client.Client
+	opts   Options
+
+	mu       sync.Mutex
+	pending  map[types.NamespacedName][]Report
+	limiters map[types.NamespacedName]*rate.Limiter
+	now      func() time.Time
+}
+
+// NewServer returns a telemetry server writing to FlightTasks through c.
+func NewServer(c client.Client, opts Options) (*Server, error) {
+	if len(opts.Key) == 0 {
+		return nil, errors.New("telemetry token key is empty")
+	}
+	if opts.FlushInterval <= 0 {
+		opts.FlushInterval = defaultFlushInterval
+	}
+	if opts.Rate <= 0 {
+		opts.Rate = defaultRate
+	}
+	if opts.Burst <= 0 {
+		opts.Burst = defaultBurst
+	}
+	if opts.TrackLength <= 0 {
+		opts.TrackLength = defaultTrackLength
+	}
+	if opts.URL == "" {
+		opts.URL = "http://" + opts.BindAddress
+	}
+	opts.URL = strings.TrimSuffix(opts.URL, "/")
+	return &Server{
+		client:   c,
+		opts:     opts,
+		pending:  map[types.NamespacedName][]Report{},
+		limiters: map[types.NamespacedName]*rate.Limiter{},
+		now:      time.Now,
+	}, nil
+}
+
+// Endpoint is the URL a task posts its reports to.
+func (s *Server) Endpoint(namespace, name string) string {
+	return fmt.Sprintf("%s/v1/namespaces/%s/flighttasks/%s/telemetry", s.opts.URL, namespace, name)
+}
+
+// Token is the bearer token that authenticates reports for a task.
+func (s *Server) Token(namespace, name string) string {
+	mac := hmac.New(sha256.New, s.opts.Key)
+	mac.Write([]byte(namespace + "/" + name))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// NeedLeaderElection is false: every replica accepts reports and patches status.
+func (s *Server) NeedLeaderElection() bool {
+	return false
+}
+
+// Start serves until ctx is cancelled, then flushes what is left.
+func (s *Server) Start(ctx context.Context) error {
+	logger := log.FromContext(ctx).WithName("telemetry")
+	ln, err := net.Listen("tcp", s.opts.BindAddress)
+	if err != nil {
+		return err
+	}
+	srv := &http.Server{Handler: s, ReadHeaderTimeout: 10 * time.Second}
+	errCh := make(chan error, 1)
+	go func() {
+		logger.Info("serving telemetry", "address", ln.Addr().String())
+		if err := srv.Serve(ln); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			errCh <- err
+		}
+	}()
+
+	ticker := time.NewTicker(s.opts.FlushInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			s.Flush(ctx)
+		case err := <-errCh:
+			return err
+		case <-ctx.Done():
+			shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+			_ = srv.Shutdown(shutdownCtx)
+			s.Flush(shutdownCtx)
+			return nil
+		}
+	}
+}
+
+// ServeHTTP accepts the reports of one task.
+func (s *Server) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	key, ok := parsePath(req.URL.Path)
+	if !ok {
+		s.reject(w, http.StatusNotFound, "not_found", "unknown path")
+		return
+	}
+	if req.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		s.reject(w, http.StatusMethodNotAllowed, "bad_request", "only POST is supported")
+		return
+	}
+	token, found := strings.CutPrefix(req.Header.Get("Authorization"), "Bearer ")
+	if !found || !hmac.Equal([]byte(token), []byte(s.Token(key.Namespace, key.Name))) {
+		s.reject(w, http.StatusUnauthorized, "unauthorized", "invalid token")
+		return
+	}
+	if !s.limiter(key).Allow() {
+		w.Header().Set("Retry-After", "1")
+		s.reject(w, http.StatusTooManyRequests, "rate_limited", "too many reports")
+		return
+	}
+
+	reports, err := decodeReports(io.LimitReader(req.Body, maxBodyBytes))
+	if err != nil {
+		s.reject(w, http.StatusBadRequest, "bad_request", err.Error())
+		return
+	}
+
+	var task airforcev1alpha1.FlightTask
+	if err := s.client.Get(req.Context(), key, &task); err != nil {
+		if apierrors.IsNotFound(err) {
+			s.reject(w, http.StatusNotFound, "not_found", "flighttask not found")
+			return
+		}
+		s.reject(w, http.StatusServiceUnavailable, "error", err.Error())
+		return
+	}
+	if task.Status.Phase == airforcev1alpha1.FlightTaskPhaseSucceeded || task.Status.Phase == airforcev1alpha1.FlightTaskPhaseFailed {
+		s.reject(w, http.StatusConflict, "finished", "flighttask is finished")
+		return
+	}
+
+	now := s.now()
+	for i := range reports {
+		if reports[i].Time == nil {
+			reports[i].Time = &metav1.Time{Time: now}
+		}
+	}
+	s.mu.Lock()
+	s.pending[key] = append(s.pending[key], reports...)
+	s.mu.Unlock()
+	reportsTotal.WithLabelValues("accepted").Inc()
+	w.WriteHeader(http.StatusAccepted)
+}
+
+func (s *Server) reject(w http.ResponseWriter, status int, result, msg string) {
+	reportsTotal.WithLabelValues(result).Inc()
+	http.Error(w, msg, status)
+}
+
+func (s *Server) limiter(key types.NamespacedName) *rate.Limiter {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	l, ok := s.limiters[key]
+	if !ok {
+		l = rate.NewLimiter(rate.Limit(s.opts.Rate), s.opts.Burst)
+		s.limiters[key] = l
+	}
+	return l
+}
+
+// Flush writes the buffered reports, one status patch per task. Reports for tasks that
+// are gone are dropped; on other errors they are kept for the next flush. Rate limiters
+// that refilled are released, so finished tasks do not keep theirs.
+func (s *Server) Flush(ctx context.Context) {
+	defer s.pruneLimiters()
+
+	logger := log.FromContext(ctx).WithName("telemetry")
+	s.mu.Lock()
+	pending := s.pending
+	s.pending = map[types.NamespacedName][]Report{}
+	s.mu.Unlock()
+
+	for key, reports := range pending {
+		err := retry.RetryOnConflict(retry.DefaultRetry, func() error {
+			var task airforcev1alpha1.FlightTask
+			if err := s.client.Get(ctx, key, &task); err != nil {
+				return err
+			}
+			patch := client.MergeFromWithOptions(task.DeepCopy(), client.MergeFromWithOptimisticLock{})
+			if task.Status.ExecutionStatus == nil {
+				task.Status.ExecutionStatus = &airforcev1alpha1.ExecutionStatus{}
+			}
+			applyReports(task.Status.ExecutionStatus, reports, s.opts.TrackLength)
+			return s.client.Status().Patch(ctx, &task, patch)
+		})
+		switch {
+		case err == nil:
+		case apierrors.IsNotFound(err):
+			s.mu.Lock()
+			delete(s.limiters, key)
+			s.mu.Unlock()
+		default:
+			logger.Error(err, "failed to write telemetry", "flightTask", key.String())
+			s.mu.Lock()
+			s.pending[key] = append(reports, s.pending[key]...)
+			s.mu.Unlock()
+		}
+	}
+}
+
+// pruneLimiters drops the limiters of tasks with no buffered reports whose bucket is
+// full again; a new limiter for the same task would allow exactly the same requests.
+func (s *Server) pruneLimiters() {
+	now := time.Now()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for key, l := range s.limiters {
+		if _, ok := s.pending[key]; !ok && l.TokensAt(now) >= float64(l.Burst()) {
+			delete(s.limiters, key)
+		}
+	}
+}
+
+// applyReports merges reports into the execution status in time order.
+func applyReports(exec *airforcev1alpha1.ExecutionStatus, reports []Report, trackLength int) {
+	sort.SliceStable(reports, func(i, j int) bool {
+		return reports[i].Time.Before(reports[j].Time)
+	})
+	for _, r := range reports {
+		if exec.LastReportTime != nil && r.Time.Before(exec.LastReportTime) {
+			continue
+		}
+		exec.LastReportTime = r.Time.DeepCopy()
+		if r.Location != nil {
+			exec.Location = r.Location.DeepCopy()
+			exec.Track = append(exec.Track, airforcev1alpha1.TrackPoint{
+				Time:     *r.Time.DeepCopy(),
+				Location: *r.Location,
+				Altitude: r.Altitude,
+				Speed:    r.Speed,
+			})
+		}
+		if r.Altitude != "" {
+			exec.Altitude = r.Altitude
+		}
+		if r.Speed != "" {
+			exec.Speed = r.Speed
+		}
+		if r.FuelRemaining != nil {
+			exec.FuelRemaining = *r.FuelRemaining
+		}
+		if r.Phase != "" {
+			exec.ReportedPhase = r.Phase
+		}
+		for k, v := range r.WeaponsRemaining {
+			if exec.WeaponsRemaining == nil {
+				exec.WeaponsRemaining = map[string]int32{}
+			}
+			exec.WeaponsRemaining[k] = v
+		}
+		for k, v := range r.Extra {
+			if exec.Extra == nil {
+				exec.Extra = map[string]string{}
+			}
+			exec.Extra[k] = v
+		}
+	}
+	if len(exec.Track) > trackLength {
+		exec.Track = append([]airforcev1alpha1.TrackPoint(nil), exec.Track[len(exec.Track)-trackLength:]...)
+	}
+}
+
+// decodeReports reads a single report or an array of reports and validates them.
+func decodeReports(r io.Reader) ([]Report, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	var reports []Report
+	if trimmed := strings.TrimSpace(string(data)); strings.HasPrefix(trimmed, "[") {
+		err = json.Unmarshal(data, &reports)
+	} else {
+		var report Report
+		err = json.Unmarshal(data, &report)
+		reports = []Report{report}
+	}
+	if err != nil {
+		return nil, fmt.Errorf("invalid report: %w", err)
+	}
+	if len(reports) == 0 {
+		return nil, errors.New("no reports")
+	}
+	for i, report := range reports {
+		if report.Location == nil {
+			continue
+		}
+		if _, err := geo.ParsePoint(report.Location.Latitude, report.Location.Longitude); err != nil {
+			return nil, fmt.Errorf("report %d: %w", i, err)
+		}
+	}
+	return reports, nil
+}
+
+// parsePath extracts the task from /v1/namespaces/<namespace>/flighttasks/<name>/telemetry.
+func parsePath(path string) (types.NamespacedName, bool) {
+	parts := strings.Split(strings.Trim(path, "/"), "/")
+	if len(parts) != 6 || parts[0] != "v1" || parts[1] != "namespaces" || parts[3] != "flighttasks" || parts[5] != "telemetry" ||
+		parts[2] == "" || parts[4] == "" {
+		return types.NamespacedName{}, false
+	}
+	return types.NamespacedName{Namespace: parts[2], Name: parts[4]}, true
+}
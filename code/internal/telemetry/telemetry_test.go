@@ -0,0 +1,180 @@
+/*
+Copyright 2026 yydashuai.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package telemetry
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	airforcev1alpha1 "github.com/yydashuai/mission-system/api/v1alpha1"
+)
+
+func newTestServer(t *testing.T, task *airforcev1alpha1.FlightTask, opts Options) (*Server, client.Client) {
+	t.Helper()
+	scheme := runtime.NewScheme()
+	if err := airforcev1alpha1.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+	c := fake.NewClientBuilder().WithScheme(scheme).
+		WithObjects(task).WithStatusSubresource(&airforcev1alpha1.FlightTask{}).Build()
+	opts.Key = []byte("test-key")
+	s, err := NewServer(c, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return s, c
+}
+
+func post(s *Server, path, token, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, path, strings.NewReader(body))
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	rec := httptest.NewRecorder()
+	s.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestServeHTTP(t *testing.T) {
+	task := &airforcev1alpha1.FlightTask{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "strike"}}
+	s, _ := newTestServer(t, task, Options{URL: "http://telemetry:8090/", Rate: 1, Burst: 2})
+	path := "/v1/namespaces/default/flighttasks/strike/telemetry"
+	token := s.Token("default", "strike")
+
+	if got := s.Endpoint("default", "strike"); got != "http://telemetry:8090"+path {
+		t.Errorf("Endpoint = %q", got)
+	}
+	if s.Token("default", "other") == token {
+		t.Error("tokens of different tasks are equal")
+	}
+
+	cases := []struct {
+		name  string
+		path  string
+		token string
+		body  string
+		want  int
+	}{
+		{"unknown path", "/v1/flighttasks/strike", token, `{}`, http.StatusNotFound},
+		{"no token", path, "", `{}`, http.StatusUnauthorized},
+		{"token of another task", path, s.Token("default", "other"), `{}`, http.StatusUnauthorized},
+		{"invalid location", path, token, `{"location":{"latitude":"95","longitude":"116"}}`, http.StatusBadRequest},
+		{"accepted", path, token, `[{"location":{"latitude":"39.9","longitude":"116.4"}},{"phase":"strike"}]`, http.StatusAccepted},
+		{"rate limited", path, token, `{}`, http.StatusTooManyRequests},
+		{"unknown task", "/v1/namespaces/default/flighttasks/other/telemetry", s.Token("default", "other"), `{}`, http.StatusNotFound},
+	}
+	for _, tc := range cases {
+		if rec := post(s, tc.path, tc.token, tc.body); rec.Code != tc.want {
+			t.Errorf("%s: status = %d, want %d (%s)", tc.name, rec.Code, tc.want, rec.Body.String())
+		}
+	}
+
+	req := httptest.NewRequest(http.MethodGet, path, nil)
+	rec := httptest.NewRecorder()
+	s.ServeHTTP(rec, req)
+	if rec.Code != http.StatusMethodNotAllowed {
+		t.Errorf("GET: status = %d", rec.Code)
+	}
+}
+
+func TestServeHTTPFinishedTask(t *testing.T) {
+	task := &airforcev1alpha1.FlightTask{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "strike"}}
+	task.Status.Phase = airforcev1alpha1.FlightTaskPhaseSucceeded
+	s, _ := newTestServer(t, task, Options{})
+	rec := post(s, "/v1/namespaces/default/flighttasks/strike/telemetry", s.Token("default", "strike"), `{"speed":"800km/h"}`)
+	if rec.Code != http.StatusConflict {
+		t.Errorf("status = %d, want %d", rec.Code, http.StatusConflict)
+	}
+}
+
+func TestFlushPrunesLimiters(t *testing.T) {
+	task := &airforcev1alpha1.FlightTask{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "strike"}}
+	task.Status.Phase = airforcev1alpha1.FlightTaskPhaseSucceeded
+	s, _ := newTestServer(t, task, Options{Rate: 1000, Burst: 1})
+	post(s, "/v1/namespaces/default/flighttasks/strike/telemetry", s.Token("default", "strike"), `{}`)
+	if len(s.limiters) != 1 {
+		t.Fatalf("limiters = %d, want 1", len(s.limiters))
+	}
+	time.Sleep(10 * time.Millisecond)
+	s.Flush(context.Background())
+	if len(s.limiters) != 0 {
+		t.Errorf("limiters = %d after flush, want 0", len(s.limiters))
+	}
+}
+
+func TestFlush(t *testing.T) {
+	task := &airforcev1alpha1.FlightTask{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "strike"}}
+	s, c := newTestServer(t, task, Options{TrackLength: 2})
+	path := "/v1/namespaces/default/flighttasks/strike/telemetry"
+	token := s.Token("default", "strike")
+
+	start := time.Date(2026, 1, 1, 8, 0, 0, 0, time.UTC)
+	s.now = func() time.Time { return start.Add(time.Hour) }
+	body := `[
+		{"time":"2026-01-01T08:02:00Z","location":{"latitude":"39.92","longitude":"116.42"},"altitude":"9000m","phase":"ingress"},
+		{"time":"2026-01-01T08:00:00Z","location":{"latitude":"39.90","longitude":"116.40"}},
+		{"time":"2026-01-01T08:01:00Z","location":{"latitude":"39.91","longitude":"116.41"},"fuelRemaining":80},
+		{"speed":"850km/h","weaponsRemaining":{"pl-15":2}}
+	]`
+	if rec := post(s, path, token, body); rec.Code != http.StatusAccepted {
+		t.Fatalf("status = %d (%s)", rec.Code, rec.Body.String())
+	}
+	s.Flush(context.Background())
+
+	var got airforcev1alpha1.FlightTask
+	if err := c.Get(context.Background(), types.NamespacedName{Namespace: "default", Name: "strike"}, &got); err != nil {
+		t.Fatal(err)
+	}
+	exec := got.Status.ExecutionStatus
+	if exec == nil {
+		t.Fatal("executionStatus not set")
+	}
+	if exec.Location == nil || exec.Location.Latitude != "39.92" {
+		t.Errorf("location = %+v", exec.Location)
+	}
+	if len(exec.Track) != 2 || exec.Track[0].Location.Latitude != "39.91" || exec.Track[1].Altitude != "9000m" {
+		t.Errorf("track = %+v", exec.Track)
+	}
+	if exec.ReportedPhase != "ingress" || exec.FuelRemaining != 80 || exec.Speed != "850km/h" || exec.WeaponsRemaining["pl-15"] != 2 {
+		t.Errorf("executionStatus = %+v", exec)
+	}
+	if exec.LastReportTime == nil || !exec.LastReportTime.Time.Equal(start.Add(time.Hour)) {
+		t.Errorf("lastReportTime = %v", exec.LastReportTime)
+	}
+
+	// 早于上次上报时间的样本被丢弃
+	if rec := post(s, path, token, `{"time":"2026-01-01T08:30:00Z","phase":"egress"}`); rec.Code != http.StatusAccepted {
+		t.Fatalf("status = %d", rec.Code)
+	}
+	s.Flush(context.Background())
+	if err := c.Get(context.Background(), types.NamespacedName{Namespace: "default", Name: "strike"}, &got); err != nil {
+		t.Fatal(err)
+	}
+	if got.Status.ExecutionStatus.ReportedPhase != "ingress" {
+		t.Errorf("stale report applied: reportedPhase = %q", got.Status.ExecutionStatus.ReportedPhase)
+	}
+}
@@ -0,0 +1,117 @@
+/*
+Copyright 2026 yydashuai.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package tracing
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// WrapClient returns a client that records a span for every write
+// (create, update, patch, delete and status writes). Reads go to the cache
+// and are not traced.
+func WrapClient(c client.Client) client.Client {
+	return &tracingClient{Client: c}
+}
+
+type tracingClient struct {
+	client.Client
+}
+
+func (c *tracingClient) startSpan(ctx context.Context, op string, obj client.Object) (context.Context, trace.Span) {
+	kind := obj.GetObjectKind().GroupVersionKind().Kind
+	if kind == "" {
+		if gvk, err := c.Client.GroupVersionKindFor(obj); err == nil {
+			kind = gvk.Kind
+		}
+	}
+	return Tracer().Start(ctx, op+" "+kind,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			attribute.String("k8s.namespace.name", obj.GetNamespace()),
+			attribute.String("airforce.kind", kind),
+			attribute.String("airforce.name", obj.GetName()),
+		))
+}
+
+func (c *tracingClient) Create(ctx context.Context, obj client.Object, opts ...client.CreateOption) error {
+	ctx, span := c.startSpan(ctx, "Create", obj)
+	defer span.End()
+	err := c.Client.Create(ctx, obj, opts...)
+	RecordError(span, err)
+	return err
+}
+
+func (c *tracingClient) Update(ctx context.Context, obj client.Object, opts ...client.UpdateOption) error {
+	ctx, span := c.startSpan(ctx, "Update", obj)
+	defer span.End()
+	err := c.Client.Update(ctx, obj, opts...)
+	RecordError(span, err)
+	return err
+}
+
+func (c *tracingClient) Patch(ctx context.Context, obj client.Object, patch client.Patch, opts ...client.PatchOption) error {
+	ctx, span := c.startSpan(ctx, "Patch", obj)
+	defer span.End()
+	err := c.Client.Patch(ctx, obj, patch, opts...)
+	RecordError(span, err)
+	return err
+}
+
+func (c *tracingClient) Delete(ctx context.Context, obj client.Object, opts ...client.DeleteOption) error {
+	ctx, span := c.startSpan(ctx, "Delete", obj)
+	defer span.End()
+	err := c.Client.Delete(ctx, obj, opts...)
+	RecordError(span, err)
+	return err
+}
+
+func (c *tracingClient) Status() client.SubResourceWriter {
+	return &tracingStatusWriter{client: c, writer: c.Client.Status()}
+}
+
+type tracingStatusWriter struct {
+	client *tracingClient
+	writer client.SubResourceWriter
+}
+
+func (w *tracingStatusWriter) Create(ctx context.Context, obj client.Object, subResource client.Object, opts ...client.SubResourceCreateOption) error {
+	ctx, span := w.client.startSpan(ctx, "CreateStatus", obj)
+	defer span.End()
+	err := w.writer.Create(ctx, obj, subResource, opts...)
+	RecordError(span, err)
+	return err
+}
+
+func (w *tracingStatusWriter) Update(ctx context.Context, obj client.Object, opts ...client.SubResourceUpdateOption) error {
+	ctx, span := w.client.startSpan(ctx, "UpdateStatus", obj)
+	defer span.End()
+	err := w.writer.Update(ctx, obj, opts...)
+	RecordError(span, err)
+	return err
+}
+
+func (w *tracingStatusWriter) Patch(ctx context.Context, obj client.Object, patch client.Patch, opts ...client.SubResourcePatchOption) error {
+	ctx, span := w.client.startSpan(ctx, "PatchStatus", obj)
+	defer span.End()
+	err := w.writer.Patch(ctx, obj, patch, opts...)
+	RecordError(span, err)
+	return err
+}
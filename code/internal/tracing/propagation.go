@@ -0,0 +1,89 @@
+/*
+Copyright 2026 yydashuai.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package tracing
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// TraceParentAnnotation carries the W3C traceparent of the span that created the object.
+	TraceParentAnnotation = "airforce.mil/traceparent"
+	// TraceStateAnnotation carries the W3C tracestate, if any.
+	TraceStateAnnotation = "airforce.mil/tracestate"
+)
+
+var propagator = propagation.TraceContext{}
+
+// annotationCarrier adapts object annotations to propagation.TextMapCarrier,
+// mapping the W3C header names to the airforce.mil annotation keys.
+type annotationCarrier struct {
+	obj metav1.Object
+}
+
+func (c annotationCarrier) key(header string) string {
+	switch header {
+	case "traceparent":
+		return TraceParentAnnotation
+	case "tracestate":
+		return TraceStateAnnotation
+	}
+	return ""
+}
+
+func (c annotationCarrier) Get(header string) string {
+	key := c.key(header)
+	if key == "" {
+		return ""
+	}
+	return c.obj.GetAnnotations()[key]
+}
+
+func (c annotationCarrier) Set(header, value string) {
+	key := c.key(header)
+	if key == "" {
+		return
+	}
+	annotations := c.obj.GetAnnotations()
+	if annotations == nil {
+		annotations = map[string]string{}
+	}
+	annotations[key] = value
+	c.obj.SetAnnotations(annotations)
+}
+
+func (c annotationCarrier) Keys() []string {
+	return []string{"traceparent", "tracestate"}
+}
+
+// Inject writes the span context in ctx into obj's annotations. Call it on
+// objects that are about to be created so their reconciles link back to it.
+func Inject(ctx context.Context, obj metav1.Object) {
+	if !trace.SpanContextFromContext(ctx).IsValid() {
+		return
+	}
+	propagator.Inject(ctx, annotationCarrier{obj: obj})
+}
+
+// Extract returns ctx with the remote span context stored in obj's annotations, if any.
+func Extract(ctx context.Context, obj metav1.Object) context.Context {
+	return propagator.Extract(ctx, annotationCarrier{obj: obj})
+}
@@ -0,0 +1,196 @@
+/*
+Copyright 2026 yydashuai.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package tracing wires OpenTelemetry tracing into the controllers.
+//
+// Every reconcile starts a trace of its own, so a requeued object doesn't grow
+// one trace forever. The reconcile span is linked to the object's origin: a
+// Mission to the trace ID derived from its UID, and MissionStages, FlightTasks
+// and Pods to the reconcile that created them, whose W3C trace context is handed
+// down through the TraceParentAnnotation. Following the links leads from a Pod
+// back to its Mission.
+package tracing
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+// InstrumentationName is the name of the tracer used by all controllers.
+const InstrumentationName = "github.com/yydashuai/mission-system"
+
+// Options configures the OTLP trace pipeline.
+type Options struct {
+	// Endpoint is the base URL of the OTLP/HTTP receiver, e.g. http://otel-collector:4318.
+	// Tracing is disabled when empty.
+	Endpoint string
+	// ServiceName is reported as the service.name resource attribute.
+	ServiceName string
+	// Headers are added to every export request (e.g. authentication).
+	Headers map[string]string
+	// Timeout bounds a single export request.
+	Timeout time.Duration
+}
+
+// Setup installs a global TracerProvider that exports spans over OTLP/HTTP.
+// The returned function flushes pending spans and must be called on shutdown.
+// When opts.Endpoint is empty tracing stays disabled and a no-op shutdown is returned.
+func Setup(opts Options) (func(context.Context) error, error) {
+	if opts.Endpoint == "" {
+		return func(context.Context) error { return nil }, nil
+	}
+	exporterOpts, err := exporterOptions(opts)
+	if err != nil {
+		return nil, err
+	}
+	exporter, err := otlptracehttp.New(context.Background(), exporterOpts...)
+	if err != nil {
+		return nil, err
+	}
+	serviceName := opts.ServiceName
+	if serviceName == "" {
+		serviceName = "mission-system-controller-manager"
+	}
+	tp := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(resource.NewSchemaless(attribute.String("service.name", serviceName))),
+	)
+	otel.SetTracerProvider(tp)
+	return tp.Shutdown, nil
+}
+
+// exporterOptions translates opts into otlptracehttp options. An endpoint that
+// already ends in /v1/traces is used as is.
+func exporterOptions(opts Options) ([]otlptracehttp.Option, error) {
+	u, err := url.Parse(opts.Endpoint)
+	if err != nil {
+		return nil, fmt.Errorf("invalid OTLP endpoint %q: %w", opts.Endpoint, err)
+	}
+	if u.Scheme != "http" && u.Scheme != "https" || u.Host == "" {
+		return nil, fmt.Errorf("invalid OTLP endpoint %q: must be an http or https URL", opts.Endpoint)
+	}
+	path := u.Path
+	if !strings.HasSuffix(path, "/v1/traces") {
+		path = strings.TrimSuffix(path, "/") + "/v1/traces"
+	}
+	exporterOpts := []otlptracehttp.Option{
+		otlptracehttp.WithEndpoint(u.Host),
+		otlptracehttp.WithURLPath(path),
+	}
+	if u.Scheme == "http" {
+		exporterOpts = append(exporterOpts, otlptracehttp.WithInsecure())
+	}
+	if len(opts.Headers) > 0 {
+		exporterOpts = append(exporterOpts, otlptracehttp.WithHeaders(opts.Headers))
+	}
+	if opts.Timeout > 0 {
+		exporterOpts = append(exporterOpts, otlptracehttp.WithTimeout(opts.Timeout))
+	}
+	return exporterOpts, nil
+}
+
+// Tracer returns the tracer from the global TracerProvider.
+func Tracer() trace.Tracer {
+	return otel.Tracer(InstrumentationName)
+}
+
+// StartReconcile starts the span for one reconcile of obj, as the root of a new
+// trace. The span is linked to the context in the object's TraceParentAnnotation;
+// a Mission without one is linked to the trace derived from its UID. The returned
+// context carries a logger tagged with the trace ID so log lines can be matched
+// to the trace.
+func StartReconcile(ctx context.Context, kind string, obj metav1.Object) (context.Context, trace.Span) {
+	origin := trace.SpanContextFromContext(Extract(ctx, obj))
+	if !origin.IsValid() && kind == "Mission" {
+		origin = missionSpanContext(obj)
+	}
+	startOpts := []trace.SpanStartOption{
+		trace.WithNewRoot(),
+		trace.WithAttributes(
+			attribute.String("k8s.namespace.name", obj.GetNamespace()),
+			attribute.String("airforce.kind", kind),
+			attribute.String("airforce.name", obj.GetName()),
+		),
+	}
+	if origin.IsValid() {
+		startOpts = append(startOpts, trace.WithLinks(trace.Link{SpanContext: origin}))
+	}
+	ctx, span := Tracer().Start(ctx, "Reconcile "+kind, startOpts...)
+	if mission := obj.GetLabels()["mission"]; mission != "" {
+		span.SetAttributes(attribute.String("airforce.mission", mission))
+	}
+	if sc := span.SpanContext(); sc.HasTraceID() {
+		ctx = log.IntoContext(ctx, log.FromContext(ctx).WithValues("traceID", sc.TraceID().String()))
+	}
+	return ctx, span
+}
+
+// RecordError marks span as failed when err is non-nil.
+func RecordError(span trace.Span, err error) {
+	if err == nil {
+		return
+	}
+	span.RecordError(err)
+	span.SetStatus(codes.Error, err.Error())
+}
+
+// missionSpanContext returns a remote span context whose trace ID is the Mission
+// UID, so that every reconcile of the same Mission links to one trace ID.
+func missionSpanContext(obj metav1.Object) trace.SpanContext {
+	traceID, spanID, err := idsFromUID(string(obj.GetUID()))
+	if err != nil {
+		return trace.SpanContext{}
+	}
+	return trace.NewSpanContext(trace.SpanContextConfig{
+		TraceID:    traceID,
+		SpanID:     spanID,
+		TraceFlags: trace.FlagsSampled,
+		Remote:     true,
+	})
+}
+
+func idsFromUID(uid string) (trace.TraceID, trace.SpanID, error) {
+	var hex []byte
+	for i := 0; i < len(uid); i++ {
+		if uid[i] != '-' {
+			hex = append(hex, uid[i])
+		}
+	}
+	traceID, err := trace.TraceIDFromHex(string(hex))
+	if err != nil {
+		return trace.TraceID{}, trace.SpanID{}, err
+	}
+	var spanID trace.SpanID
+	copy(spanID[:], traceID[8:])
+	if !spanID.IsValid() {
+		return trace.TraceID{}, trace.SpanID{}, errors.New("uid does not yield a valid span id")
+	}
+	return traceID, spanID, nil
+}
@@ -0,0 +1,204 @@
+/*
+Copyright 2026 yydashuai.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package tracing
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"go.opentelemetry.io/otel"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func installInMemoryProvider(t *testing.T) *tracetest.InMemoryExporter {
+	t.Helper()
+	exporter := tracetest.NewInMemoryExporter()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter))
+	previous := otel.GetTracerProvider()
+	otel.SetTracerProvider(tp)
+	t.Cleanup(func() {
+		_ = tp.Shutdown(context.Background())
+		otel.SetTracerProvider(previous)
+	})
+	return exporter
+}
+
+func TestReconcilesLinkToTheirOrigin(t *testing.T) {
+	exporter := installInMemoryProvider(t)
+
+	mission := &metav1.ObjectMeta{Name: "strike", Namespace: "default", UID: "0f8fad5b-d9cb-469f-a165-70867728950e"}
+	ctx, missionSpan := StartReconcile(context.Background(), "Mission", mission)
+	stage := &metav1.ObjectMeta{Name: "strike-ingress", Namespace: "default", Labels: map[string]string{"mission": "strike"}}
+	Inject(ctx, stage)
+	missionSpan.End()
+
+	if stage.Annotations[TraceParentAnnotation] == "" {
+		t.Fatalf("expected %s annotation on stage", TraceParentAnnotation)
+	}
+
+	ctx, stageSpan := StartReconcile(context.Background(), "MissionStage", stage)
+	pod := &metav1.ObjectMeta{Name: "strike-ingress-lead-pod", Namespace: "default"}
+	Inject(ctx, pod)
+	stageSpan.End()
+
+	_, podSpan := StartReconcile(context.Background(), "Pod", pod)
+	podSpan.End()
+
+	spans := exporter.GetSpans()
+	if len(spans) != 3 {
+		t.Fatalf("expected 3 spans, got %d", len(spans))
+	}
+	traces := map[string]bool{}
+	for _, s := range spans {
+		if s.Parent.IsValid() {
+			t.Errorf("span %q has parent %s, want a root span", s.Name, s.Parent.SpanID())
+		}
+		if len(s.Links) != 1 {
+			t.Fatalf("span %q has %d links, want 1", s.Name, len(s.Links))
+		}
+		traces[s.SpanContext.TraceID().String()] = true
+	}
+	if len(traces) != 3 {
+		t.Errorf("expected every reconcile in a trace of its own, got %d traces", len(traces))
+	}
+	if got, want := spans[0].Links[0].SpanContext.TraceID().String(), "0f8fad5bd9cb469fa16570867728950e"; got != want {
+		t.Errorf("mission span links to trace %s, want %s", got, want)
+	}
+	if spans[1].Links[0].SpanContext.SpanID() != spans[0].SpanContext.SpanID() {
+		t.Errorf("stage span links to %s, want mission span %s", spans[1].Links[0].SpanContext.SpanID(), spans[0].SpanContext.SpanID())
+	}
+	if spans[2].Links[0].SpanContext.SpanID() != spans[1].SpanContext.SpanID() {
+		t.Errorf("pod span links to %s, want stage span %s", spans[2].Links[0].SpanContext.SpanID(), spans[1].SpanContext.SpanID())
+	}
+	if spans[1].Name != "Reconcile MissionStage" {
+		t.Errorf("stage span name = %q", spans[1].Name)
+	}
+}
+
+func TestMissionRequeueStartsNewTrace(t *testing.T) {
+	exporter := installInMemoryProvider(t)
+
+	mission := &metav1.ObjectMeta{Name: "strike", Namespace: "default", UID: "0f8fad5b-d9cb-469f-a165-70867728950e"}
+	for i := 0; i < 2; i++ {
+		_, span := StartReconcile(context.Background(), "Mission", mission)
+		span.End()
+	}
+
+	spans := exporter.GetSpans()
+	if len(spans) != 2 {
+		t.Fatalf("expected 2 spans, got %d", len(spans))
+	}
+	if spans[0].SpanContext.TraceID() == spans[1].SpanContext.TraceID() {
+		t.Errorf("both reconciles share trace %s", spans[0].SpanContext.TraceID())
+	}
+	if spans[0].Links[0].SpanContext.TraceID() != spans[1].Links[0].SpanContext.TraceID() {
+		t.Errorf("reconciles link to different Mission traces")
+	}
+}
+
+func TestStartReconcileWithoutParent(t *testing.T) {
+	exporter := installInMemoryProvider(t)
+
+	task := &metav1.ObjectMeta{Name: "standalone", Namespace: "default"}
+	_, span := StartReconcile(context.Background(), "FlightTask", task)
+	span.End()
+
+	spans := exporter.GetSpans()
+	if len(spans) != 1 {
+		t.Fatalf("expected 1 span, got %d", len(spans))
+	}
+	if spans[0].Parent.IsValid() {
+		t.Errorf("expected a root span, got parent %s", spans[0].Parent.SpanID())
+	}
+}
+
+func TestWrapClientRecordsWrites(t *testing.T) {
+	exporter := installInMemoryProvider(t)
+
+	c := WrapClient(fake.NewClientBuilder().WithScheme(clientgoscheme.Scheme).Build())
+	pod := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "p", Namespace: "default"}}
+	if err := c.Create(context.Background(), pod); err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	if err := c.Create(context.Background(), pod.DeepCopy()); err == nil {
+		t.Fatalf("expected AlreadyExists on second create")
+	}
+
+	spans := exporter.GetSpans()
+	if len(spans) != 2 {
+		t.Fatalf("expected 2 spans, got %d", len(spans))
+	}
+	if spans[0].Name != "Create Pod" {
+		t.Errorf("span name = %q, want %q", spans[0].Name, "Create Pod")
+	}
+	if spans[0].Status.Code.String() != "Unset" || spans[1].Status.Code.String() != "Error" {
+		t.Errorf("unexpected span status codes %s, %s", spans[0].Status.Code, spans[1].Status.Code)
+	}
+}
+
+func TestSetupExportsOverHTTP(t *testing.T) {
+	var contentType, path, auth string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path = r.URL.Path
+		contentType = r.Header.Get("Content-Type")
+		auth = r.Header.Get("Authorization")
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	previous := otel.GetTracerProvider()
+	defer otel.SetTracerProvider(previous)
+	shutdown, err := Setup(Options{Endpoint: server.URL, Headers: map[string]string{"Authorization": "Bearer t"}})
+	if err != nil {
+		t.Fatalf("Setup: %v", err)
+	}
+	_, span := Tracer().Start(context.Background(), "Reconcile Mission")
+	span.End()
+	if err := shutdown(context.Background()); err != nil {
+		t.Fatalf("shutdown: %v", err)
+	}
+
+	if path != "/v1/traces" || contentType != "application/x-protobuf" || auth != "Bearer t" {
+		t.Fatalf("unexpected request %s (%s, auth %q)", path, contentType, auth)
+	}
+}
+
+func TestExporterOptionsEndpoint(t *testing.T) {
+	tests := []struct {
+		endpoint string
+		wantErr  bool
+	}{
+		{endpoint: "http://collector:4318"},
+		{endpoint: "http://collector:4318/"},
+		{endpoint: "https://collector/otlp/v1/traces"},
+		{endpoint: "collector:4318", wantErr: true},
+		{endpoint: "http://", wantErr: true},
+	}
+	for _, tt := range tests {
+		_, err := exporterOptions(Options{Endpoint: tt.endpoint})
+		if tt.wantErr != (err != nil) {
+			t.Errorf("%s: err = %v, wantErr %v", tt.endpoint, err, tt.wantErr)
+		}
+	}
+}
@@ -0,0 +1,131 @@
+/*
+Copyright 2026 yydashuai.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package units parses the free-form quantities used in Weapon specifications and
+// task parameters, e.g. "200km", "120 nm", "450 lb", "Mach 4" or "800km/h".
+package units
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+const (
+	metersPerKilometer    = 1000.0
+	metersPerNauticalMile = 1852.0
+	kilogramsPerPound     = 0.45359237
+	kmhPerKnot            = 1.852
+	kmhPerMeterPerSecond  = 3.6
+	// KmhPerMach is the speed of sound at sea level in the ICAO standard atmosphere.
+	KmhPerMach = 1225.044
+)
+
+var distanceUnits = map[string]float64{
+	"m":   1,
+	"km":  metersPerKilometer,
+	"nm":  metersPerNauticalMile,
+	"nmi": metersPerNauticalMile,
+}
+
+var massUnits = map[string]float64{
+	"kg":  1,
+	"t":   1000,
+	"lb":  kilogramsPerPound,
+	"lbs": kilogramsPerPound,
+}
+
+var speedUnits = map[string]float64{
+	"km/h":  1,
+	"kmh":   1,
+	"kph":   1,
+	"m/s":   kmhPerMeterPerSecond,
+	"kn":    kmhPerKnot,
+	"kt":    kmhPerKnot,
+	"kts":   kmhPerKnot,
+	"knot":  kmhPerKnot,
+	"knots": kmhPerKnot,
+}
+
+// ParseDistance returns a distance in metres. Accepted units are m, km and nm.
+func ParseDistance(s string) (float64, error) {
+	return parse("distance", s, distanceUnits)
+}
+
+// ParseMass returns a mass in kilograms. Accepted units are kg, t and lb.
+func ParseMass(s string) (float64, error) {
+	return parse("mass", s, massUnits)
+}
+
+// ParseSpeed returns a speed in km/h. Accepted units are km/h, m/s and knots, and Mach
+// numbers written as "Mach 4", "M4" or "4 Mach".
+func ParseSpeed(s string) (float64, error) {
+	compact := strings.ToLower(strings.Join(strings.Fields(s), ""))
+	for _, prefix := range []string{"mach", "ma", "m"} {
+		if rest, ok := strings.CutPrefix(compact, prefix); ok && rest != "" && (unicode.IsDigit(rune(rest[0])) || rest[0] == '.') {
+			v, err := positive(rest)
+			if err != nil {
+				return 0, fmt.Errorf("invalid speed %q: %w", s, err)
+			}
+			return v * KmhPerMach, nil
+		}
+	}
+	if rest, ok := strings.CutSuffix(compact, "mach"); ok {
+		v, err := positive(rest)
+		if err != nil {
+			return 0, fmt.Errorf("invalid speed %q: %w", s, err)
+		}
+		return v * KmhPerMach, nil
+	}
+	return parse("speed", s, speedUnits)
+}
+
+func parse(quantity, s string, units map[string]float64) (float64, error) {
+	compact := strings.ToLower(strings.Join(strings.Fields(s), ""))
+	if compact == "" {
+		return 0, fmt.Errorf("empty %s", quantity)
+	}
+	i := 0
+	for i < len(compact) && (compact[i] >= '0' && compact[i] <= '9' || compact[i] == '.' || compact[i] == ',' || compact[i] == '-' || compact[i] == '+') {
+		i++
+	}
+	number, unit := compact[:i], compact[i:]
+	if unit == "" {
+		return 0, fmt.Errorf("invalid %s %q: missing unit", quantity, s)
+	}
+	factor, ok := units[unit]
+	if !ok {
+		return 0, fmt.Errorf("invalid %s %q: unknown unit %q", quantity, s, unit)
+	}
+	v, err := positive(number)
+	if err != nil {
+		return 0, fmt.Errorf("invalid %s %q: %w", quantity, s, err)
+	}
+	return v * factor, nil
+}
+
+// positive parses a number, allowing thousands separators, and rejects values <= 0.
+func positive(s string) (float64, error) {
+	v, err := strconv.ParseFloat(strings.ReplaceAll(s, ",", ""), 64)
+	if err != nil {
+		return 0, fmt.Errorf("%q is not a number", s)
+	}
+	if v <= 0 {
+		return 0, fmt.Errorf("must be positive")
+	}
+	return v, nil
+}
@@ -0,0 +1,72 @@
+/*
+Copyright 2026 yydashuai.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package units
+
+import (
+	"math"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	cases := []struct {
+		parse func(string) (float64, error)
+		in    string
+		want  float64
+	}{
+		{ParseDistance, "200km", 200000},
+		{ParseDistance, "1,200 KM", 1200000},
+		{ParseDistance, "120 nm", 222240},
+		{ParseDistance, "4.2m", 4.2},
+		{ParseMass, "200kg", 200},
+		{ParseMass, "450 lb", 204.1165665},
+		{ParseSpeed, "800km/h", 800},
+		{ParseSpeed, "500 knots", 926},
+		{ParseSpeed, "Mach 4", 4900.176},
+		{ParseSpeed, "M2.5", 3062.61},
+		{ParseSpeed, "4 mach", 4900.176},
+	}
+	for _, c := range cases {
+		got, err := c.parse(c.in)
+		if err != nil {
+			t.Errorf("%q: %v", c.in, err)
+			continue
+		}
+		if math.Abs(got-c.want) > 1e-6 {
+			t.Errorf("%q = %v, want %v", c.in, got, c.want)
+		}
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	cases := []struct {
+		parse func(string) (float64, error)
+		in    string
+	}{
+		{ParseDistance, ""},
+		{ParseDistance, "200"},
+		{ParseDistance, "200 furlongs"},
+		{ParseDistance, "-5km"},
+		{ParseMass, "heavy"},
+		{ParseSpeed, "4.0"},
+		{ParseSpeed, "Mach fast"},
+	}
+	for _, c := range cases {
+		if v, err := c.parse(c.in); err == nil {
+			t.Errorf("%q = %v, want error", c.in, v)
+		}
+	}
+}
@@ -129,6 +129,9 @@ const buildObjective = (objective) => {
   if (!objective) return '--'
   if (objective.targetDescription) return objective.targetDescription
   if (objective.targetArea) return objective.targetArea
+  if (Array.isArray(objective.targets) && objective.targets.length) {
+    return objective.targets.map((target) => target.description || target.name).join(', ')
+  }
   if (objective.targetCoordinates) {
     const { latitude, longitude } = objective.targetCoordinates
     if (latitude || longitude) return `${latitude || '--'}, ${longitude || '--'}`